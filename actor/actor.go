@@ -0,0 +1,516 @@
+package actor
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abmpio/threadingx/lang"
+	"github.com/abmpio/timelinex"
+	"github.com/abmpio/timelinex/threading"
+	"github.com/lithammer/shortuuid/v4"
+)
+
+var (
+	// Ask在超时时间内没有收到回复
+	ErrAskTimeout = errors.New("actor: ask timeout")
+	// actor已经停止
+	ErrActorStopped = errors.New("actor: actor stopped")
+)
+
+// actor的宿主,如timelinex.OneLogicThread
+// actor作为工作项放置在宿主的逻辑线程中,每一帧处理一次邮箱中的消息
+type Host interface {
+	timelinex.ITimeline
+	timelinex.ISceneTimer
+	LogicThread() *threading.LogicThread
+}
+
+// actor的行为,Receive运行在宿主的逻辑线程中
+type Behavior[M any] interface {
+	Receive(ctx *Context[M], msg M)
+}
+
+// 可选接口,actor启动(包括重启)后、处理第一条消息之前调用
+type PreStarter[M any] interface {
+	PreStart(ctx *Context[M])
+}
+
+// 可选接口,actor停止(包括重启前)时调用
+type PostStopper[M any] interface {
+	PostStop(ctx *Context[M])
+}
+
+// 创建actor行为的工厂,重启时会重新调用以获得全新的状态
+type Producer[M any] func() Behavior[M]
+
+type behaviorFunc[M any] func(ctx *Context[M], msg M)
+
+func (f behaviorFunc[M]) Receive(ctx *Context[M], msg M) {
+	f(ctx, msg)
+}
+
+// 根据一个无状态的回调创建Producer
+func FromFunc[M any](receive func(ctx *Context[M], msg M)) Producer[M] {
+	return func() Behavior[M] {
+		return behaviorFunc[M](receive)
+	}
+}
+
+type SpawnOption func(o *spawnOptions)
+
+type spawnOptions struct {
+	name       string
+	supervisor SupervisorStrategy
+}
+
+// 设置actor的名称,默认自动生成
+func SpawnOptionWithName(name string) SpawnOption {
+	return func(o *spawnOptions) {
+		o.name = name
+	}
+}
+
+// 设置actor对其子actor的监督策略,默认为RestartStrategy
+// 顶层actor自身的失败也由这个策略处理
+func SpawnOptionWithSupervisor(strategy SupervisorStrategy) SpawnOption {
+	return func(o *spawnOptions) {
+		o.supervisor = strategy
+	}
+}
+
+// 在宿主的逻辑线程中创建一个顶层actor
+func Spawn[M any](host Host, producer Producer[M], opts ...SpawnOption) *Ref[M] {
+	return spawn[M](host, nil, producer, opts...)
+}
+
+// 创建一个子actor,子actor与父actor运行在同一个逻辑线程中,并受父actor的监督
+// 父actor停止时,子actor也会停止
+func SpawnChild[C any, M any](parent *Context[M], producer Producer[C], opts ...SpawnOption) *Ref[C] {
+	return spawn[C](parent.cell.host, parent.cell, producer, opts...)
+}
+
+func spawn[M any](host Host, parent cell, producer Producer[M], opts ...SpawnOption) *Ref[M] {
+	options := &spawnOptions{
+		name:       shortuuid.New(),
+		supervisor: RestartStrategy,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+	c := &actorCell[M]{
+		id:          options.name,
+		host:        host,
+		producer:    producer,
+		parent:      parent,
+		supervisor:  options.supervisor,
+		mailbox:     make([]M, 0),
+		childList:   make(map[cell]struct{}),
+		timerList:   make(map[string]struct{}),
+		stoppedChan: make(chan struct{}),
+	}
+	if parent != nil {
+		c.id = parent.ID() + "/" + options.name
+		if !parent.addChild(c) {
+			// 父actor已经停止
+			c.stopped.Set(true)
+			close(c.stoppedChan)
+			return &Ref[M]{cell: c}
+		}
+	}
+	c.needStart.Set(true)
+	host.LogicThread().AttatchWorkItem(c)
+	return &Ref[M]{cell: c}
+}
+
+// actor的引用,可以在任意协程中使用
+type Ref[M any] struct {
+	cell *actorCell[M]
+}
+
+// actor的id,子actor的id包含父actor的id
+func (r *Ref[M]) ID() string {
+	return r.cell.id
+}
+
+// 发送一条消息,actor已经停止时返回false
+func (r *Ref[M]) Tell(msg M) bool {
+	return r.cell.tell(msg)
+}
+
+// 请求停止actor,邮箱中尚未处理的消息会被丢弃
+func (r *Ref[M]) Stop() {
+	r.cell.requestStop()
+}
+
+// actor完全停止后关闭的通道
+func (r *Ref[M]) Done() <-chan struct{} {
+	return r.cell.stoppedChan
+}
+
+func (r *Ref[M]) IsStopped() bool {
+	return r.cell.stopped.Get()
+}
+
+// 向actor发送一条需要回复的消息,并等待回复
+// build 根据回复函数构建消息,actor在处理消息时调用回复函数
+// 超时基于宿主的场景计时器,不能在actor所在的逻辑线程中调用,否则会阻塞这个逻辑线程
+func Ask[M any, R any](ref *Ref[M], build func(reply func(R)) M, timeout time.Duration) (R, error) {
+	var empty R
+	resultChan := make(chan R, 1)
+	timeoutChan := make(chan struct{})
+	replied := int32(0)
+	msg := build(func(v R) {
+		if atomic.CompareAndSwapInt32(&replied, 0, 1) {
+			resultChan <- v
+		}
+	})
+	if !ref.Tell(msg) {
+		return empty, ErrActorStopped
+	}
+	timerKey := fmt.Sprintf("actor:%s:ask:%s", ref.cell.id, shortuuid.New())
+	ref.cell.host.StartNewOneTimer(timeout, func() {
+		close(timeoutChan)
+	}, timelinex.SceneTimerOptionWithKey(timerKey), timelinex.SceneTimerOptionWithDontRunInTimelineThread())
+
+	select {
+	case v := <-resultChan:
+		ref.cell.host.RemoveTimer(timerKey)
+		return v, nil
+	case <-timeoutChan:
+		return empty, ErrAskTimeout
+	case <-ref.cell.stoppedChan:
+		ref.cell.host.RemoveTimer(timerKey)
+		return empty, ErrActorStopped
+	}
+}
+
+// actor处理消息时的上下文,只能在actor所在的逻辑线程中使用
+type Context[M any] struct {
+	cell *actorCell[M]
+}
+
+// 自身的引用
+func (c *Context[M]) Self() *Ref[M] {
+	return &Ref[M]{cell: c.cell}
+}
+
+// 停止自身
+func (c *Context[M]) Stop() {
+	c.cell.requestStop()
+}
+
+// 在d时间后向自身发送msg,返回计时器的key,actor停止或重启时计时器自动移除
+func (c *Context[M]) SendAfter(d time.Duration, msg M) string {
+	timelinex.AssertOnLogicThread(c.cell.host)
+	key := c.cell.nextTimerKey()
+	c.cell.timerList[key] = struct{}{}
+	c.cell.host.StartNewOneTimer(d, func() {
+		delete(c.cell.timerList, key)
+		c.cell.tell(msg)
+	}, timelinex.SceneTimerOptionWithKey(key))
+	return key
+}
+
+// 每隔d时间向自身发送msg,返回计时器的key,actor停止或重启时计时器自动移除
+func (c *Context[M]) SendEvery(d time.Duration, msg M) string {
+	timelinex.AssertOnLogicThread(c.cell.host)
+	key := c.cell.nextTimerKey()
+	c.cell.timerList[key] = struct{}{}
+	c.cell.host.StartRecurNewTimer(d, func() {
+		c.cell.tell(msg)
+	}, timelinex.SceneTimerOptionWithKey(key))
+	return key
+}
+
+// 取消由SendAfter或SendEvery启动的计时器
+func (c *Context[M]) CancelTimer(key string) {
+	timelinex.AssertOnLogicThread(c.cell.host)
+	if _, ok := c.cell.timerList[key]; !ok {
+		return
+	}
+	delete(c.cell.timerList, key)
+	c.cell.host.RemoveTimer(key)
+}
+
+// 非泛型的actor,用于父子之间的监督
+type cell interface {
+	ID() string
+	addChild(child cell) bool
+	removeChild(child cell)
+	requestStop()
+	// 由子actor在失败且策略为Escalate时调用
+	escalate(reason interface{})
+	// 父actor对子actor失败的处理
+	decide(child cell, reason interface{}) Directive
+}
+
+var _ threading.IWorkItem = (*actorCell[any])(nil)
+
+type actorCell[M any] struct {
+	id         string
+	host       Host
+	producer   Producer[M]
+	behavior   Behavior[M]
+	parent     cell
+	supervisor SupervisorStrategy
+
+	mailboxLock sync.Mutex
+	mailbox     []M
+
+	// 以下字段只在逻辑线程中访问
+	childLock sync.Mutex
+	childList map[cell]struct{}
+	timerList map[string]struct{}
+	timerSeq  uint64
+
+	needStart     lang.SafeBool
+	stopRequested lang.SafeBool
+	// 由子actor上报的失败,在下一帧处理
+	escalatedLock   sync.Mutex
+	escalatedReason interface{}
+	escalated       bool
+
+	stopped     lang.SafeBool
+	stoppedChan chan struct{}
+}
+
+// #region cell Members
+
+func (c *actorCell[M]) ID() string {
+	return c.id
+}
+
+func (c *actorCell[M]) addChild(child cell) bool {
+	c.childLock.Lock()
+	defer c.childLock.Unlock()
+	if c.stopped.Get() || c.stopRequested.Get() {
+		return false
+	}
+	c.childList[child] = struct{}{}
+	return true
+}
+
+func (c *actorCell[M]) removeChild(child cell) {
+	c.childLock.Lock()
+	defer c.childLock.Unlock()
+	delete(c.childList, child)
+}
+
+func (c *actorCell[M]) requestStop() {
+	c.stopRequested.Set(true)
+}
+
+func (c *actorCell[M]) escalate(reason interface{}) {
+	c.escalatedLock.Lock()
+	defer c.escalatedLock.Unlock()
+	c.escalated = true
+	c.escalatedReason = reason
+}
+
+func (c *actorCell[M]) decide(child cell, reason interface{}) Directive {
+	if c.supervisor == nil {
+		return Restart
+	}
+	return c.supervisor(child.ID(), reason)
+}
+
+// #endregion
+
+// #region threading.IWorkItem Members
+
+func (c *actorCell[M]) Description() string {
+	return "actor:" + c.id
+}
+
+// 每一帧处理一次邮箱中已有的消息
+func (c *actorCell[M]) DoWork() {
+	if c.stopped.Get() {
+		return
+	}
+	if c.stopRequested.Get() {
+		c.finalize()
+		return
+	}
+	if c.needStart.Get() {
+		c.needStart.Set(false)
+		if reason, failed := c.start(); failed {
+			c.fail(reason)
+			return
+		}
+	}
+	if reason, ok := c.takeEscalated(); ok {
+		c.fail(reason)
+		return
+	}
+
+	c.mailboxLock.Lock()
+	messageList := c.mailbox
+	c.mailbox = make([]M, 0)
+	c.mailboxLock.Unlock()
+
+	ctx := &Context[M]{cell: c}
+	for i, eachMessage := range messageList {
+		if c.stopRequested.Get() {
+			return
+		}
+		if reason, failed := c.receive(ctx, eachMessage); failed {
+			// 失败的消息被丢弃,剩余的消息放回邮箱的头部
+			c.requeue(messageList[i+1:])
+			c.fail(reason)
+			return
+		}
+	}
+}
+
+// #endregion
+
+func (c *actorCell[M]) tell(msg M) bool {
+	if c.stopped.Get() || c.stopRequested.Get() {
+		return false
+	}
+	c.mailboxLock.Lock()
+	defer c.mailboxLock.Unlock()
+	c.mailbox = append(c.mailbox, msg)
+	return true
+}
+
+func (c *actorCell[M]) requeue(messageList []M) {
+	if len(messageList) <= 0 {
+		return
+	}
+	c.mailboxLock.Lock()
+	defer c.mailboxLock.Unlock()
+	c.mailbox = append(append(make([]M, 0, len(messageList)+len(c.mailbox)), messageList...), c.mailbox...)
+}
+
+func (c *actorCell[M]) receive(ctx *Context[M], msg M) (reason interface{}, failed bool) {
+	return protect(func() {
+		c.behavior.Receive(ctx, msg)
+	})
+}
+
+// 创建新的行为实例并调用PreStart,panic时返回失败的原因
+func (c *actorCell[M]) start() (reason interface{}, failed bool) {
+	return protect(func() {
+		c.behavior = c.producer()
+		if starter, ok := c.behavior.(PreStarter[M]); ok {
+			starter.PreStart(&Context[M]{cell: c})
+		}
+	})
+}
+
+// 执行actor的回调,panic时返回失败的原因
+func protect(fn func()) (reason interface{}, failed bool) {
+	defer func() {
+		if p := recover(); p != nil {
+			reason = p
+			failed = true
+		}
+	}()
+	fn()
+	return nil, false
+}
+
+func (c *actorCell[M]) takeEscalated() (interface{}, bool) {
+	c.escalatedLock.Lock()
+	defer c.escalatedLock.Unlock()
+	if !c.escalated {
+		return nil, false
+	}
+	c.escalated = false
+	return c.escalatedReason, true
+}
+
+// 处理失败,由父actor的监督策略决定如何处理,顶层actor使用自身的策略
+// 正在停止的actor(如PostStop失败)只会按照Escalate上报,其它的处理方式不起作用
+func (c *actorCell[M]) fail(reason interface{}) {
+	directive := Restart
+	if c.parent != nil {
+		directive = c.parent.decide(c, reason)
+	} else if c.supervisor != nil {
+		directive = c.supervisor(c.id, reason)
+	}
+	if c.stopRequested.Get() {
+		if directive == Escalate && c.parent != nil {
+			c.parent.escalate(reason)
+		}
+		return
+	}
+	switch directive {
+	case Resume:
+		return
+	case Stop:
+		c.finalize()
+	case Escalate:
+		if c.parent == nil {
+			c.finalize()
+			return
+		}
+		c.parent.escalate(reason)
+	default:
+		c.restart()
+	}
+}
+
+// 重启:停止所有子actor与计时器,在下一帧使用新的行为实例
+// 新的行为实例在下一帧创建,PreStart持续失败时不会在同一帧中反复重启
+func (c *actorCell[M]) restart() {
+	c.cleanup()
+	c.needStart.Set(true)
+}
+
+// 停止子actor、移除计时器并调用PostStop
+func (c *actorCell[M]) cleanup() {
+	c.childLock.Lock()
+	childList := make([]cell, 0, len(c.childList))
+	for eachChild := range c.childList {
+		childList = append(childList, eachChild)
+	}
+	c.childList = make(map[cell]struct{})
+	c.childLock.Unlock()
+	for _, eachChild := range childList {
+		eachChild.requestStop()
+	}
+
+	for eachKey := range c.timerList {
+		c.host.RemoveTimer(eachKey)
+	}
+	c.timerList = make(map[string]struct{})
+
+	// 每个行为实例只调用一次PostStop
+	behavior := c.behavior
+	c.behavior = nil
+	if stopper, ok := behavior.(PostStopper[M]); ok {
+		if reason, failed := protect(func() {
+			stopper.PostStop(&Context[M]{cell: c})
+		}); failed {
+			c.fail(reason)
+		}
+	}
+}
+
+// 彻底停止,运行在逻辑线程中
+func (c *actorCell[M]) finalize() {
+	if c.stopped.Get() {
+		return
+	}
+	c.stopRequested.Set(true)
+	c.cleanup()
+	c.stopped.Set(true)
+	c.host.LogicThread().DetatchWorkItem(c)
+	if c.parent != nil {
+		c.parent.removeChild(c)
+	}
+	c.mailboxLock.Lock()
+	c.mailbox = make([]M, 0)
+	c.mailboxLock.Unlock()
+	close(c.stoppedChan)
+}
+
+func (c *actorCell[M]) nextTimerKey() string {
+	c.timerSeq++
+	return fmt.Sprintf("actor:%s:timer:%d", c.id, c.timerSeq)
+}
@@ -0,0 +1,209 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abmpio/timelinex"
+)
+
+type counterMsg struct {
+	add   int
+	panic bool
+	reply func(int)
+}
+
+type counter struct {
+	value int
+}
+
+func (c *counter) Receive(ctx *Context[counterMsg], msg counterMsg) {
+	if msg.panic {
+		panic("boom")
+	}
+	c.value += msg.add
+	if msg.reply != nil {
+		msg.reply(c.value)
+	}
+}
+
+func newCounter() Behavior[counterMsg] {
+	return &counter{}
+}
+
+func askValue(t *testing.T, ref *Ref[counterMsg]) int {
+	t.Helper()
+	v, err := Ask(ref, func(reply func(int)) counterMsg {
+		return counterMsg{reply: reply}
+	}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestActorTellAndAsk(t *testing.T) {
+	host := timelinex.NewOneLogicThread()
+	defer host.Shutdown(context.Background())
+
+	ref := Spawn(host, newCounter)
+	for i := 0; i < 10; i++ {
+		ref.Tell(counterMsg{add: 1})
+	}
+	if v := askValue(t, ref); v != 10 {
+		t.Fatalf("expected counter 10, got %d", v)
+	}
+}
+
+func TestActorAskTimeout(t *testing.T) {
+	host := timelinex.NewOneLogicThread()
+	defer host.Shutdown(context.Background())
+
+	ref := Spawn(host, FromFunc(func(ctx *Context[func(int)], msg func(int)) {
+		// 从不回复
+	}))
+	_, err := Ask(ref, func(reply func(int)) func(int) {
+		return reply
+	}, 30*time.Millisecond)
+	if !errors.Is(err, ErrAskTimeout) {
+		t.Fatalf("expected ask timeout, got %v", err)
+	}
+}
+
+func TestActorSupervisionRestartsAndStopsChildren(t *testing.T) {
+	host := timelinex.NewOneLogicThread()
+	defer host.Shutdown(context.Background())
+
+	childChan := make(chan *Ref[counterMsg], 2)
+	parent := Spawn(host, FromFunc(func(ctx *Context[string], msg string) {
+		switch msg {
+		case "restart":
+			childChan <- SpawnChild(ctx, newCounter)
+		case "stop":
+			childChan <- SpawnChild(ctx, newCounter, SpawnOptionWithSupervisor(StopStrategy))
+		}
+	}), SpawnOptionWithSupervisor(func(childID string, reason interface{}) Directive {
+		if strings.Contains(childID, "stopped") {
+			return Stop
+		}
+		return Restart
+	}))
+
+	parent.Tell("restart")
+	restarted := <-childChan
+	restarted.Tell(counterMsg{add: 5})
+	if v := askValue(t, restarted); v != 5 {
+		t.Fatalf("expected counter 5, got %d", v)
+	}
+	restarted.Tell(counterMsg{panic: true})
+	if v := askValue(t, restarted); v != 0 {
+		t.Fatalf("expected restarted child to start with fresh state, got %d", v)
+	}
+
+	stoppedChild := SpawnChild(&Context[string]{cell: parent.cell}, newCounter, SpawnOptionWithName("stopped"))
+	stoppedChild.Tell(counterMsg{panic: true})
+	select {
+	case <-stoppedChild.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected child to be stopped by the supervisor")
+	}
+
+	parent.Stop()
+	select {
+	case <-restarted.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected child to stop with its parent")
+	}
+}
+
+func TestActorTimersAreRemovedOnStop(t *testing.T) {
+	host := timelinex.NewOneLogicThread()
+	defer host.Shutdown(context.Background())
+
+	ticks := make(chan struct{}, 100)
+	ref := Spawn(host, FromFunc(func(ctx *Context[string], msg string) {
+		switch msg {
+		case "start":
+			ctx.SendEvery(10*time.Millisecond, "tick")
+		case "tick":
+			ticks <- struct{}{}
+		}
+	}))
+	ref.Tell("start")
+	<-ticks
+
+	ref.Stop()
+	<-ref.Done()
+	for _, eachTimeline := range timelinex.DebugSnapshot() {
+		if eachTimeline.Name != host.LogicThread().ID() {
+			continue
+		}
+		for _, eachTimer := range eachTimeline.Timers {
+			if strings.HasPrefix(eachTimer.Key, "actor:"+ref.ID()) {
+				t.Fatalf("expected actor timer to be removed, got %+v", eachTimer)
+			}
+		}
+	}
+}
+
+type lifecyclePanicker struct {
+	counter
+	panicOnStart bool
+	stopCount    *int32
+}
+
+func (p *lifecyclePanicker) PreStart(ctx *Context[counterMsg]) {
+	if p.panicOnStart {
+		panic("start failed")
+	}
+}
+
+func (p *lifecyclePanicker) PostStop(ctx *Context[counterMsg]) {
+	atomic.AddInt32(p.stopCount, 1)
+	panic("stop failed")
+}
+
+func TestActorLifecyclePanicsAreSupervised(t *testing.T) {
+	host := timelinex.NewOneLogicThread()
+	defer host.Shutdown(context.Background())
+
+	var stopCount int32
+	var reasonList []interface{}
+	var lock sync.Mutex
+	starts := 0
+	ref := Spawn(host, func() Behavior[counterMsg] {
+		starts++
+		// 第一次启动失败,重启后正常
+		return &lifecyclePanicker{panicOnStart: starts == 1, stopCount: &stopCount}
+	}, SpawnOptionWithSupervisor(func(childID string, reason interface{}) Directive {
+		lock.Lock()
+		defer lock.Unlock()
+		reasonList = append(reasonList, reason)
+		return Restart
+	}))
+
+	ref.Tell(counterMsg{add: 3})
+	if v := askValue(t, ref); v != 3 {
+		t.Fatalf("expected restarted actor to process messages, got %d", v)
+	}
+
+	ref.Stop()
+	select {
+	case <-ref.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected actor to stop even though PostStop panics")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if len(reasonList) != 3 || reasonList[0] != "start failed" || reasonList[1] != "stop failed" || reasonList[2] != "stop failed" {
+		t.Fatalf("unexpected supervised reasons %v", reasonList)
+	}
+	if atomic.LoadInt32(&stopCount) != 2 {
+		t.Fatalf("expected PostStop once per behavior instance, got %d", stopCount)
+	}
+}
@@ -0,0 +1,38 @@
+package actor
+
+// 监督策略对失败的处理方式
+type Directive int
+
+const (
+	// 丢弃引起失败的消息,保留状态继续处理后续消息
+	Resume Directive = iota
+	// 停止子actor与计时器,使用新的行为实例重新开始
+	Restart
+	// 停止actor
+	Stop
+	// 将失败上报给父actor,由父actor的监督者处理
+	Escalate
+)
+
+// 监督策略,childID为失败的actor的id,reason为Receive中panic的值
+type SupervisorStrategy func(childID string, reason interface{}) Directive
+
+// 总是重启失败的actor
+func RestartStrategy(childID string, reason interface{}) Directive {
+	return Restart
+}
+
+// 总是停止失败的actor
+func StopStrategy(childID string, reason interface{}) Directive {
+	return Stop
+}
+
+// 总是恢复失败的actor
+func ResumeStrategy(childID string, reason interface{}) Directive {
+	return Resume
+}
+
+// 总是将失败上报给父actor
+func EscalateStrategy(childID string, reason interface{}) Directive {
+	return Escalate
+}
@@ -0,0 +1,79 @@
+package timelinex
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// 检查到在逻辑线程之外调用逻辑线程专用的方法时的处理方式
+type AffinityCheckMode int32
+
+const (
+	// 不检查
+	AffinityCheckOff AffinityCheckMode = iota
+	// 打印调用堆栈
+	AffinityCheckLog
+	// panic
+	AffinityCheckPanic
+)
+
+func (m AffinityCheckMode) String() string {
+	switch m {
+	case AffinityCheckOff:
+		return "off"
+	case AffinityCheckLog:
+		return "log"
+	case AffinityCheckPanic:
+		return "panic"
+	default:
+		return "unknown"
+	}
+}
+
+// 能够判断当前代码是否运行在所属逻辑线程中的对象,如OneLogicThread与时间轴
+type IThreadAffinity interface {
+	IsOnLogicThread() bool
+}
+
+var _affinityCheckMode atomic.Int32
+
+func init() {
+	// 使用timelinex_debug编译标签时默认panic,否则默认不检查
+	_affinityCheckMode.Store(int32(defaultAffinityCheckMode))
+}
+
+// 设置线程亲和性检查的方式,可以在运行时打开检查
+func SetAffinityCheckMode(mode AffinityCheckMode) {
+	_affinityCheckMode.Store(int32(mode))
+}
+
+// 当前线程亲和性检查的方式
+func GetAffinityCheckMode() AffinityCheckMode {
+	return AffinityCheckMode(_affinityCheckMode.Load())
+}
+
+// 断言当前代码运行在owner所属的逻辑线程中
+// owner没有实现IThreadAffinity或者检查被关闭时不做任何事情,关闭时只有一次原子读取的开销
+// 时间轴的observer遍历、暂停与恢复,场景计时器派发的回调,以及fsm、bt、actor等辅助包的线程专用方法中调用此方法
+func AssertOnLogicThread(owner interface{}) {
+	mode := GetAffinityCheckMode()
+	if mode == AffinityCheckOff {
+		return
+	}
+	affinity, ok := owner.(IThreadAffinity)
+	if !ok || affinity.IsOnLogicThread() {
+		return
+	}
+	reportAffinityViolation(mode)
+}
+
+func reportAffinityViolation(mode AffinityCheckMode) {
+	msg := "timelinex: logic-thread-only API called from another goroutine"
+	switch mode {
+	case AffinityCheckLog:
+		fmt.Printf("%s\n%s\n", msg, debug.Stack())
+	case AffinityCheckPanic:
+		panic(msg)
+	}
+}
@@ -0,0 +1,5 @@
+//go:build timelinex_debug
+
+package timelinex
+
+const defaultAffinityCheckMode = AffinityCheckPanic
@@ -0,0 +1,41 @@
+//go:build timelinex_debug
+
+package timelinex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abmpio/timelinex/threading"
+)
+
+func expectAffinityPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected %s to panic off the logic thread", name)
+		}
+	}()
+	fn()
+}
+
+func TestDebugBuildAssertsTimelineEntryPoints(t *testing.T) {
+	if GetAffinityCheckMode() != AffinityCheckPanic {
+		t.Fatalf("expected timelinex_debug builds to panic by default, got %s", GetAffinityCheckMode())
+	}
+
+	// 绑定到一个没有启动的逻辑线程,测试协程不是这个逻辑线程
+	tl, timer := newTestTimelineWithTimer()
+	defer timer.Stop()
+	tl.setLogicThread(threading.NewLogicThread())
+
+	expectAffinityPanic(t, "DoWork", tl.DoWork)
+	expectAffinityPanic(t, "OnPaused", func() { tl.OnPaused(time.Now()) })
+	expectAffinityPanic(t, "OnResumed", func() { tl.OnResumed(time.Now(), false) })
+
+	timer.dispatch("save", false, Observer(func() {
+		t.Fatal("expected the timer callback not to run off the logic thread")
+	}))
+	observer := tl.dequeueOneTimelineObserver()
+	expectAffinityPanic(t, "timer callback", func() { observer.OnNext(16) })
+}
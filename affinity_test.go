@@ -0,0 +1,62 @@
+package timelinex
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOneLogicThreadAffinity(t *testing.T) {
+	thread := NewOneLogicThread()
+	defer thread.Shutdown(context.Background())
+
+	if thread.IsOnLogicThread() {
+		t.Fatalf("expected test goroutine not to be the logic thread")
+	}
+
+	previous := GetAffinityCheckMode()
+	SetAffinityCheckMode(AffinityCheckPanic)
+	defer SetAffinityCheckMode(previous)
+
+	resultChan := make(chan interface{}, 1)
+	thread.SubscribeAsOneTime(Observer(func() {
+		defer func() {
+			resultChan <- recover()
+		}()
+		if !thread.IsOnLogicThread() || !thread.ITimeline.(IThreadAffinity).IsOnLogicThread() {
+			panic("expected observer to run on the logic thread")
+		}
+		thread.AssertOnLogicThread()
+	}), nil)
+	select {
+	case r := <-resultChan:
+		if r != nil {
+			t.Fatalf("expected no violation on the logic thread, got %v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected one-time observer to run")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expected AssertOnLogicThread to panic off the logic thread")
+			}
+		}()
+		thread.AssertOnLogicThread()
+	}()
+
+	SetAffinityCheckMode(AffinityCheckOff)
+	thread.AssertOnLogicThread()
+}
+
+func TestGlobalTimelineIsNotBoundToLogicThread(t *testing.T) {
+	previous := GetAffinityCheckMode()
+	SetAffinityCheckMode(AffinityCheckPanic)
+	defer SetAffinityCheckMode(previous)
+
+	if !GlobalTimeline().(IThreadAffinity).IsOnLogicThread() {
+		t.Fatal("expected the unbound global timeline to pass affinity checks")
+	}
+	AssertOnLogicThread(GlobalTimeline())
+}
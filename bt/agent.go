@@ -0,0 +1,188 @@
+package bt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abmpio/timelinex"
+	"github.com/lithammer/shortuuid/v4"
+)
+
+// 行为树的宿主,提供时间轴与场景计时器,如timelinex.OneLogicThread
+type Host interface {
+	timelinex.ITimeline
+	timelinex.ISceneTimer
+}
+
+// 节点执行的上下文
+type Context struct {
+	Agent      *Agent
+	Blackboard *Blackboard
+	// 与上一帧相差的毫秒值
+	DeltaMS float64
+}
+
+// 行为树,只是对根节点的包装,可以被多个Agent共享
+type Tree struct {
+	name string
+	root Node
+}
+
+func NewTree(name string, root Node) *Tree {
+	return &Tree{
+		name: name,
+		root: root,
+	}
+}
+
+func (t *Tree) Name() string {
+	return t.name
+}
+
+func (t *Tree) Root() Node {
+	return t.root
+}
+
+var _ timelinex.ITimelineObserver = (*Agent)(nil)
+
+// 运行一棵行为树的个体,拥有自己的黑板与节点运行状态
+// 订阅到时间轴后每一帧执行一次根节点,根节点完成后下一帧重新开始
+// 除Start与Stop之外的方法都应运行在时间轴线程中
+type Agent struct {
+	id         string
+	tree       *Tree
+	host       Host
+	blackboard *Blackboard
+
+	// 每个节点的运行状态
+	memoryList map[Node]interface{}
+	// 处于冷却中的节点
+	cooldownList map[Node]string
+	// 当前有效的计时器,停止时全部移除
+	timerList map[string]struct{}
+
+	timerSeq   uint64
+	lastStatus Status
+}
+
+func NewAgent(tree *Tree, host Host) *Agent {
+	return &Agent{
+		id:           shortuuid.New(),
+		tree:         tree,
+		host:         host,
+		blackboard:   NewBlackboard(),
+		memoryList:   make(map[Node]interface{}),
+		cooldownList: make(map[Node]string),
+		timerList:    make(map[string]struct{}),
+	}
+}
+
+func (a *Agent) ID() string {
+	return a.id
+}
+
+func (a *Agent) Blackboard() *Blackboard {
+	return a.blackboard
+}
+
+// 最近一次执行根节点的结果
+func (a *Agent) LastStatus() Status {
+	return a.lastStatus
+}
+
+// 订阅到时间轴开始运行,可以在任意协程中调用
+func (a *Agent) Start() {
+	a.host.Subscribe(a)
+}
+
+// 取消订阅,并在时间轴线程中中止正在运行的节点、移除所有计时器
+func (a *Agent) Stop() {
+	a.host.Unsubscribe(a)
+	a.host.SubscribeAsOneTime(timelinex.Observer(a.reset), nil)
+}
+
+// 执行一次根节点
+func (a *Agent) Tick(deltaMS float64) Status {
+	timelinex.AssertOnLogicThread(a.host)
+	a.lastStatus = a.tree.root.Tick(&Context{
+		Agent:      a,
+		Blackboard: a.blackboard,
+		DeltaMS:    deltaMS,
+	})
+	return a.lastStatus
+}
+
+// #region ITimelineObserver Members
+
+func (a *Agent) OnNext(deltaMS float64) {
+	a.Tick(deltaMS)
+}
+
+// #endregion
+
+func (a *Agent) reset() {
+	a.tree.root.Abort(&Context{
+		Agent:      a,
+		Blackboard: a.blackboard,
+	})
+	for eachKey := range a.timerList {
+		a.host.RemoveTimer(eachKey)
+	}
+	a.timerList = make(map[string]struct{})
+	a.cooldownList = make(map[Node]string)
+	a.memoryList = make(map[Node]interface{})
+}
+
+func (a *Agent) memory(node Node) interface{} {
+	return a.memoryList[node]
+}
+
+func (a *Agent) setMemory(node Node, v interface{}) {
+	a.memoryList[node] = v
+}
+
+func (a *Agent) clearMemory(node Node) {
+	delete(a.memoryList, node)
+}
+
+// 为节点启动一个场景计时器,回调运行在时间轴线程中
+func (a *Agent) startTimer(node Node, d time.Duration, action func()) string {
+	a.timerSeq++
+	key := fmt.Sprintf("bt:%s:%s:%d", a.id, node.Name(), a.timerSeq)
+	a.timerList[key] = struct{}{}
+	a.host.StartNewOneTimer(d, func() {
+		if _, ok := a.timerList[key]; !ok {
+			// 已经被移除
+			return
+		}
+		delete(a.timerList, key)
+		action()
+	}, timelinex.SceneTimerOptionWithKey(key))
+	return key
+}
+
+func (a *Agent) removeTimer(key string) {
+	if _, ok := a.timerList[key]; !ok {
+		return
+	}
+	delete(a.timerList, key)
+	a.host.RemoveTimer(key)
+}
+
+func (a *Agent) isCoolingDown(node Node) bool {
+	_, ok := a.cooldownList[node]
+	return ok
+}
+
+func (a *Agent) startCooldown(node Node, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	var key string
+	key = a.startTimer(node, d, func() {
+		if a.cooldownList[node] == key {
+			delete(a.cooldownList, node)
+		}
+	})
+	a.cooldownList[node] = key
+}
@@ -0,0 +1,45 @@
+package bt
+
+// 每个Agent私有的黑板,用于节点之间共享数据
+// 黑板只应在时间轴线程中访问
+type Blackboard struct {
+	valueList map[string]interface{}
+}
+
+func NewBlackboard() *Blackboard {
+	return &Blackboard{
+		valueList: make(map[string]interface{}),
+	}
+}
+
+func (b *Blackboard) Get(key string) (interface{}, bool) {
+	v, ok := b.valueList[key]
+	return v, ok
+}
+
+func (b *Blackboard) Set(key string, v interface{}) {
+	b.valueList[key] = v
+}
+
+func (b *Blackboard) Delete(key string) {
+	delete(b.valueList, key)
+}
+
+func (b *Blackboard) Has(key string) bool {
+	_, ok := b.valueList[key]
+	return ok
+}
+
+// 以指定的类型从黑板中获取值,值不存在或者类型不匹配时返回false
+func BlackboardGet[T any](b *Blackboard, key string) (T, bool) {
+	var empty T
+	v, ok := b.valueList[key]
+	if !ok {
+		return empty, false
+	}
+	result, ok := v.(T)
+	if !ok {
+		return empty, false
+	}
+	return result, true
+}
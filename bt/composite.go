@@ -0,0 +1,166 @@
+package bt
+
+var _ Node = (*SequenceNode)(nil)
+var _ Node = (*SelectorNode)(nil)
+var _ Node = (*ParallelNode)(nil)
+
+// 组合节点的公共部分
+type compositeBase struct {
+	nodeBase
+	childList []Node
+}
+
+func (n *compositeBase) Children() []Node {
+	return n.childList
+}
+
+// 按顺序执行子节点,任意一个失败则失败,全部成功则成功
+// 子节点返回Running时,下一帧从这个子节点继续执行
+type SequenceNode struct {
+	compositeBase
+}
+
+func Sequence(name string, children ...Node) *SequenceNode {
+	return &SequenceNode{
+		compositeBase: compositeBase{
+			nodeBase:  nodeBase{name: name},
+			childList: children,
+		},
+	}
+}
+
+// #region Node Members
+
+func (n *SequenceNode) Tick(ctx *Context) Status {
+	return tickInOrder(ctx, n, n.childList, Success)
+}
+
+func (n *SequenceNode) Abort(ctx *Context) {
+	abortNode(ctx, n)
+}
+
+// #endregion
+
+// 按顺序执行子节点,任意一个成功则成功,全部失败则失败
+// 子节点返回Running时,下一帧从这个子节点继续执行
+type SelectorNode struct {
+	compositeBase
+}
+
+func Selector(name string, children ...Node) *SelectorNode {
+	return &SelectorNode{
+		compositeBase: compositeBase{
+			nodeBase:  nodeBase{name: name},
+			childList: children,
+		},
+	}
+}
+
+// #region Node Members
+
+func (n *SelectorNode) Tick(ctx *Context) Status {
+	return tickInOrder(ctx, n, n.childList, Failure)
+}
+
+func (n *SelectorNode) Abort(ctx *Context) {
+	abortNode(ctx, n)
+}
+
+// #endregion
+
+// Sequence与Selector的公共逻辑
+// continueStatus 为继续执行下一个子节点的状态,Sequence为Success,Selector为Failure
+func tickInOrder(ctx *Context, node Node, childList []Node, continueStatus Status) Status {
+	index, _ := ctx.Agent.memory(node).(int)
+	for index < len(childList) {
+		status := childList[index].Tick(ctx)
+		if status == Running {
+			ctx.Agent.setMemory(node, index)
+			return Running
+		}
+		if status != continueStatus {
+			ctx.Agent.clearMemory(node)
+			return status
+		}
+		index++
+	}
+	ctx.Agent.clearMemory(node)
+	return continueStatus
+}
+
+// 每一帧同时执行所有未完成的子节点
+// 成功的子节点数达到successThreshold时成功,失败的子节点数达到failureThreshold时失败,
+// 此时仍在运行的子节点会被中止
+type ParallelNode struct {
+	compositeBase
+	successThreshold int
+	failureThreshold int
+}
+
+// successThreshold 小于等于0时表示需要全部子节点成功
+// failureThreshold 小于等于0时表示任意一个子节点失败即失败
+func Parallel(name string, successThreshold int, failureThreshold int, children ...Node) *ParallelNode {
+	if successThreshold <= 0 || successThreshold > len(children) {
+		successThreshold = len(children)
+	}
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+	return &ParallelNode{
+		compositeBase: compositeBase{
+			nodeBase:  nodeBase{name: name},
+			childList: children,
+		},
+		successThreshold: successThreshold,
+		failureThreshold: failureThreshold,
+	}
+}
+
+// #region Node Members
+
+func (n *ParallelNode) Tick(ctx *Context) Status {
+	resultList, ok := ctx.Agent.memory(n).([]Status)
+	if !ok {
+		resultList = make([]Status, len(n.childList))
+	}
+	successCount := 0
+	failureCount := 0
+	for i, eachChild := range n.childList {
+		if resultList[i] != Success && resultList[i] != Failure {
+			resultList[i] = eachChild.Tick(ctx)
+		}
+		switch resultList[i] {
+		case Success:
+			successCount++
+		case Failure:
+			failureCount++
+		}
+	}
+
+	var result Status
+	switch {
+	case successCount >= n.successThreshold:
+		result = Success
+	case failureCount >= n.failureThreshold:
+		result = Failure
+	case successCount+failureCount >= len(n.childList):
+		// 所有子节点都已完成但没有达到成功的数量
+		result = Failure
+	default:
+		ctx.Agent.setMemory(n, resultList)
+		return Running
+	}
+	for i, eachChild := range n.childList {
+		if resultList[i] == Running {
+			eachChild.Abort(ctx)
+		}
+	}
+	ctx.Agent.clearMemory(n)
+	return result
+}
+
+func (n *ParallelNode) Abort(ctx *Context) {
+	abortNode(ctx, n)
+}
+
+// #endregion
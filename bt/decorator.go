@@ -0,0 +1,190 @@
+package bt
+
+import (
+	"time"
+)
+
+var _ Node = (*InverterNode)(nil)
+var _ Node = (*RepeatNode)(nil)
+var _ Node = (*CooldownNode)(nil)
+var _ Node = (*TimeoutNode)(nil)
+
+// 装饰节点的公共部分
+type decoratorBase struct {
+	nodeBase
+	child Node
+}
+
+func (n *decoratorBase) Children() []Node {
+	return []Node{n.child}
+}
+
+// 反转子节点的结果,Running保持不变
+type InverterNode struct {
+	decoratorBase
+}
+
+func Inverter(name string, child Node) *InverterNode {
+	return &InverterNode{
+		decoratorBase: decoratorBase{
+			nodeBase: nodeBase{name: name},
+			child:    child,
+		},
+	}
+}
+
+// #region Node Members
+
+func (n *InverterNode) Tick(ctx *Context) Status {
+	switch n.child.Tick(ctx) {
+	case Success:
+		return Failure
+	case Failure:
+		return Success
+	}
+	return Running
+}
+
+func (n *InverterNode) Abort(ctx *Context) {
+	abortNode(ctx, n)
+}
+
+// #endregion
+
+// 重复执行子节点count次,子节点失败时立即失败
+// 每次子节点成功后在下一帧开始下一次执行,count小于等于0时一直重复
+type RepeatNode struct {
+	decoratorBase
+	count int
+}
+
+func Repeat(name string, count int, child Node) *RepeatNode {
+	return &RepeatNode{
+		decoratorBase: decoratorBase{
+			nodeBase: nodeBase{name: name},
+			child:    child,
+		},
+		count: count,
+	}
+}
+
+// #region Node Members
+
+func (n *RepeatNode) Tick(ctx *Context) Status {
+	done, _ := ctx.Agent.memory(n).(int)
+	switch n.child.Tick(ctx) {
+	case Running:
+		return Running
+	case Failure:
+		ctx.Agent.clearMemory(n)
+		return Failure
+	}
+	done++
+	if n.count > 0 && done >= n.count {
+		ctx.Agent.clearMemory(n)
+		return Success
+	}
+	ctx.Agent.setMemory(n, done)
+	return Running
+}
+
+func (n *RepeatNode) Abort(ctx *Context) {
+	abortNode(ctx, n)
+}
+
+// #endregion
+
+// 子节点执行完成后进入冷却,冷却期间直接返回失败
+// 冷却基于场景计时器,不会因为中止而被清除
+type CooldownNode struct {
+	decoratorBase
+	duration time.Duration
+}
+
+func Cooldown(name string, duration time.Duration, child Node) *CooldownNode {
+	return &CooldownNode{
+		decoratorBase: decoratorBase{
+			nodeBase: nodeBase{name: name},
+			child:    child,
+		},
+		duration: duration,
+	}
+}
+
+// #region Node Members
+
+func (n *CooldownNode) Tick(ctx *Context) Status {
+	if ctx.Agent.isCoolingDown(n) {
+		return Failure
+	}
+	status := n.child.Tick(ctx)
+	if status != Running {
+		ctx.Agent.startCooldown(n, n.duration)
+	}
+	return status
+}
+
+func (n *CooldownNode) Abort(ctx *Context) {
+	abortNode(ctx, n)
+}
+
+// #endregion
+
+// 子节点持续运行超过指定时间后被中止并返回失败
+// 超时基于场景计时器
+type TimeoutNode struct {
+	decoratorBase
+	duration time.Duration
+}
+
+func Timeout(name string, duration time.Duration, child Node) *TimeoutNode {
+	return &TimeoutNode{
+		decoratorBase: decoratorBase{
+			nodeBase: nodeBase{name: name},
+			child:    child,
+		},
+		duration: duration,
+	}
+}
+
+type timeoutState struct {
+	timerKey string
+	expired  bool
+}
+
+// #region Node Members
+
+func (n *TimeoutNode) Tick(ctx *Context) Status {
+	state, ok := ctx.Agent.memory(n).(*timeoutState)
+	if ok && state.expired {
+		n.child.Abort(ctx)
+		ctx.Agent.clearMemory(n)
+		return Failure
+	}
+	status := n.child.Tick(ctx)
+	if status != Running {
+		if ok {
+			ctx.Agent.removeTimer(state.timerKey)
+		}
+		ctx.Agent.clearMemory(n)
+		return status
+	}
+	if !ok {
+		state = &timeoutState{}
+		// 计时器回调运行在时间轴线程中,与Tick不会并发
+		state.timerKey = ctx.Agent.startTimer(n, n.duration, func() {
+			state.expired = true
+		})
+		ctx.Agent.setMemory(n, state)
+	}
+	return Running
+}
+
+func (n *TimeoutNode) Abort(ctx *Context) {
+	if state, ok := ctx.Agent.memory(n).(*timeoutState); ok {
+		ctx.Agent.removeTimer(state.timerKey)
+	}
+	abortNode(ctx, n)
+}
+
+// #endregion
@@ -0,0 +1,66 @@
+package bt
+
+// 动作节点的回调
+type ActionFunc func(ctx *Context) Status
+
+// 条件节点的回调
+type ConditionFunc func(ctx *Context) bool
+
+var _ Node = (*ActionNode)(nil)
+var _ Node = (*ConditionNode)(nil)
+
+// 执行一个动作的叶子节点,返回Running时下一帧会再次执行
+type ActionNode struct {
+	nodeBase
+	action ActionFunc
+}
+
+func Action(name string, action ActionFunc) *ActionNode {
+	return &ActionNode{
+		nodeBase: nodeBase{name: name},
+		action:   action,
+	}
+}
+
+// #region Node Members
+
+func (n *ActionNode) Tick(ctx *Context) Status {
+	if n.action == nil {
+		return Failure
+	}
+	return n.action(ctx)
+}
+
+func (n *ActionNode) Abort(ctx *Context) {
+	abortNode(ctx, n)
+}
+
+// #endregion
+
+// 判断条件的叶子节点,条件成立时返回Success,否则返回Failure
+type ConditionNode struct {
+	nodeBase
+	condition ConditionFunc
+}
+
+func Condition(name string, condition ConditionFunc) *ConditionNode {
+	return &ConditionNode{
+		nodeBase:  nodeBase{name: name},
+		condition: condition,
+	}
+}
+
+// #region Node Members
+
+func (n *ConditionNode) Tick(ctx *Context) Status {
+	if n.condition == nil || !n.condition(ctx) {
+		return Failure
+	}
+	return Success
+}
+
+func (n *ConditionNode) Abort(ctx *Context) {
+	abortNode(ctx, n)
+}
+
+// #endregion
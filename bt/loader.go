@@ -0,0 +1,204 @@
+package bt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 节点类型
+const (
+	NodeTypeSequence  = "sequence"
+	NodeTypeSelector  = "selector"
+	NodeTypeParallel  = "parallel"
+	NodeTypeInverter  = "inverter"
+	NodeTypeRepeat    = "repeat"
+	NodeTypeCooldown  = "cooldown"
+	NodeTypeTimeout   = "timeout"
+	NodeTypeAction    = "action"
+	NodeTypeCondition = "condition"
+)
+
+// 行为树的JSON定义
+//
+//	{
+//		"name": "guard",
+//		"root": {
+//			"type": "selector",
+//			"children": [
+//				{"type": "sequence", "children": [
+//					{"type": "condition", "name": "hasTarget"},
+//					{"type": "cooldown", "duration": "2s", "child": {"type": "action", "name": "attack"}}
+//				]},
+//				{"type": "timeout", "duration": "5s", "child": {"type": "action", "name": "patrol"}}
+//			]
+//		}
+//	}
+type TreeDefinition struct {
+	Name string          `json:"name"`
+	Root *NodeDefinition `json:"root"`
+}
+
+// 节点的JSON定义
+type NodeDefinition struct {
+	Type string `json:"type"`
+	// 对于action与condition,为注册到Registry中的名称
+	Name     string            `json:"name,omitempty"`
+	Children []*NodeDefinition `json:"children,omitempty"`
+	Child    *NodeDefinition   `json:"child,omitempty"`
+	// repeat的次数,小于等于0时一直重复
+	Count int `json:"count,omitempty"`
+	// cooldown与timeout的时长,使用time.ParseDuration的格式,如"1.5s"
+	Duration string `json:"duration,omitempty"`
+	// parallel成功与失败的阈值
+	SuccessThreshold int `json:"success,omitempty"`
+	FailureThreshold int `json:"failure,omitempty"`
+}
+
+// action与condition的注册表,JSON中的节点通过名称引用这里注册的回调
+type Registry struct {
+	rwLock        sync.RWMutex
+	actionList    map[string]ActionFunc
+	conditionList map[string]ConditionFunc
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		actionList:    make(map[string]ActionFunc),
+		conditionList: make(map[string]ConditionFunc),
+	}
+}
+
+func (r *Registry) RegisterAction(name string, action ActionFunc) {
+	r.rwLock.Lock()
+	defer r.rwLock.Unlock()
+	r.actionList[name] = action
+}
+
+func (r *Registry) RegisterCondition(name string, condition ConditionFunc) {
+	r.rwLock.Lock()
+	defer r.rwLock.Unlock()
+	r.conditionList[name] = condition
+}
+
+// 从JSON中加载行为树,加载前会进行校验
+func (r *Registry) Load(data []byte) (*Tree, error) {
+	def := &TreeDefinition{}
+	if err := json.Unmarshal(data, def); err != nil {
+		return nil, fmt.Errorf("bt: invalid tree json, %w", err)
+	}
+	return r.Build(def)
+}
+
+// 根据定义构建行为树,构建前会进行校验
+func (r *Registry) Build(def *TreeDefinition) (*Tree, error) {
+	if err := r.Validate(def); err != nil {
+		return nil, err
+	}
+	r.rwLock.RLock()
+	defer r.rwLock.RUnlock()
+	return NewTree(def.Name, r.buildNode(def.Root)), nil
+}
+
+// 校验定义,返回所有发现的错误
+func (r *Registry) Validate(def *TreeDefinition) error {
+	if def == nil || def.Root == nil {
+		return errors.New("bt: tree has no root")
+	}
+	r.rwLock.RLock()
+	defer r.rwLock.RUnlock()
+	errList := make([]error, 0)
+	r.validateNode(def.Root, "root", &errList)
+	return errors.Join(errList...)
+}
+
+func (r *Registry) validateNode(def *NodeDefinition, path string, errList *[]error) {
+	if def == nil {
+		*errList = append(*errList, fmt.Errorf("bt: %s: node is null", path))
+		return
+	}
+	addErr := func(format string, args ...interface{}) {
+		*errList = append(*errList, fmt.Errorf("bt: %s(%s): %s", path, def.Type, fmt.Sprintf(format, args...)))
+	}
+	switch def.Type {
+	case NodeTypeSequence, NodeTypeSelector, NodeTypeParallel:
+		if len(def.Children) <= 0 {
+			addErr("requires at least one child")
+		}
+		if def.Child != nil {
+			addErr("uses children instead of child")
+		}
+		if def.Type == NodeTypeParallel && def.SuccessThreshold > len(def.Children) {
+			addErr("success threshold %d exceeds children count %d", def.SuccessThreshold, len(def.Children))
+		}
+		for i, eachChild := range def.Children {
+			r.validateNode(eachChild, fmt.Sprintf("%s.children[%d]", path, i), errList)
+		}
+	case NodeTypeInverter, NodeTypeRepeat, NodeTypeCooldown, NodeTypeTimeout:
+		if def.Child == nil {
+			addErr("requires a child")
+		} else {
+			r.validateNode(def.Child, path+".child", errList)
+		}
+		if len(def.Children) > 0 {
+			addErr("uses child instead of children")
+		}
+		if def.Type == NodeTypeCooldown || def.Type == NodeTypeTimeout {
+			d, err := time.ParseDuration(def.Duration)
+			if err != nil {
+				addErr("invalid duration %q", def.Duration)
+			} else if d <= 0 {
+				addErr("duration must be positive")
+			}
+		}
+	case NodeTypeAction:
+		if _, ok := r.actionList[def.Name]; !ok {
+			addErr("action %q is not registered", def.Name)
+		}
+	case NodeTypeCondition:
+		if _, ok := r.conditionList[def.Name]; !ok {
+			addErr("condition %q is not registered", def.Name)
+		}
+	default:
+		addErr("unknown node type")
+	}
+}
+
+// 定义已经通过校验
+func (r *Registry) buildNode(def *NodeDefinition) Node {
+	name := def.Name
+	if len(name) <= 0 {
+		name = def.Type
+	}
+	switch def.Type {
+	case NodeTypeSequence, NodeTypeSelector, NodeTypeParallel:
+		childList := make([]Node, 0, len(def.Children))
+		for _, eachChild := range def.Children {
+			childList = append(childList, r.buildNode(eachChild))
+		}
+		if def.Type == NodeTypeSequence {
+			return Sequence(name, childList...)
+		}
+		if def.Type == NodeTypeSelector {
+			return Selector(name, childList...)
+		}
+		return Parallel(name, def.SuccessThreshold, def.FailureThreshold, childList...)
+	case NodeTypeInverter:
+		return Inverter(name, r.buildNode(def.Child))
+	case NodeTypeRepeat:
+		return Repeat(name, def.Count, r.buildNode(def.Child))
+	case NodeTypeCooldown:
+		d, _ := time.ParseDuration(def.Duration)
+		return Cooldown(name, d, r.buildNode(def.Child))
+	case NodeTypeTimeout:
+		d, _ := time.ParseDuration(def.Duration)
+		return Timeout(name, d, r.buildNode(def.Child))
+	case NodeTypeAction:
+		return Action(name, r.actionList[def.Name])
+	case NodeTypeCondition:
+		return Condition(name, r.conditionList[def.Name])
+	}
+	return nil
+}
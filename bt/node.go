@@ -0,0 +1,59 @@
+package bt
+
+// 节点执行的结果
+type Status int
+
+const (
+	// 节点执行成功
+	Success Status = iota + 1
+	// 节点执行失败
+	Failure
+	// 节点仍在执行中,下一帧会继续执行
+	Running
+)
+
+func (s Status) String() string {
+	switch s {
+	case Success:
+		return "success"
+	case Failure:
+		return "failure"
+	case Running:
+		return "running"
+	}
+	return "unknown"
+}
+
+// 行为树节点
+// 节点本身是无状态的,可以被多个Agent共享,运行状态保存在每个Agent的节点内存中
+type Node interface {
+	// 节点名称,用于调试
+	Name() string
+	// 子节点列表
+	Children() []Node
+	// 执行一次节点
+	Tick(ctx *Context) Status
+	// 中止正在运行的节点,清理此节点在Agent中的运行状态
+	Abort(ctx *Context)
+}
+
+// 节点的公共部分
+type nodeBase struct {
+	name string
+}
+
+func (n *nodeBase) Name() string {
+	return n.name
+}
+
+func (n *nodeBase) Children() []Node {
+	return nil
+}
+
+// 中止节点及其所有子节点
+func abortNode(ctx *Context, node Node) {
+	for _, eachChild := range node.Children() {
+		eachChild.Abort(ctx)
+	}
+	ctx.Agent.clearMemory(node)
+}
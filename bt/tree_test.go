@@ -0,0 +1,137 @@
+package bt
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abmpio/timelinex"
+)
+
+func TestSequenceResumesRunningChild(t *testing.T) {
+	firstCalls := 0
+	secondCalls := 0
+	tree := NewTree("seq", Sequence("root",
+		Action("first", func(ctx *Context) Status {
+			firstCalls++
+			return Success
+		}),
+		Action("second", func(ctx *Context) Status {
+			secondCalls++
+			if secondCalls < 3 {
+				return Running
+			}
+			return Success
+		}),
+	))
+	agent := NewAgent(tree, nil)
+
+	for i := 0; i < 2; i++ {
+		if status := agent.Tick(16); status != Running {
+			t.Fatalf("expected running on frame %d, got %s", i, status)
+		}
+	}
+	if status := agent.Tick(16); status != Success {
+		t.Fatalf("expected success, got %s", status)
+	}
+	if firstCalls != 1 || secondCalls != 3 {
+		t.Fatalf("expected running child to be resumed, got first=%d second=%d", firstCalls, secondCalls)
+	}
+}
+
+func TestParallelAndRepeatWithBlackboard(t *testing.T) {
+	tree := NewTree("parallel", Parallel("root", 1, 0,
+		Repeat("count", 3, Action("inc", func(ctx *Context) Status {
+			v, _ := BlackboardGet[int](ctx.Blackboard, "count")
+			ctx.Blackboard.Set("count", v+1)
+			return Success
+		})),
+		Action("forever", func(ctx *Context) Status {
+			return Running
+		}),
+	))
+	agent := NewAgent(tree, nil)
+
+	statusList := make([]Status, 0)
+	for i := 0; i < 3; i++ {
+		statusList = append(statusList, agent.Tick(16))
+	}
+	if statusList[0] != Running || statusList[1] != Running || statusList[2] != Success {
+		t.Fatalf("unexpected status sequence %v", statusList)
+	}
+	if v, _ := BlackboardGet[int](agent.Blackboard(), "count"); v != 3 {
+		t.Fatalf("expected repeat to run 3 times, got %d", v)
+	}
+	if len(agent.memoryList) != 0 {
+		t.Fatalf("expected finished tree to leave no running state, got %d entries", len(agent.memoryList))
+	}
+}
+
+func TestTimeoutAbortsRunningChild(t *testing.T) {
+	host := timelinex.NewOneLogicThread()
+	defer host.Shutdown(context.Background())
+
+	fallback := atomic.Bool{}
+	tree := NewTree("timeout", Selector("root",
+		Timeout("limit", 30*time.Millisecond, Action("forever", func(ctx *Context) Status {
+			return Running
+		})),
+		Action("fallback", func(ctx *Context) Status {
+			fallback.Store(true)
+			return Success
+		}),
+	))
+	agent := NewAgent(tree, host)
+	agent.Start()
+	defer agent.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !fallback.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected timeout to abort the running child")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRegistryLoadAndValidate(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterAction("attack", func(ctx *Context) Status { return Success })
+	registry.RegisterCondition("hasTarget", func(ctx *Context) bool { return true })
+
+	tree, err := registry.Load([]byte(`{
+		"name": "guard",
+		"root": {"type": "sequence", "children": [
+			{"type": "condition", "name": "hasTarget"},
+			{"type": "inverter", "child": {"type": "inverter", "child": {"type": "action", "name": "attack"}}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := NewAgent(tree, nil).Tick(16); status != Success {
+		t.Fatalf("expected loaded tree to succeed, got %s", status)
+	}
+
+	_, err = registry.Load([]byte(`{
+		"root": {"type": "selector", "children": [
+			{"type": "action", "name": "flee"},
+			{"type": "cooldown", "duration": "soon", "child": {"type": "action", "name": "attack"}},
+			{"type": "jump"}
+		]}
+	}`))
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, expected := range []string{
+		`root.children[0](action): action "flee" is not registered`,
+		`root.children[1](cooldown): invalid duration "soon"`,
+		`root.children[2](jump): unknown node type`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error to contain %q, got %v", expected, err)
+		}
+	}
+}
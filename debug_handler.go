@@ -0,0 +1,101 @@
+package timelinex
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var _ http.Handler = (*debugHandler)(nil)
+
+// 用于查看时间轴运行状态的http.Handler
+type debugHandler struct {
+}
+
+// 创建一个用于查看所有时间轴、observer及场景计时器的http.Handler,可以挂载到管理端的mux中
+// 默认输出HTML,当请求带有format=json参数或者Accept为application/json时输出JSON
+func NewDebugHandler() http.Handler {
+	return &debugHandler{}
+}
+
+func (h *debugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snapshot := DebugSnapshot()
+	if wantJSON(r) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(snapshot)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := debugPageTemplate.Execute(w, debugPage{
+		Now:       time.Now(),
+		Timelines: snapshot,
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func wantJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "json" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+type debugPage struct {
+	Now       time.Time
+	Timelines []TimelineDebugInfo
+}
+
+var debugPageTemplate = template.Must(template.New("timelinex").Funcs(template.FuncMap{
+	"formatTime": func(v time.Time) string {
+		if v.IsZero() {
+			return "-"
+		}
+		return v.Format("2006-01-02 15:04:05.000")
+	},
+	"until": func(now time.Time, v time.Time) string {
+		if v.IsZero() {
+			return "-"
+		}
+		return v.Sub(now).Round(time.Millisecond).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>timelinex</title>
+<style>
+body { font-family: monospace; margin: 16px; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<p>{{formatTime .Now}} · <a href="?format=json">json</a></p>
+{{range .Timelines}}
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>frame</th><th>last frame</th><th>delta ms</th><th>tick duration</th><th>one-time queue</th></tr>
+<tr><td>{{.FrameCount}}</td><td>{{formatTime .LastFrameTime}}</td><td>{{.LastDeltaMS}}</td><td>{{.LastTickDuration}}</td><td>{{.OneTimeQueueLength}}</td></tr>
+</table>
+<h3>observers ({{len .Observers}})</h3>
+<table>
+<tr><th>type</th><th>description</th><th>subscribed at</th><th>calls</th></tr>
+{{range .Observers}}<tr><td>{{.Type}}</td><td>{{.Description}}</td><td>{{formatTime .SubscribedAt}}</td><td>{{.CallCount}}</td></tr>
+{{end}}</table>
+<h3>scene timers ({{len .Timers}})</h3>
+<table>
+<tr><th>key</th><th>schedule</th><th>next fire</th><th>in</th><th>runs</th><th>error</th></tr>
+{{range .Timers}}<tr><td>{{.Key}}</td><td>{{.Schedule}}</td><td>{{formatTime .NextFireTime}}</td><td>{{until $.Now .NextFireTime}}</td><td>{{.RunCount}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>no timeline registered</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,141 @@
+package timelinex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type describedTestObserver struct {
+	testTimelineObserver
+}
+
+func (o *describedTestObserver) Description() string {
+	return "player saver"
+}
+
+func TestDebugHandlerListsObserversAndTimers(t *testing.T) {
+	thread := NewOneLogicThread()
+	defer thread.Shutdown(context.Background())
+
+	thread.Subscribe(&describedTestObserver{})
+	thread.StartRecurNewTimer(time.Hour, func() {}, SceneTimerOptionWithKey("hourly-save"))
+	time.Sleep(50 * time.Millisecond)
+
+	handler := NewDebugHandler()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/timeline?format=json", nil))
+
+	infoList := make([]TimelineDebugInfo, 0)
+	if err := json.Unmarshal(recorder.Body.Bytes(), &infoList); err != nil {
+		t.Fatalf("expected json output, got %v", err)
+	}
+	var info *TimelineDebugInfo
+	for i := range infoList {
+		if infoList[i].Name == thread.logicThread.ID() {
+			info = &infoList[i]
+		}
+	}
+	if info == nil {
+		t.Fatalf("expected logic thread timeline to be registered, got %+v", infoList)
+	}
+	if info.FrameCount == 0 {
+		t.Fatal("expected frame count to advance")
+	}
+	if len(info.Observers) != 1 || info.Observers[0].Description != "player saver" || info.Observers[0].CallCount == 0 {
+		t.Fatalf("unexpected observers %+v", info.Observers)
+	}
+	if len(info.Timers) != 1 || info.Timers[0].Key != "hourly-save" || info.Timers[0].NextFireTime.IsZero() {
+		t.Fatalf("unexpected timers %+v", info.Timers)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/timeline", nil))
+	body := recorder.Body.String()
+	if !strings.Contains(body, "hourly-save") || !strings.Contains(body, "*timelinex.describedTestObserver") {
+		t.Fatalf("expected html output to list observer and timer, got:\n%s", body)
+	}
+}
+
+type hitsDescribedTestObserver struct {
+	testTimelineObserver
+}
+
+func (o *hitsDescribedTestObserver) Description() string {
+	return fmt.Sprintf("hits %d", o.hits)
+}
+
+func TestDebugSnapshotDescribesObserversOnLogicThread(t *testing.T) {
+	thread := NewOneLogicThread()
+	defer thread.Shutdown(context.Background())
+
+	thread.Subscribe(&hitsDescribedTestObserver{})
+	for i := 0; i < 5; i++ {
+		for _, info := range DebugSnapshot() {
+			if info.Name != thread.logicThread.ID() {
+				continue
+			}
+			if len(info.Observers) != 1 || !strings.HasPrefix(info.Observers[0].Description, "hits ") {
+				t.Fatalf("unexpected observers %+v", info.Observers)
+			}
+		}
+	}
+
+	// 没有驱动的时间轴超时后仍然返回其他信息
+	tl := newTimeline()
+	tl.Subscribe(&hitsDescribedTestObserver{})
+	info := tl.debugInfo("idle")
+	if len(info.Observers) != 1 || info.Observers[0].Description != "" {
+		t.Fatalf("expected observer without description, got %+v", info.Observers)
+	}
+}
+
+func TestDebugSnapshotDropsStoppedLogicThread(t *testing.T) {
+	thread := NewOneLogicThread()
+	defer thread.Shutdown(context.Background())
+
+	registered := func() bool {
+		_timelineRegistry.rwLock.RLock()
+		defer _timelineRegistry.rwLock.RUnlock()
+		_, ok := _timelineRegistry.timelineList[thread.ITimeline.(*timeline)]
+		return ok
+	}
+	if !registered() {
+		t.Fatal("expected running logic thread to be registered")
+	}
+
+	thread.LogicThread().Shutdown(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for registered() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected stopped logic thread to be unregistered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDebugSnapshotDoesNotQueueOnPausedTimeline(t *testing.T) {
+	thread := NewOneLogicThread()
+	defer thread.Shutdown(context.Background())
+
+	thread.Subscribe(&hitsDescribedTestObserver{})
+	thread.Pause()
+	time.Sleep(30 * time.Millisecond)
+
+	tl := thread.ITimeline.(*timeline)
+	for i := 0; i < 3; i++ {
+		info := tl.debugInfo("paused")
+		if len(info.Observers) != 1 || info.Observers[0].Description != "" {
+			t.Fatalf("expected observer without description while paused, got %+v", info.Observers)
+		}
+	}
+	if v := atomic.LoadInt64(&tl.oneTimeQueueLength); v != 0 {
+		t.Fatalf("expected no queued description requests, got %d", v)
+	}
+}
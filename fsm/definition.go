@@ -0,0 +1,258 @@
+package fsm
+
+import (
+	"fmt"
+	"time"
+)
+
+// 状态回调
+type StateFunc func(ctx *Context)
+
+// 转换的守卫条件,返回false时转换不会发生
+type GuardFunc func(ctx *Context) bool
+
+// 状态机的声明式定义,一个定义可以被多个Machine实例共享
+type Definition struct {
+	name    string
+	initial string
+
+	stateList []*stateDef
+	stateMap  map[string]*stateDef
+}
+
+// 一个状态的定义
+type stateDef struct {
+	name    string
+	parent  string
+	initial string
+
+	onEnter  StateFunc
+	onUpdate StateFunc
+	onExit   StateFunc
+
+	transitionList []*transitionDef
+	timedList      []*timedTransitionDef
+
+	// 以下字段在Validate后有效
+	parentDef   *stateDef
+	childList   []*stateDef
+	depth       int
+	initialLeaf *stateDef
+}
+
+// 由事件触发的转换
+type transitionDef struct {
+	event  string
+	target string
+	guards []GuardFunc
+}
+
+// 在状态中停留指定时间后触发的转换
+type timedTransitionDef struct {
+	after  time.Duration
+	target string
+	guards []GuardFunc
+}
+
+func NewDefinition(name string) *Definition {
+	return &Definition{
+		name:      name,
+		stateList: make([]*stateDef, 0),
+		stateMap:  make(map[string]*stateDef),
+	}
+}
+
+// 状态机名称
+func (d *Definition) Name() string {
+	return d.name
+}
+
+// 设置状态机的初始状态,不设置时使用第一个声明的顶层状态
+func (d *Definition) SetInitial(name string) *Definition {
+	d.initial = name
+	return d
+}
+
+// 声明一个状态,如果状态已经存在则返回原有状态的构建器
+func (d *Definition) State(name string) *StateBuilder {
+	s, ok := d.stateMap[name]
+	if !ok {
+		s = &stateDef{
+			name:           name,
+			transitionList: make([]*transitionDef, 0),
+			timedList:      make([]*timedTransitionDef, 0),
+		}
+		d.stateList = append(d.stateList, s)
+		d.stateMap[name] = s
+	}
+	return &StateBuilder{state: s}
+}
+
+// 校验定义,并计算层次结构
+func (d *Definition) Validate() error {
+	if len(d.stateList) <= 0 {
+		return fmt.Errorf("fsm %s: no state defined", d.name)
+	}
+	for _, eachState := range d.stateList {
+		eachState.parentDef = nil
+		eachState.childList = make([]*stateDef, 0)
+		eachState.initialLeaf = nil
+	}
+	for _, eachState := range d.stateList {
+		if len(eachState.parent) <= 0 {
+			continue
+		}
+		parent, ok := d.stateMap[eachState.parent]
+		if !ok {
+			return fmt.Errorf("fsm %s: state %s has unknown parent %s", d.name, eachState.name, eachState.parent)
+		}
+		eachState.parentDef = parent
+		parent.childList = append(parent.childList, eachState)
+	}
+	for _, eachState := range d.stateList {
+		depth := 0
+		for p := eachState.parentDef; p != nil; p = p.parentDef {
+			depth++
+			if depth > len(d.stateList) {
+				return fmt.Errorf("fsm %s: parent cycle detected at state %s", d.name, eachState.name)
+			}
+		}
+		eachState.depth = depth
+	}
+	for _, eachState := range d.stateList {
+		if len(eachState.initial) > 0 {
+			child, ok := d.stateMap[eachState.initial]
+			if !ok || child.parentDef != eachState {
+				return fmt.Errorf("fsm %s: initial state %s of %s is not a child of it", d.name, eachState.initial, eachState.name)
+			}
+		}
+		for _, eachTransition := range eachState.transitionList {
+			if _, ok := d.stateMap[eachTransition.target]; !ok {
+				return fmt.Errorf("fsm %s: transition %s from %s targets unknown state %s", d.name, eachTransition.event, eachState.name, eachTransition.target)
+			}
+		}
+		for _, eachTimed := range eachState.timedList {
+			if _, ok := d.stateMap[eachTimed.target]; !ok {
+				return fmt.Errorf("fsm %s: timed transition from %s targets unknown state %s", d.name, eachState.name, eachTimed.target)
+			}
+			if eachTimed.after <= 0 {
+				return fmt.Errorf("fsm %s: timed transition from %s must have a positive duration", d.name, eachState.name)
+			}
+		}
+	}
+	if len(d.initial) > 0 {
+		if _, ok := d.stateMap[d.initial]; !ok {
+			return fmt.Errorf("fsm %s: unknown initial state %s", d.name, d.initial)
+		}
+	}
+	for _, eachState := range d.stateList {
+		eachState.initialLeaf = eachState.resolveInitialLeaf()
+	}
+	return nil
+}
+
+// 获取初始状态
+func (d *Definition) initialState() *stateDef {
+	if len(d.initial) > 0 {
+		return d.stateMap[d.initial]
+	}
+	for _, eachState := range d.stateList {
+		if eachState.parentDef == nil {
+			return eachState
+		}
+	}
+	return nil
+}
+
+// 进入一个复合状态时最终进入的叶子状态
+func (s *stateDef) resolveInitialLeaf() *stateDef {
+	current := s
+	for len(current.childList) > 0 {
+		next := current.childList[0]
+		for _, eachChild := range current.childList {
+			if eachChild.name == current.initial {
+				next = eachChild
+				break
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// 从根状态到当前状态的路径
+func (s *stateDef) path() []*stateDef {
+	result := make([]*stateDef, s.depth+1)
+	current := s
+	for i := s.depth; i >= 0; i-- {
+		result[i] = current
+		current = current.parentDef
+	}
+	return result
+}
+
+// 用于声明状态的构建器
+type StateBuilder struct {
+	state *stateDef
+}
+
+// 设置父状态,用于构建层次状态机
+func (b *StateBuilder) Parent(name string) *StateBuilder {
+	b.state.parent = name
+	return b
+}
+
+// 设置复合状态的初始子状态,不设置时使用第一个声明的子状态
+func (b *StateBuilder) Initial(child string) *StateBuilder {
+	b.state.initial = child
+	return b
+}
+
+// 进入状态时的回调
+func (b *StateBuilder) OnEnter(fn StateFunc) *StateBuilder {
+	b.state.onEnter = fn
+	return b
+}
+
+// 处于此状态时每一帧的回调,运行在时间轴线程中
+func (b *StateBuilder) OnUpdate(fn StateFunc) *StateBuilder {
+	b.state.onUpdate = fn
+	return b
+}
+
+// 离开状态时的回调
+func (b *StateBuilder) OnExit(fn StateFunc) *StateBuilder {
+	b.state.onExit = fn
+	return b
+}
+
+// 收到event事件时转换到target状态,guards全部通过时才会转换
+// 同一个事件可以声明多个转换,按声明顺序选择第一个守卫通过的转换
+func (b *StateBuilder) On(event string, target string, guards ...GuardFunc) *StateBuilder {
+	b.state.transitionList = append(b.state.transitionList, &transitionDef{
+		event:  event,
+		target: target,
+		guards: guards,
+	})
+	return b
+}
+
+// 在此状态(包括其子状态)中停留after时间后转换到target状态
+// 计时器基于场景计时器,离开此状态时自动取消
+func (b *StateBuilder) After(after time.Duration, target string, guards ...GuardFunc) *StateBuilder {
+	b.state.timedList = append(b.state.timedList, &timedTransitionDef{
+		after:  after,
+		target: target,
+		guards: guards,
+	})
+	return b
+}
+
+func passGuards(ctx *Context, guards []GuardFunc) bool {
+	for _, eachGuard := range guards {
+		if eachGuard != nil && !eachGuard(ctx) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package fsm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 将状态机定义导出为Graphviz DOT格式
+// 复合状态导出为cluster子图,定时转换的边以"after 时间"标注,带守卫的转换以"[guarded]"标注
+func (d *Definition) DOT() (string, error) {
+	if err := d.Validate(); err != nil {
+		return "", err
+	}
+	return d.dot(""), nil
+}
+
+// 导出状态机的DOT格式,当前所处的状态会被高亮
+func (m *Machine) DOT() string {
+	return m.def.dot(m.Current())
+}
+
+func (d *Definition) dot(current string) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "digraph %s {\n", dotQuote(d.name))
+	b.WriteString("\tcompound=true;\n")
+	b.WriteString("\tnode [shape=box, style=rounded];\n")
+	b.WriteString("\t\"__start\" [shape=point];\n")
+
+	for _, eachState := range d.stateList {
+		if eachState.parentDef == nil {
+			d.writeDotState(b, eachState, current, 1)
+		}
+	}
+
+	if initial := d.initialState(); initial != nil {
+		b.WriteString("\t\"__start\" -> ")
+		b.WriteString(dotQuote(initial.initialLeaf.name))
+		if len(initial.childList) > 0 {
+			fmt.Fprintf(b, " [lhead=%s]", dotQuote(dotClusterName(initial)))
+		}
+		b.WriteString(";\n")
+	}
+
+	for _, eachState := range d.stateList {
+		for _, eachTransition := range eachState.transitionList {
+			label := eachTransition.event
+			if len(eachTransition.guards) > 0 {
+				label += " [guarded]"
+			}
+			d.writeDotEdge(b, eachState, d.stateMap[eachTransition.target], label, false)
+		}
+		for _, eachTimed := range eachState.timedList {
+			label := "after " + eachTimed.after.String()
+			if len(eachTimed.guards) > 0 {
+				label += " [guarded]"
+			}
+			d.writeDotEdge(b, eachState, d.stateMap[eachTimed.target], label, true)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (d *Definition) writeDotState(b *strings.Builder, s *stateDef, current string, indent int) {
+	prefix := strings.Repeat("\t", indent)
+	if len(s.childList) <= 0 {
+		b.WriteString(prefix)
+		b.WriteString(dotQuote(s.name))
+		if s.name == current {
+			b.WriteString(" [style=\"rounded,filled\", fillcolor=lightblue]")
+		}
+		b.WriteString(";\n")
+		return
+	}
+	fmt.Fprintf(b, "%ssubgraph %s {\n", prefix, dotQuote(dotClusterName(s)))
+	fmt.Fprintf(b, "%s\tlabel=%s;\n", prefix, dotQuote(s.name))
+	for _, eachChild := range s.childList {
+		d.writeDotState(b, eachChild, current, indent+1)
+	}
+	fmt.Fprintf(b, "%s}\n", prefix)
+}
+
+// 复合状态没有对应的节点,边连接到它的初始叶子状态并使用ltail/lhead指向cluster
+func (d *Definition) writeDotEdge(b *strings.Builder, from *stateDef, to *stateDef, label string, timed bool) {
+	attrList := []string{"label=" + dotQuote(label)}
+	if timed {
+		attrList = append(attrList, "style=dashed")
+	}
+	if len(from.childList) > 0 {
+		attrList = append(attrList, "ltail="+dotQuote(dotClusterName(from)))
+	}
+	if len(to.childList) > 0 {
+		attrList = append(attrList, "lhead="+dotQuote(dotClusterName(to)))
+	}
+	fmt.Fprintf(b, "\t%s -> %s [%s];\n",
+		dotQuote(from.initialLeaf.name),
+		dotQuote(to.initialLeaf.name),
+		strings.Join(attrList, ", "))
+}
+
+func dotClusterName(s *stateDef) string {
+	return "cluster_" + s.name
+}
+
+func dotQuote(v string) string {
+	return "\"" + strings.ReplaceAll(v, "\"", "\\\"") + "\""
+}
@@ -0,0 +1,396 @@
+package fsm
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/abmpio/timelinex"
+	"github.com/lithammer/shortuuid/v4"
+)
+
+const (
+	defaultHistoryLimit = 100
+)
+
+// 状态机的宿主,提供时间轴与场景计时器,如timelinex.OneLogicThread
+type Host interface {
+	timelinex.ITimeline
+	timelinex.ISceneTimer
+}
+
+// 状态回调的上下文
+type Context struct {
+	Machine *Machine
+	// 回调所属的状态
+	State string
+	// 引起当前转换的事件,定时转换时为空
+	Event string
+	// 事件携带的数据
+	Data interface{}
+	// 与上一帧相差的毫秒值,只在OnUpdate中有效
+	DeltaMS float64
+}
+
+// 一次状态转换的记录,用于调试
+type TransitionRecord struct {
+	From  string
+	To    string
+	Event string
+	// 是否由定时转换触发
+	Timed bool
+	At    time.Time
+}
+
+type MachineOption func(m *Machine)
+
+// 设置状态机的id,用于生成计时器的key,默认自动生成
+func MachineOptionWithID(id string) MachineOption {
+	return func(m *Machine) {
+		m.id = id
+	}
+}
+
+// 设置保留的转换历史条数,默认为100,小于等于0时不记录历史
+func MachineOptionWithHistoryLimit(limit int) MachineOption {
+	return func(m *Machine) {
+		m.historyLimit = limit
+	}
+}
+
+var _ timelinex.ITimelineObserver = (*Machine)(nil)
+
+// 由时间轴驱动的状态机
+// 除Post、Current、History之外的方法及所有回调都应运行在时间轴线程中
+type Machine struct {
+	id   string
+	def  *Definition
+	host Host
+
+	// 保护current与historyList,以便在其它协程中读取
+	rwLock      sync.RWMutex
+	current     *stateDef
+	historyList []TransitionRecord
+
+	historyLimit int
+	// 每个激活状态进入时的序号,用于判断定时转换是否已经失效
+	enterGenerationList map[string]uint64
+	generation          uint64
+	// 每个激活状态启动的计时器key
+	activeTimerList map[string][]string
+
+	// 正在执行转换时触发的事件会排队,在当前转换完成后处理
+	transiting       bool
+	pendingEventList []pendingEvent
+}
+
+type pendingEvent struct {
+	event string
+	data  interface{}
+}
+
+// 创建一个状态机,定义会在这里校验
+func NewMachine(def *Definition, host Host, opts ...MachineOption) (*Machine, error) {
+	if def == nil {
+		return nil, fmt.Errorf("fsm: definition is nil")
+	}
+	if host == nil {
+		return nil, fmt.Errorf("fsm %s: host is nil", def.name)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	m := &Machine{
+		id:                  shortuuid.New(),
+		def:                 def,
+		host:                host,
+		historyList:         make([]TransitionRecord, 0),
+		historyLimit:        defaultHistoryLimit,
+		enterGenerationList: make(map[string]uint64),
+		activeTimerList:     make(map[string][]string),
+		pendingEventList:    make([]pendingEvent, 0),
+	}
+	for _, eachOpt := range opts {
+		eachOpt(m)
+	}
+	return m, nil
+}
+
+// 状态机id
+func (m *Machine) ID() string {
+	return m.id
+}
+
+// 状态机定义
+func (m *Machine) Definition() *Definition {
+	return m.def
+}
+
+// 启动状态机,可以在任意协程中调用
+// 初始状态在下一帧的时间轴线程中进入,之后每一帧调用激活状态的OnUpdate
+func (m *Machine) Start() {
+	m.host.SubscribeAsOneTime(timelinex.Observer(m.enterInitial), nil)
+	m.host.Subscribe(m)
+}
+
+// 停止状态机,可以在任意协程中调用
+// 立即取消订阅时间轴,所有激活的状态在下一帧的时间轴线程中依次退出,排队的事件被丢弃
+func (m *Machine) Stop() {
+	m.host.Unsubscribe(m)
+	m.host.SubscribeAsOneTime(timelinex.Observer(m.exitAll), nil)
+}
+
+// 当前所处的叶子状态,未启动时为空
+func (m *Machine) Current() string {
+	m.rwLock.RLock()
+	defer m.rwLock.RUnlock()
+	if m.current == nil {
+		return ""
+	}
+	return m.current.name
+}
+
+// 当前是否处于state状态,对于复合状态,处于它的任意子状态时也返回true
+func (m *Machine) Is(state string) bool {
+	m.rwLock.RLock()
+	defer m.rwLock.RUnlock()
+	for s := m.current; s != nil; s = s.parentDef {
+		if s.name == state {
+			return true
+		}
+	}
+	return false
+}
+
+// 转换历史的副本,按发生的先后顺序排列
+func (m *Machine) History() []TransitionRecord {
+	m.rwLock.RLock()
+	defer m.rwLock.RUnlock()
+	return append([]TransitionRecord(nil), m.historyList...)
+}
+
+// 触发一个事件,必须在时间轴线程中调用
+// 从当前叶子状态开始向上查找能处理此事件的转换,返回是否发生了转换
+// 在状态回调中触发的事件会在当前转换完成后处理,此时总是返回true
+func (m *Machine) Fire(event string, data interface{}) bool {
+	timelinex.AssertOnLogicThread(m.host)
+	if m.transiting {
+		m.pendingEventList = append(m.pendingEventList, pendingEvent{event: event, data: data})
+		return true
+	}
+	result := m.fire(event, data)
+	m.drainPendingEvents()
+	return result
+}
+
+// 在任意协程中触发一个事件,事件会在下一帧的时间轴线程中处理
+func (m *Machine) Post(event string, data interface{}) {
+	m.host.SubscribeAsOneTime(timelinex.Observer(func() {
+		m.Fire(event, data)
+	}), nil)
+}
+
+// #region ITimelineObserver Members
+
+func (m *Machine) OnNext(deltaMS float64) {
+	if m.current == nil {
+		return
+	}
+	for _, eachState := range m.current.path() {
+		if m.current == nil {
+			// 在回调中被停止
+			return
+		}
+		if eachState.onUpdate == nil {
+			continue
+		}
+		eachState.onUpdate(&Context{
+			Machine: m,
+			State:   eachState.name,
+			DeltaMS: deltaMS,
+		})
+	}
+}
+
+// #endregion
+
+func (m *Machine) enterInitial() {
+	if m.current != nil {
+		return
+	}
+	initial := m.def.initialState()
+	m.transit(nil, initial, &Context{Machine: m}, false)
+	m.drainPendingEvents()
+}
+
+// 依次退出所有激活的状态,运行在时间轴线程中
+func (m *Machine) exitAll() {
+	m.pendingEventList = m.pendingEventList[:0]
+	if m.current == nil {
+		return
+	}
+	path := m.current.path()
+	for i := len(path) - 1; i >= 0; i-- {
+		m.exitState(path[i], &Context{Machine: m})
+	}
+	m.setCurrent(nil)
+}
+
+// 处理在转换过程中排队的事件
+func (m *Machine) drainPendingEvents() {
+	for len(m.pendingEventList) > 0 {
+		next := m.pendingEventList[0]
+		m.pendingEventList = m.pendingEventList[1:]
+		m.fire(next.event, next.data)
+	}
+}
+
+func (m *Machine) fire(event string, data interface{}) bool {
+	if m.current == nil {
+		return false
+	}
+	ctx := &Context{
+		Machine: m,
+		Event:   event,
+		Data:    data,
+	}
+	for s := m.current; s != nil; s = s.parentDef {
+		for _, eachTransition := range s.transitionList {
+			if eachTransition.event != event {
+				continue
+			}
+			ctx.State = s.name
+			if !passGuards(ctx, eachTransition.guards) {
+				continue
+			}
+			m.transit(m.current, m.def.stateMap[eachTransition.target], ctx, false)
+			return true
+		}
+	}
+	return false
+}
+
+// 定时转换的回调,运行在时间轴线程中
+func (m *Machine) fireTimed(s *stateDef, timed *timedTransitionDef, generation uint64) {
+	if m.enterGenerationList[s.name] != generation {
+		// 状态已经退出或者重新进入,计时器失效
+		return
+	}
+	ctx := &Context{
+		Machine: m,
+		State:   s.name,
+	}
+	if !passGuards(ctx, timed.guards) {
+		return
+	}
+	m.transit(m.current, m.def.stateMap[timed.target], ctx, true)
+	m.drainPendingEvents()
+}
+
+// 从from转换到target,退出到共同祖先后再进入target及其初始子状态
+func (m *Machine) transit(from *stateDef, target *stateDef, ctx *Context, timed bool) {
+	m.transiting = true
+	defer func() {
+		m.transiting = false
+	}()
+
+	targetPath := target.path()
+	fromPath := make([]*stateDef, 0)
+	if from != nil {
+		fromPath = from.path()
+	}
+	common := 0
+	for common < len(fromPath) && common < len(targetPath) && fromPath[common] == targetPath[common] {
+		common++
+	}
+	if common == len(targetPath) {
+		// 转换到自身或祖先状态,退出并重新进入目标状态
+		common = len(targetPath) - 1
+	}
+
+	for i := len(fromPath) - 1; i >= common; i-- {
+		m.exitState(fromPath[i], ctx)
+	}
+	leaf := target.initialLeaf
+	m.setCurrent(leaf)
+	for _, eachState := range leaf.path()[common:] {
+		m.enterState(eachState, ctx)
+	}
+
+	fromName := ""
+	if from != nil {
+		fromName = from.name
+	}
+	m.record(TransitionRecord{
+		From:  fromName,
+		To:    leaf.name,
+		Event: ctx.Event,
+		Timed: timed,
+		At:    time.Now(),
+	})
+}
+
+func (m *Machine) enterState(s *stateDef, ctx *Context) {
+	m.generation++
+	generation := m.generation
+	m.enterGenerationList[s.name] = generation
+
+	if s.onEnter != nil {
+		s.onEnter(&Context{
+			Machine: m,
+			State:   s.name,
+			Event:   ctx.Event,
+			Data:    ctx.Data,
+		})
+	}
+
+	if len(s.timedList) <= 0 {
+		return
+	}
+	keyList := make([]string, 0, len(s.timedList))
+	for i, eachTimed := range s.timedList {
+		timed := eachTimed
+		key := fmt.Sprintf("fsm:%s:%s:%d:%d", m.id, s.name, i, generation)
+		m.host.StartNewOneTimer(timed.after, func() {
+			m.fireTimed(s, timed, generation)
+		}, timelinex.SceneTimerOptionWithKey(key))
+		keyList = append(keyList, key)
+	}
+	m.activeTimerList[s.name] = keyList
+}
+
+func (m *Machine) exitState(s *stateDef, ctx *Context) {
+	// 先取消此状态的计时器
+	for _, eachKey := range m.activeTimerList[s.name] {
+		m.host.RemoveTimer(eachKey)
+	}
+	delete(m.activeTimerList, s.name)
+	delete(m.enterGenerationList, s.name)
+
+	if s.onExit != nil {
+		s.onExit(&Context{
+			Machine: m,
+			State:   s.name,
+			Event:   ctx.Event,
+			Data:    ctx.Data,
+		})
+	}
+}
+
+func (m *Machine) setCurrent(s *stateDef) {
+	m.rwLock.Lock()
+	defer m.rwLock.Unlock()
+	m.current = s
+}
+
+func (m *Machine) record(r TransitionRecord) {
+	if m.historyLimit <= 0 {
+		return
+	}
+	m.rwLock.Lock()
+	defer m.rwLock.Unlock()
+	m.historyList = append(m.historyList, r)
+	if len(m.historyList) > m.historyLimit {
+		m.historyList = append([]TransitionRecord(nil), m.historyList[len(m.historyList)-m.historyLimit:]...)
+	}
+}
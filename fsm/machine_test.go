@@ -0,0 +1,167 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abmpio/timelinex"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("condition not reached before deadline")
+}
+
+func newMatchDefinition(lobbyTimeout time.Duration, exitList *[]string) *Definition {
+	def := NewDefinition("match")
+	def.State("Lobby").
+		OnExit(func(ctx *Context) { *exitList = append(*exitList, ctx.State) }).
+		After(lobbyTimeout, "Countdown").
+		On("start", "Countdown")
+	def.State("InGame").
+		Initial("Countdown").
+		OnExit(func(ctx *Context) { *exitList = append(*exitList, ctx.State) }).
+		On("abort", "Lobby")
+	def.State("Countdown").Parent("InGame").On("go", "Playing")
+	def.State("Playing").Parent("InGame")
+	return def
+}
+
+func TestMachineTimedTransition(t *testing.T) {
+	host := timelinex.NewOneLogicThread()
+	defer host.Shutdown(context.Background())
+
+	exitList := make([]string, 0)
+	m, err := NewMachine(newMatchDefinition(30*time.Millisecond, &exitList), host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+
+	waitFor(t, func() bool { return m.Current() == "Countdown" })
+	if !m.Is("InGame") {
+		t.Fatal("expected Countdown to be inside InGame")
+	}
+	history := m.History()
+	if len(history) != 2 || !history[1].Timed || history[1].From != "Lobby" {
+		t.Fatalf("unexpected history %+v", history)
+	}
+}
+
+func TestMachineHierarchicalEventsAndTimerCancellation(t *testing.T) {
+	host := timelinex.NewOneLogicThread()
+	defer host.Shutdown(context.Background())
+
+	exitList := make([]string, 0)
+	m, err := NewMachine(newMatchDefinition(80*time.Millisecond, &exitList), host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	waitFor(t, func() bool { return m.Current() == "Lobby" })
+
+	m.Post("start", nil)
+	m.Post("go", nil)
+	waitFor(t, func() bool { return m.Current() == "Playing" })
+
+	// abort 由父状态InGame处理
+	m.Post("abort", nil)
+	waitFor(t, func() bool { return m.Current() == "Lobby" })
+
+	m.Post("start", nil)
+	waitFor(t, func() bool { return m.Current() == "Countdown" })
+
+	// Lobby的定时转换已经随着退出被取消,不应再次触发
+	time.Sleep(150 * time.Millisecond)
+	for _, eachRecord := range m.History() {
+		if eachRecord.Timed {
+			t.Fatalf("expected lobby timer to be cancelled, got history %+v", m.History())
+		}
+	}
+}
+
+func TestMachineGuardBlocksTransition(t *testing.T) {
+	host := timelinex.NewOneLogicThread()
+	defer host.Shutdown(context.Background())
+
+	ready := false
+	def := NewDefinition("guard")
+	def.State("Idle").On("run", "Running", func(ctx *Context) bool { return ready })
+	def.State("Running")
+	m, err := NewMachine(def, host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	waitFor(t, func() bool { return m.Current() == "Idle" })
+
+	m.Post("run", nil)
+	time.Sleep(50 * time.Millisecond)
+	if m.Current() != "Idle" {
+		t.Fatalf("expected guard to block transition, got %s", m.Current())
+	}
+	host.SubscribeAsOneTime(timelinex.Observer(func() { ready = true }), nil)
+	m.Post("run", nil)
+	waitFor(t, func() bool { return m.Current() == "Running" })
+}
+
+func TestDefinitionValidateAndDOT(t *testing.T) {
+	def := NewDefinition("broken")
+	def.State("A").On("x", "Missing")
+	if err := def.Validate(); err == nil {
+		t.Fatal("expected unknown target to fail validation")
+	}
+
+	exitList := make([]string, 0)
+	dot, err := newMatchDefinition(time.Second, &exitList).DOT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"subgraph \"cluster_InGame\"",
+		"\"Lobby\" -> \"Countdown\" [label=\"after 1s\", style=dashed]",
+		"\"Countdown\" -> \"Lobby\" [label=\"abort\", ltail=\"cluster_InGame\"]",
+	} {
+		if !strings.Contains(dot, expected) {
+			t.Fatalf("expected dot output to contain %q, got:\n%s", expected, dot)
+		}
+	}
+}
+
+func TestMachineStopExitsStatesOnLogicThread(t *testing.T) {
+	host := timelinex.NewOneLogicThread()
+	defer host.Shutdown(context.Background())
+
+	exitList := make([]string, 0)
+	onLogicThread := true
+	def := NewDefinition("match")
+	def.State("Lobby").
+		OnExit(func(ctx *Context) {
+			onLogicThread = onLogicThread && host.IsOnLogicThread()
+			exitList = append(exitList, ctx.State)
+		})
+	m, err := NewMachine(def, host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Start()
+	waitFor(t, func() bool { return m.Current() == "Lobby" })
+
+	m.Stop()
+	waitFor(t, func() bool { return m.Current() == "" })
+	done := make(chan struct{})
+	host.SubscribeAsOneTime(timelinex.Observer(func() { close(done) }), nil)
+	<-done
+	if !onLogicThread || len(exitList) != 1 || exitList[0] != "Lobby" {
+		t.Fatalf("expected Lobby to exit once on the logic thread, got %v (on logic thread %v)", exitList, onLogicThread)
+	}
+}
@@ -0,0 +1,209 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// 依赖的任务不属于当前的图
+	ErrForeignJob = errors.New("jobs: dependency belongs to another graph")
+)
+
+// 任务图中存在循环依赖
+type CycleError struct {
+	// 组成循环的任务名称,首尾相同
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "jobs: dependency cycle " + strings.Join(e.Path, " -> ")
+}
+
+// 一帧中需要执行的一个任务
+type Job struct {
+	graph *Graph
+	index int
+	name  string
+	fn    func()
+	deps  []*Job
+}
+
+// 任务的名称
+func (j *Job) Name() string {
+	return j.name
+}
+
+// 增加依赖,当前任务在deps全部完成后才会执行
+func (j *Job) After(deps ...*Job) *Job {
+	for _, eachDep := range deps {
+		if eachDep == nil {
+			continue
+		}
+		if eachDep.graph != j.graph {
+			j.graph.err = errors.Join(j.graph.err, fmt.Errorf("%w: %s -> %s", ErrForeignJob, j.name, eachDep.name))
+			continue
+		}
+		j.deps = append(j.deps, eachDep)
+	}
+	return j
+}
+
+// 任务图,描述一帧中需要执行的任务以及它们之间的依赖
+// 任务图只能在一个协程中构建,构建完成后交给Scheduler.Run执行
+type Graph struct {
+	jobList []*Job
+	// 上一个Barrier之前的任务,之后加入的任务都依赖这些任务
+	barrierList []*Job
+	// 上一个Barrier之后加入的任务
+	phaseList []*Job
+	err       error
+}
+
+func NewGraph() *Graph {
+	return &Graph{
+		jobList:     make([]*Job, 0),
+		barrierList: make([]*Job, 0),
+		phaseList:   make([]*Job, 0),
+	}
+}
+
+// 增加一个任务,fn在deps全部完成后执行
+func (g *Graph) Add(name string, fn func(), deps ...*Job) *Job {
+	j := &Job{
+		graph: g,
+		index: len(g.jobList),
+		name:  name,
+		fn:    fn,
+		deps:  make([]*Job, 0, len(deps)+len(g.barrierList)),
+	}
+	j.deps = append(j.deps, g.barrierList...)
+	j.After(deps...)
+	g.jobList = append(g.jobList, j)
+	g.phaseList = append(g.phaseList, j)
+	return j
+}
+
+// 屏障,之后加入的任务都在屏障之前的任务全部完成后才会执行
+func (g *Graph) Barrier() {
+	if len(g.phaseList) <= 0 {
+		return
+	}
+	g.barrierList = g.phaseList
+	g.phaseList = make([]*Job, 0)
+}
+
+// 任务数量
+func (g *Graph) Len() int {
+	return len(g.jobList)
+}
+
+// 检查任务图,存在循环依赖时返回*CycleError
+func (g *Graph) Validate() error {
+	_, err := g.Order()
+	return err
+}
+
+// 按拓扑顺序返回所有的任务,没有依赖关系的任务按加入的顺序排列
+func (g *Graph) Order() ([]*Job, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	pending := make([]int, len(g.jobList))
+	dependents := g.dependents()
+	for _, eachJob := range g.jobList {
+		pending[eachJob.index] = len(eachJob.deps)
+	}
+
+	result := make([]*Job, 0, len(g.jobList))
+	done := make([]bool, len(g.jobList))
+	for len(result) < len(g.jobList) {
+		// 每次取加入顺序最靠前的可执行任务,保证顺序是确定的
+		next := -1
+		for i := range g.jobList {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, g.findCycle(done)
+		}
+		done[next] = true
+		result = append(result, g.jobList[next])
+		for _, eachDependent := range dependents[next] {
+			pending[eachDependent.index]--
+		}
+	}
+	return result, nil
+}
+
+// 每个任务被哪些任务依赖
+func (g *Graph) dependents() [][]*Job {
+	result := make([][]*Job, len(g.jobList))
+	for _, eachJob := range g.jobList {
+		for _, eachDep := range eachJob.deps {
+			result[eachDep.index] = append(result[eachDep.index], eachJob)
+		}
+	}
+	return result
+}
+
+// 在没有完成的任务中查找一个循环
+func (g *Graph) findCycle(done []bool) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(g.jobList))
+	stack := make([]*Job, 0)
+	var cycle []*Job
+
+	var visit func(j *Job) bool
+	visit = func(j *Job) bool {
+		state[j.index] = visiting
+		stack = append(stack, j)
+		for _, eachDep := range j.deps {
+			if done[eachDep.index] {
+				continue
+			}
+			switch state[eachDep.index] {
+			case visiting:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == eachDep {
+						cycle = append([]*Job(nil), stack[i:]...)
+						break
+					}
+				}
+				return true
+			case unvisited:
+				if visit(eachDep) {
+					return true
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[j.index] = visited
+		return false
+	}
+	for _, eachJob := range g.jobList {
+		if done[eachJob.index] || state[eachJob.index] != unvisited {
+			continue
+		}
+		if visit(eachJob) {
+			break
+		}
+	}
+
+	// stack中是依赖方向,反转后为执行方向
+	path := make([]string, 0, len(cycle)+1)
+	for i := len(cycle) - 1; i >= 0; i-- {
+		path = append(path, cycle[i].name)
+	}
+	if len(path) > 0 {
+		path = append(path, path[0])
+	}
+	return &CycleError{Path: path}
+}
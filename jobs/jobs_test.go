@@ -0,0 +1,167 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abmpio/timelinex/threading"
+)
+
+func TestSchedulerRespectsDependencies(t *testing.T) {
+	s := NewScheduler(SchedulerOptionWithWorkers(4))
+	defer s.Shutdown(context.Background())
+
+	var lock sync.Mutex
+	finished := make(map[string]bool)
+	record := func(name string, deps ...string) func() {
+		return func() {
+			time.Sleep(2 * time.Millisecond)
+			lock.Lock()
+			defer lock.Unlock()
+			for _, eachDep := range deps {
+				if !finished[eachDep] {
+					t.Errorf("%s ran before %s", name, eachDep)
+				}
+			}
+			finished[name] = true
+		}
+	}
+
+	g := NewGraph()
+	physics := g.Add("physics", record("physics"))
+	ai := g.Add("ai", record("ai"))
+	g.Add("collision", record("collision", "physics", "ai"), physics, ai)
+	g.Barrier()
+	g.Add("network", record("network", "physics", "ai", "collision"))
+
+	report, err := s.Run(g)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(finished) != 4 {
+		t.Fatalf("expected 4 jobs to finish, got %v", finished)
+	}
+	for _, eachTiming := range report.Jobs {
+		if eachTiming.Start.IsZero() || eachTiming.Duration <= 0 {
+			t.Fatalf("expected timing for %s, got %+v", eachTiming.Name, eachTiming)
+		}
+	}
+}
+
+func TestSchedulerRunsIndependentJobsInParallel(t *testing.T) {
+	s := NewScheduler(SchedulerOptionWithWorkers(4))
+	defer s.Shutdown(context.Background())
+
+	var running, maxRunning int32
+	g := NewGraph()
+	for i := 0; i < 4; i++ {
+		g.Add("work", func() {
+			v := atomic.AddInt32(&running, 1)
+			for {
+				old := atomic.LoadInt32(&maxRunning)
+				if v <= old || atomic.CompareAndSwapInt32(&maxRunning, old, v) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+	if _, err := s.Run(g); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if atomic.LoadInt32(&maxRunning) < 2 {
+		t.Fatalf("expected jobs to run in parallel, max concurrency %d", maxRunning)
+	}
+}
+
+func TestGraphDetectsCycle(t *testing.T) {
+	g := NewGraph()
+	a := g.Add("a", nil)
+	b := g.Add("b", nil, a)
+	c := g.Add("c", nil, b)
+	a.After(c)
+	g.Add("d", nil)
+
+	var cycleErr *CycleError
+	if err := g.Validate(); !errors.As(err, &cycleErr) {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if path := strings.Join(cycleErr.Path, " -> "); path != "a -> b -> c -> a" && path != "b -> c -> a -> b" && path != "c -> a -> b -> c" {
+		t.Fatalf("unexpected cycle path %s", path)
+	}
+
+	s := NewScheduler(SchedulerOptionWithDeterministic())
+	if _, err := s.Run(g); !errors.As(err, &cycleErr) {
+		t.Fatalf("expected Run to reject the cycle, got %v", err)
+	}
+}
+
+func TestDeterministicSchedulerRunsInTopologicalOrder(t *testing.T) {
+	s := NewScheduler(SchedulerOptionWithDeterministic())
+	order := make([]string, 0)
+	add := func(g *Graph, name string, deps ...*Job) *Job {
+		return g.Add(name, func() { order = append(order, name) }, deps...)
+	}
+
+	g := NewGraph()
+	late := add(g, "late")
+	early := add(g, "early")
+	late.After(early)
+	add(g, "free")
+	add(g, "last", late)
+
+	if _, err := s.Run(g); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := strings.Join(order, ","); got != "early,late,free,last" {
+		t.Fatalf("unexpected order %s", got)
+	}
+}
+
+func TestSchedulerSkipsDependentsOfPanickedJob(t *testing.T) {
+	s := NewScheduler(SchedulerOptionWithWorkers(2))
+	defer s.Shutdown(context.Background())
+
+	var ran int32
+	g := NewGraph()
+	broken := g.Add("broken", func() { panic("boom") })
+	g.Add("dependent", func() { atomic.AddInt32(&ran, 1) }, broken)
+	g.Add("independent", func() { atomic.AddInt32(&ran, 1) })
+
+	report, err := s.Run(g)
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Job != "broken" {
+		t.Fatalf("expected panic error from broken, got %v", err)
+	}
+	if atomic.LoadInt32(&ran) != 1 || !report.Jobs[1].Skipped {
+		t.Fatalf("expected only the independent job to run, report %+v", report.Jobs)
+	}
+}
+
+func TestSchedulerRunAfterShutdownOrWithStoppedPool(t *testing.T) {
+	g := NewGraph()
+	g.Add("noop", func() {})
+
+	s := NewScheduler(SchedulerOptionWithWorkers(2))
+	s.Shutdown(context.Background())
+	if _, err := s.Run(g); !errors.Is(err, ErrSchedulerClosed) {
+		t.Fatalf("expected ErrSchedulerClosed, got %v", err)
+	}
+
+	pool := threading.NewThreadPool(2, nil)
+	s = NewScheduler(SchedulerOptionWithThreadPool(pool))
+	if _, err := s.Run(g); !errors.Is(err, ErrPoolNotRunning) {
+		t.Fatalf("expected ErrPoolNotRunning for an unstarted pool, got %v", err)
+	}
+	pool.Start()
+	defer pool.Shutdown(context.Background())
+	if _, err := s.Run(g); err != nil {
+		t.Fatalf("expected Run to succeed once the pool is started, got %v", err)
+	}
+}
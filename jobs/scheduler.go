@@ -0,0 +1,251 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abmpio/timelinex/threading"
+)
+
+var (
+	// 调用Shutdown之后再调用Run
+	ErrSchedulerClosed = errors.New("jobs: scheduler is shut down")
+	// 通过SchedulerOptionWithThreadPool传入的线程池没有运行,任务不会被执行
+	ErrPoolNotRunning = errors.New("jobs: thread pool is not running")
+)
+
+// 一个任务的执行情况
+type JobTiming struct {
+	Name string `json:"name"`
+	// 开始执行的时间
+	Start time.Time `json:"start"`
+	// 执行耗时
+	Duration time.Duration `json:"duration"`
+	// 任务发生panic时的信息
+	Panic interface{} `json:"panic,omitempty"`
+	// 依赖的任务发生panic,这个任务没有执行
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// 一次Run的执行报告
+type Report struct {
+	// 按任务加入的顺序排列
+	Jobs []JobTiming `json:"jobs"`
+	// 从Run开始到所有任务完成的总耗时
+	Total time.Duration `json:"total"`
+}
+
+// 任务发生了panic
+type PanicError struct {
+	Job   string
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("jobs: job %s panicked: %v", e.Job, e.Value)
+}
+
+type schedulerOptions struct {
+	workers       int
+	deterministic bool
+	pool          *threading.ThreadPool
+}
+
+type SchedulerOption func(o *schedulerOptions)
+
+// 工作线程的数量,默认为runtime.NumCPU()
+func SchedulerOptionWithWorkers(workers int) SchedulerOption {
+	return func(o *schedulerOptions) {
+		if workers > 0 {
+			o.workers = workers
+		}
+	}
+}
+
+// 确定性模式,所有任务在调用Run的协程中按拓扑顺序串行执行,用于回放
+func SchedulerOptionWithDeterministic() SchedulerOption {
+	return func(o *schedulerOptions) {
+		o.deterministic = true
+	}
+}
+
+// 使用已有的线程池执行任务,线程池需要由调用者启动与停止
+// Run时线程池没有运行会返回ErrPoolNotRunning
+func SchedulerOptionWithThreadPool(pool *threading.ThreadPool) SchedulerOption {
+	return func(o *schedulerOptions) {
+		o.pool = pool
+	}
+}
+
+// 在线程池中并行执行任务图
+// 一般在时间轴的observer中构建任务图并调用Run,Run会阻塞到所有任务完成(屏障),
+// 因此时间轴中的其它observer以及下一帧都不会与任务并行执行,任务之外的代码仍然是单线程的
+type Scheduler struct {
+	*schedulerOptions
+	ownPool bool
+	closed  atomic.Bool
+}
+
+func NewScheduler(opts ...SchedulerOption) *Scheduler {
+	options := &schedulerOptions{
+		workers: runtime.NumCPU(),
+	}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+	s := &Scheduler{
+		schedulerOptions: options,
+	}
+	if s.pool == nil && !s.deterministic {
+		s.pool = threading.NewThreadPool(s.workers, nil, threading.ThreadOptionWithName("jobs"))
+		s.pool.Start()
+		s.ownPool = true
+	}
+	return s
+}
+
+// 是否为确定性模式
+func (s *Scheduler) Deterministic() bool {
+	return s.deterministic
+}
+
+// 执行任务图并等待所有任务完成
+// 任务图存在循环依赖时不执行任何任务并返回*CycleError
+// 有任务发生panic时,依赖它的任务不会执行,返回第一个*PanicError
+// Shutdown之后返回ErrSchedulerClosed,线程池没有运行时返回ErrPoolNotRunning,这两种情况都不执行任何任务
+func (s *Scheduler) Run(g *Graph) (*Report, error) {
+	if s.closed.Load() {
+		return nil, ErrSchedulerClosed
+	}
+	if !s.deterministic && s.pool != nil && !s.pool.IsRunning() {
+		return nil, ErrPoolNotRunning
+	}
+	order, err := g.Order()
+	if err != nil {
+		return nil, err
+	}
+	run := newGraphRun(g)
+	start := time.Now()
+	if s.deterministic || s.pool == nil {
+		for _, eachJob := range order {
+			run.execute(eachJob)
+		}
+	} else {
+		run.wg.Add(len(g.jobList))
+		for _, eachJob := range g.jobList {
+			if len(eachJob.deps) <= 0 {
+				s.submit(run, eachJob)
+			}
+		}
+		run.wg.Wait()
+	}
+	run.report.Total = time.Since(start)
+	return run.report, run.firstPanic
+}
+
+// 停止自己创建的线程池,之后不能再调用Run
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.closed.Store(true)
+	if !s.ownPool {
+		return nil
+	}
+	return s.pool.Shutdown(ctx)
+}
+
+func (s *Scheduler) submit(run *graphRun, j *Job) {
+	s.pool.Post(func() {
+		defer run.wg.Done()
+		run.execute(j)
+		for _, eachDependent := range run.dependents[j.index] {
+			if run.release(eachDependent) {
+				s.submit(run, eachDependent)
+			}
+		}
+	})
+}
+
+// 一次Run的状态
+type graphRun struct {
+	wg         sync.WaitGroup
+	lock       sync.Mutex
+	dependents [][]*Job
+	pending    []int
+	// 依赖的任务发生了panic
+	failed     []bool
+	report     *Report
+	firstPanic error
+}
+
+func newGraphRun(g *Graph) *graphRun {
+	run := &graphRun{
+		dependents: g.dependents(),
+		pending:    make([]int, len(g.jobList)),
+		failed:     make([]bool, len(g.jobList)),
+		report: &Report{
+			Jobs: make([]JobTiming, len(g.jobList)),
+		},
+	}
+	for _, eachJob := range g.jobList {
+		run.pending[eachJob.index] = len(eachJob.deps)
+		run.report.Jobs[eachJob.index].Name = eachJob.name
+	}
+	return run
+}
+
+// 依赖的任务完成,返回这个任务是否可以开始执行
+func (r *graphRun) release(j *Job) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.pending[j.index]--
+	return r.pending[j.index] == 0
+}
+
+func (r *graphRun) execute(j *Job) {
+	r.lock.Lock()
+	skipped := r.failed[j.index]
+	r.lock.Unlock()
+
+	timing := &r.report.Jobs[j.index]
+	if skipped {
+		timing.Skipped = true
+		r.fail(j)
+		return
+	}
+
+	timing.Start = time.Now()
+	panicValue := r.call(j)
+	timing.Duration = time.Since(timing.Start)
+	if panicValue != nil {
+		timing.Panic = panicValue
+		r.lock.Lock()
+		if r.firstPanic == nil {
+			r.firstPanic = &PanicError{Job: j.name, Value: panicValue}
+		}
+		r.lock.Unlock()
+		r.fail(j)
+	}
+}
+
+func (r *graphRun) call(j *Job) (panicValue interface{}) {
+	defer func() {
+		panicValue = recover()
+	}()
+	if j.fn != nil {
+		j.fn()
+	}
+	return nil
+}
+
+// 将依赖j的任务标记为不执行
+func (r *graphRun) fail(j *Job) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, eachDependent := range r.dependents[j.index] {
+		r.failed[eachDependent.index] = true
+	}
+}
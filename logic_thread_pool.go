@@ -0,0 +1,453 @@
+package timelinex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/abmpio/timelinex/threading"
+)
+
+const (
+	defaultLogicThreadPoolVirtualNodes = 100
+)
+
+type LogicThreadPoolOption func(p *LogicThreadPool)
+
+// 设置一致性哈希中每个逻辑线程的虚拟节点数,默认为100
+func LogicThreadPoolOptionWithVirtualNodes(virtualNodes int) LogicThreadPoolOption {
+	return func(p *LogicThreadPool) {
+		if virtualNodes > 0 {
+			p.virtualNodes = virtualNodes
+		}
+	}
+}
+
+// 设置池中每个逻辑线程的选项,如帧率与空闲降频
+func LogicThreadPoolOptionWithThreadOptions(opts ...threading.ThreadOption) LogicThreadPoolOption {
+	return func(p *LogicThreadPool) {
+		p.threadOptions = append(p.threadOptions, opts...)
+	}
+}
+
+// 设置key迁移完成后的回调,回调运行在目标逻辑线程中
+func LogicThreadPoolOptionWithMovedCallback(callback func(key string, from *OneLogicThread, to *OneLogicThread)) LogicThreadPoolOption {
+	return func(p *LogicThreadPool) {
+		p.movedCallback = callback
+	}
+}
+
+// 多个逻辑线程组成的池,每个逻辑线程拥有独立的时间轴与场景计时器
+// 工作与订阅通过key(如房间id、玩家id)以一致性哈希路由到固定的逻辑线程中,同一个key的所有回调总是运行在同一个线程中
+type LogicThreadPool struct {
+	rwLock        sync.RWMutex
+	threadOptions []threading.ThreadOption
+	threadList    []*OneLogicThread
+	ring          []hashRingNode
+	virtualNodes  int
+	keyList       map[string]*shardKey
+
+	movedCallback func(key string, from *OneLogicThread, to *OneLogicThread)
+}
+
+type hashRingNode struct {
+	hash   uint64
+	thread *OneLogicThread
+}
+
+// 一个key在池中的路由信息与状态
+type shardKey struct {
+	key   string
+	owner *OneLogicThread
+	// 手动迁移的key不再参与重新平衡
+	pinned bool
+	// 正在迁移中,期间投递的工作会被暂存,迁移完成后在目标线程中执行
+	moving       bool
+	pendingList  []func()
+	observerList []*shardObserver
+	// key的状态,只在所属的逻辑线程中访问
+	state    interface{}
+	hasState bool
+	// 已经被Release
+	released bool
+}
+
+// 订阅到逻辑线程中的observer的包装,只在当前所属的线程中执行
+// 源线程的帧可能在目标线程接管之后仍在遍历observer快照,因此执行前还需要确认当前运行在所属的线程中
+type shardObserver struct {
+	inner  ITimelineObserver
+	active atomic.Pointer[OneLogicThread]
+}
+
+var _ ITimelineObserver = (*shardObserver)(nil)
+var _ IIdleTimelineObserver = (*shardObserver)(nil)
+
+// #region ITimelineObserver Members
+
+func (o *shardObserver) OnNext(deltaMS float64) {
+	if !o.isOwnedByCurrentThread() {
+		// 正在迁移中,或者已经迁移到其它线程
+		return
+	}
+	o.inner.OnNext(deltaMS)
+}
+
+// #endregion
+
+// #region IIdleTimelineObserver Members
+
+func (o *shardObserver) IsIdle() bool {
+	if !o.isOwnedByCurrentThread() {
+		return true
+	}
+	idleObserver, ok := o.inner.(IIdleTimelineObserver)
+	return ok && idleObserver.IsIdle()
+}
+
+// #endregion
+
+func (o *shardObserver) isOwnedByCurrentThread() bool {
+	owner := o.active.Load()
+	return owner != nil && owner.IsOnLogicThread()
+}
+
+// 创建一个包含n个逻辑线程的池,所有逻辑线程立即启动
+func NewLogicThreadPool(n int, opts ...LogicThreadPoolOption) *LogicThreadPool {
+	if n <= 0 {
+		n = 1
+	}
+	p := &LogicThreadPool{
+		threadList:   make([]*OneLogicThread, 0, n),
+		virtualNodes: defaultLogicThreadPoolVirtualNodes,
+		keyList:      make(map[string]*shardKey),
+	}
+	for _, eachOpt := range opts {
+		eachOpt(p)
+	}
+	for i := 0; i < n; i++ {
+		p.threadList = append(p.threadList, NewOneLogicThread(p.threadOptions...))
+	}
+	p.rebuildRing()
+	return p
+}
+
+// 池中逻辑线程的数量
+func (p *LogicThreadPool) Size() int {
+	p.rwLock.RLock()
+	defer p.rwLock.RUnlock()
+	return len(p.threadList)
+}
+
+// 池中所有的逻辑线程
+func (p *LogicThreadPool) Threads() []*OneLogicThread {
+	p.rwLock.RLock()
+	defer p.rwLock.RUnlock()
+	return append([]*OneLogicThread(nil), p.threadList...)
+}
+
+// 获取key当前所属的逻辑线程,不会为key创建路由信息
+func (p *LogicThreadPool) Thread(key string) *OneLogicThread {
+	p.rwLock.RLock()
+	defer p.rwLock.RUnlock()
+	if k, ok := p.keyList[key]; ok {
+		return k.owner
+	}
+	return p.locate(key)
+}
+
+// 在key所属的逻辑线程的下一帧中执行action
+// key正在迁移时,action会在迁移完成后在目标线程中执行
+// 只投递工作的key不会保留路由信息
+func (p *LogicThreadPool) Post(key string, action func()) {
+	if action == nil {
+		return
+	}
+	p.rwLock.Lock()
+	defer p.rwLock.Unlock()
+
+	k, ok := p.keyList[key]
+	if !ok {
+		p.locate(key).SubscribeAsOneTime(Observer(action), nil)
+		return
+	}
+	if k.moving {
+		k.pendingList = append(k.pendingList, action)
+		return
+	}
+	k.owner.SubscribeAsOneTime(Observer(action), nil)
+}
+
+// 将observer订阅到key所属的逻辑线程的时间轴中,key迁移时observer跟随迁移
+func (p *LogicThreadPool) Subscribe(key string, observer ITimelineObserver) ITimelineObserver {
+	if observer == nil {
+		return nil
+	}
+	p.rwLock.Lock()
+	defer p.rwLock.Unlock()
+
+	k := p.ensureKey(key)
+	wrapper := &shardObserver{inner: observer}
+	k.observerList = append(k.observerList, wrapper)
+	if !k.moving {
+		wrapper.active.Store(k.owner)
+		k.owner.Subscribe(wrapper)
+	}
+	return observer
+}
+
+// 取消key上的一个订阅,key上没有订阅与状态并且没有被手动迁移时移除key的路由信息
+func (p *LogicThreadPool) Unsubscribe(key string, observer ITimelineObserver) {
+	p.rwLock.Lock()
+	defer p.rwLock.Unlock()
+
+	k, ok := p.keyList[key]
+	if !ok {
+		return
+	}
+	for i, eachWrapper := range k.observerList {
+		if eachWrapper.inner != observer {
+			continue
+		}
+		eachWrapper.active.Store(nil)
+		k.owner.Unsubscribe(eachWrapper)
+		k.observerList = removeSliceByIndex(k.observerList, i)
+		p.removeUnusedKey(k)
+		return
+	}
+}
+
+// 移除key,取消key上的所有订阅并丢弃其状态
+func (p *LogicThreadPool) Release(key string) {
+	p.rwLock.Lock()
+	defer p.rwLock.Unlock()
+
+	k, ok := p.keyList[key]
+	if !ok {
+		return
+	}
+	for _, eachWrapper := range k.observerList {
+		eachWrapper.active.Store(nil)
+		k.owner.Unsubscribe(eachWrapper)
+	}
+	k.released = true
+	delete(p.keyList, key)
+}
+
+// 设置key的状态,必须在key所属的逻辑线程中调用(如Post或者订阅的observer中)
+// 状态会在key迁移时一起迁移到目标线程
+func (p *LogicThreadPool) SetState(key string, state interface{}) {
+	p.rwLock.Lock()
+	defer p.rwLock.Unlock()
+
+	k := p.ensureKey(key)
+	k.state = state
+	k.hasState = true
+}
+
+// 获取key的状态,必须在key所属的逻辑线程中调用
+func (p *LogicThreadPool) State(key string) (interface{}, bool) {
+	p.rwLock.RLock()
+	defer p.rwLock.RUnlock()
+
+	k, ok := p.keyList[key]
+	if !ok {
+		return nil, false
+	}
+	return k.state, k.hasState
+}
+
+// 增加一个逻辑线程,并将一致性哈希中归属发生变化的key迁移到新的线程中
+// 手动迁移过的key不参与重新平衡
+func (p *LogicThreadPool) AddThread() *OneLogicThread {
+	thread := NewOneLogicThread(p.threadOptions...)
+
+	p.rwLock.Lock()
+	defer p.rwLock.Unlock()
+	p.threadList = append(p.threadList, thread)
+	p.rebuildRing()
+	for _, eachKey := range p.keyList {
+		if eachKey.pinned || eachKey.moving {
+			continue
+		}
+		target := p.locate(eachKey.key)
+		if target != eachKey.owner {
+			p.move(eachKey, target)
+		}
+	}
+	return thread
+}
+
+// 将key迁移到指定的逻辑线程中,之后key不再参与重新平衡
+// 迁移在源线程的下一帧开始,源线程中的observer停止执行并交出状态,然后在目标线程的下一帧中接管
+func (p *LogicThreadPool) MoveKey(key string, target *OneLogicThread) error {
+	p.rwLock.Lock()
+	defer p.rwLock.Unlock()
+
+	if !p.containsThread(target) {
+		return fmt.Errorf("logic thread is not in this pool")
+	}
+	k := p.ensureKey(key)
+	if k.moving {
+		return fmt.Errorf("key %s is moving", key)
+	}
+	k.pinned = true
+	if k.owner == target {
+		return nil
+	}
+	p.move(k, target)
+	return nil
+}
+
+// 停止池中所有的逻辑线程,返回每个逻辑线程关闭时的错误
+func (p *LogicThreadPool) Shutdown(ctx context.Context, opts ...ShutdownOption) error {
+	p.rwLock.Lock()
+	threadList := append([]*OneLogicThread(nil), p.threadList...)
+	p.keyList = make(map[string]*shardKey)
+	p.rwLock.Unlock()
+
+	// 不持有锁等待,逻辑线程中的工作项可能还会访问池
+	errList := make([]error, 0)
+	for _, eachThread := range threadList {
+		if err := eachThread.Shutdown(ctx, opts...); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	return errors.Join(errList...)
+}
+
+// 调用者需要持有写锁
+func (p *LogicThreadPool) ensureKey(key string) *shardKey {
+	k, ok := p.keyList[key]
+	if ok {
+		return k
+	}
+	k = &shardKey{
+		key:          key,
+		owner:        p.locate(key),
+		pendingList:  make([]func(), 0),
+		observerList: make([]*shardObserver, 0),
+	}
+	p.keyList[key] = k
+	return k
+}
+
+// 移除不再需要路由信息的key,调用者需要持有写锁
+// 正在迁移的key在迁移完成后不再接管,暂存的工作仍然在目标线程中执行
+func (p *LogicThreadPool) removeUnusedKey(k *shardKey) {
+	if len(k.observerList) > 0 || k.hasState || k.pinned {
+		return
+	}
+	if k.moving && len(k.pendingList) > 0 {
+		return
+	}
+	k.released = true
+	delete(p.keyList, k.key)
+}
+
+// 调用者需要持有写锁
+func (p *LogicThreadPool) move(k *shardKey, target *OneLogicThread) {
+	source := k.owner
+	k.moving = true
+	// 在源线程的帧边界中停止所有observer,此后源线程不会再访问此key
+	source.SubscribeAsOneTime(Observer(func() {
+		p.rwLock.Lock()
+		for _, eachWrapper := range k.observerList {
+			eachWrapper.active.Store(nil)
+			source.Unsubscribe(eachWrapper)
+		}
+		p.rwLock.Unlock()
+
+		// 在目标线程的帧边界中接管
+		target.SubscribeAsOneTime(Observer(func() {
+			p.rwLock.Lock()
+			if k.released {
+				p.rwLock.Unlock()
+				return
+			}
+			k.owner = target
+			k.moving = false
+			for _, eachWrapper := range k.observerList {
+				eachWrapper.active.Store(target)
+				target.Subscribe(eachWrapper)
+			}
+			pendingList := k.pendingList
+			k.pendingList = make([]func(), 0)
+			p.rwLock.Unlock()
+
+			for _, eachAction := range pendingList {
+				eachAction()
+			}
+			if p.movedCallback != nil {
+				p.movedCallback(k.key, source, target)
+			}
+		}), nil)
+	}), nil)
+}
+
+func (p *LogicThreadPool) containsThread(thread *OneLogicThread) bool {
+	for _, eachThread := range p.threadList {
+		if eachThread == thread {
+			return true
+		}
+	}
+	return false
+}
+
+// 调用者需要持有写锁
+func (p *LogicThreadPool) rebuildRing() {
+	ring := make([]hashRingNode, 0, len(p.threadList)*p.virtualNodes)
+	for _, eachThread := range p.threadList {
+		for i := 0; i < p.virtualNodes; i++ {
+			ring = append(ring, hashRingNode{
+				hash:   hashKey(fmt.Sprintf("%s#%d", eachThread.logicThread.ID(), i)),
+				thread: eachThread,
+			})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+	p.ring = ring
+}
+
+// 根据一致性哈希查找key应该归属的逻辑线程
+func (p *LogicThreadPool) locate(key string) *OneLogicThread {
+	h := hashKey(key)
+	index := sort.Search(len(p.ring), func(i int) bool {
+		return p.ring[i].hash >= h
+	})
+	if index >= len(p.ring) {
+		index = 0
+	}
+	return p.ring[index].thread
+}
+
+// fnv对只有末尾几个字符不同的key分布较差,这里再做一次混淆
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	v := h.Sum64()
+	v ^= v >> 33
+	v *= 0xff51afd7ed558ccd
+	v ^= v >> 33
+	v *= 0xc4ceb9fe1a85ec53
+	v ^= v >> 33
+	return v
+}
+
+// 正在迁移中的key的数量
+func (p *LogicThreadPool) movingCount() int {
+	p.rwLock.RLock()
+	defer p.rwLock.RUnlock()
+	count := 0
+	for _, eachKey := range p.keyList {
+		if eachKey.moving {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,158 @@
+package timelinex
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLogicThreadPoolRoutesKeysConsistently(t *testing.T) {
+	pool := NewLogicThreadPool(3)
+	defer pool.Shutdown(context.Background())
+
+	usedList := make(map[*OneLogicThread]int)
+	for i := 0; i < 60; i++ {
+		key := fmt.Sprintf("room-%d", i)
+		thread := pool.Thread(key)
+		if pool.Thread(key) != thread {
+			t.Fatalf("expected key %s to be routed to the same thread", key)
+		}
+		usedList[thread]++
+	}
+	if len(usedList) != 3 {
+		t.Fatalf("expected keys to be spread over all threads, got %v", usedList)
+	}
+}
+
+func TestLogicThreadPoolAddThreadMovesKeysWithState(t *testing.T) {
+	movedCount := int64(0)
+	pool := NewLogicThreadPool(1, LogicThreadPoolOptionWithMovedCallback(func(key string, from *OneLogicThread, to *OneLogicThread) {
+		atomic.AddInt64(&movedCount, 1)
+	}))
+	defer pool.Shutdown(context.Background())
+
+	hitList := make(map[string]*int64)
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("player-%d", i)
+		hits := int64(0)
+		hitList[key] = &hits
+		pool.Subscribe(key, Observer(func() {
+			atomic.AddInt64(&hits, 1)
+		}))
+		pool.Post(key, func() {
+			pool.SetState(key, "state of "+key)
+		})
+	}
+
+	newThread := pool.AddThread()
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt64(&movedCount) == 0 || pool.movingCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected some keys to move to the new thread")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	for key, hits := range hitList {
+		if pool.Thread(key) != newThread {
+			continue
+		}
+		stateChan := make(chan interface{}, 1)
+		pool.Post(key, func() {
+			v, _ := pool.State(key)
+			stateChan <- v
+		})
+		if v := <-stateChan; v != "state of "+key {
+			t.Fatalf("expected state of %s to move with it, got %v", key, v)
+		}
+		before := atomic.LoadInt64(hits)
+		time.Sleep(50 * time.Millisecond)
+		if atomic.LoadInt64(hits) <= before {
+			t.Fatalf("expected observer of %s to keep running on the new thread", key)
+		}
+	}
+}
+
+func TestLogicThreadPoolMoveKeyPinsKey(t *testing.T) {
+	pool := NewLogicThreadPool(2)
+	defer pool.Shutdown(context.Background())
+
+	key := "room-1"
+	source := pool.Thread(key)
+	var target *OneLogicThread
+	for _, eachThread := range pool.Threads() {
+		if eachThread != source {
+			target = eachThread
+		}
+	}
+	if err := pool.MoveKey(key, target); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan *OneLogicThread, 1)
+	pool.Post(key, func() {
+		done <- pool.Thread(key)
+	})
+	if owner := <-done; owner != target {
+		t.Fatal("expected posted work to run after the key moved")
+	}
+
+	pool.AddThread()
+	time.Sleep(50 * time.Millisecond)
+	if pool.Thread(key) != target {
+		t.Fatal("expected pinned key not to be rebalanced")
+	}
+}
+
+func TestLogicThreadPoolDoesNotKeepPostOnlyKeys(t *testing.T) {
+	pool := NewLogicThreadPool(2)
+	defer pool.Shutdown(context.Background())
+
+	done := make(chan struct{}, 100)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("request-%d", i)
+		pool.Thread(key)
+		pool.Post(key, func() { done <- struct{}{} })
+	}
+	for i := 0; i < 100; i++ {
+		<-done
+	}
+
+	observer := Observer(func() {})
+	pool.Subscribe("session", observer)
+	pool.Unsubscribe("session", observer)
+
+	pool.rwLock.RLock()
+	defer pool.rwLock.RUnlock()
+	if len(pool.keyList) != 0 {
+		t.Fatalf("expected no routing entries to be kept, got %d", len(pool.keyList))
+	}
+}
+
+func TestLogicThreadPoolMovedObserverNeverRunsConcurrently(t *testing.T) {
+	pool := NewLogicThreadPool(2)
+	defer pool.Shutdown(context.Background())
+
+	key := "room-1"
+	var running, overlapped int32
+	pool.Subscribe(key, Observer(func() {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.StoreInt32(&overlapped, 1)
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	}))
+
+	threadList := pool.Threads()
+	deadline := time.Now().Add(300 * time.Millisecond)
+	for i := 0; time.Now().Before(deadline); {
+		if pool.MoveKey(key, threadList[i%2]) == nil {
+			i++
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&overlapped) != 0 {
+		t.Fatal("expected the observer of a key to run on one thread at a time")
+	}
+}
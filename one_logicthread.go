@@ -1,33 +1,179 @@
 package timelinex
 
-import "github.com/abmpio/timelinex/threading"
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/abmpio/timelinex/scheduler"
+	"github.com/abmpio/timelinex/threading"
+)
 
 type OneLogicThread struct {
 	ITimeline
 	ISceneTimer
 
 	logicThread *threading.LogicThread
+	shutdown    atomic.Bool
 }
 
-func NewOneLogicThread() *OneLogicThread {
+// opts 用于设置逻辑线程,如threading.ThreadOptionWithFPS
+func NewOneLogicThread(opts ...threading.ThreadOption) *OneLogicThread {
 	t := &OneLogicThread{
 		ITimeline:   newTimeline(),
 		ISceneTimer: newSceneTimer(),
 
-		logicThread: threading.NewLogicThread(),
+		logicThread: threading.NewLogicThread(opts...),
 	}
 
 	t.ITimeline.(*timeline).setSceneTimer(t.ISceneTimer)
+	// 新的订阅、一次性observer以及计时器的派发都会唤醒处于空闲降频状态的逻辑线程
+	t.ITimeline.(*timeline).setWaker(t.logicThread.Wake)
+	t.ITimeline.(*timeline).setLogicThread(t.logicThread)
 	t.ISceneTimer.(*sceneTimer).setTimeline(t.ITimeline)
 	t.logicThread.AttatchWorkItem(t.ITimeline.(threading.IWorkItem))
+	registerTimeline(t.logicThread.ID(), t.ITimeline)
 
 	t.logicThread.Start()
+	// 逻辑线程退出后(包括直接停止LogicThread而没有调用Shutdown)不再在调试信息中显示
+	go func() {
+		t.logicThread.Wait(context.Background())
+		unregisterTimeline(t.ITimeline)
+	}()
 	return t
 }
 
-func (t *OneLogicThread) Shutdown() {
-	if t.ISceneTimer != nil {
-		t.ISceneTimer.(*sceneTimer).Stop()
+// 承载时间轴的逻辑线程,可以往其中放置其它的工作项
+func (t *OneLogicThread) LogicThread() *threading.LogicThread {
+	return t.logicThread
+}
+
+// 暂停逻辑线程,时间轴不再执行,场景计时器的派发暂存到恢复为止
+func (t *OneLogicThread) Pause() {
+	t.logicThread.Pause()
+}
+
+// 恢复逻辑线程,暂停的时长不计入下一帧的间隔
+func (t *OneLogicThread) Resume() {
+	t.logicThread.Resume()
+}
+
+// 暂停状态下再执行n帧,单步执行的帧中不执行暂存的计时器派发
+func (t *OneLogicThread) Step(n int) {
+	t.logicThread.Step(n)
+}
+
+// 是否处于暂停状态
+func (t *OneLogicThread) Paused() bool {
+	return t.logicThread.Paused()
+}
+
+// 当前代码是否运行在这个逻辑线程中
+func (t *OneLogicThread) IsOnLogicThread() bool {
+	return t.logicThread.IsCurrent()
+}
+
+// 断言当前代码运行在这个逻辑线程中,检查方式由SetAffinityCheckMode决定
+func (t *OneLogicThread) AssertOnLogicThread() {
+	AssertOnLogicThread(t)
+}
+
+type shutdownOptions struct {
+	drain bool
+}
+
+type ShutdownOption func(o *shutdownOptions)
+
+// 关闭时先执行完一次性observer队列以及已经到期的计时器,再停止逻辑线程
+func ShutdownOptionWithDrain() ShutdownOption {
+	return func(o *shutdownOptions) {
+		o.drain = true
+	}
+}
+
+// 关闭时被放弃的内容
+type ShutdownError struct {
+	// 停止时已经到期但还没有触发的计时器,没有到期的计时器随关闭一起停止,不视为被放弃
+	AbandonedTimers []scheduler.TaskInfo
+	// 停止时仍然在队列中没有执行的一次性observer数量,包括暂停期间暂存的计时器派发
+	AbandonedOneTimeObservers int64
+	// ctx到期时为ctx的错误
+	Err error
+}
+
+func (e *ShutdownError) Error() string {
+	msg := fmt.Sprintf("shutdown abandoned %d timers and %d one-time observers",
+		len(e.AbandonedTimers),
+		e.AbandonedOneTimeObservers)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// 关闭逻辑线程
+// 1. 不再接受新的订阅与计时器
+// 2. 指定ShutdownOptionWithDrain时,等待一次性observer队列以及到期的计时器执行完成
+// 3. 停止所有计时器,并等待逻辑线程执行完当前帧后退出
+// 在逻辑线程中调用(如计时器回调)时不排空也不等待,逻辑线程在当前帧结束后退出
+// 有内容被放弃或者ctx到期时返回*ShutdownError,重复调用时直接返回nil
+func (t *OneLogicThread) Shutdown(ctx context.Context, opts ...ShutdownOption) error {
+	if !t.shutdown.CompareAndSwap(false, true) {
+		return nil
+	}
+	options := &shutdownOptions{}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+
+	tl := t.ITimeline.(*timeline)
+	timer := t.ISceneTimer.(*sceneTimer)
+	tl.close()
+	timer.close()
+
+	// 当前帧就是调用者自身,等待会导致死锁
+	onLogicThread := t.IsOnLogicThread()
+	var err error
+	if options.drain && !onLogicThread {
+		err = t.drain(ctx, tl, timer)
+	}
+	abandonedTimers := timer.dueTimers(time.Now())
+	timer.Stop()
+	if onLogicThread {
+		t.logicThread.Stop()
+	} else if waitErr := t.logicThread.Shutdown(ctx); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	unregisterTimeline(t.ITimeline)
+
+	abandonedOneTimeObservers := atomic.LoadInt64(&tl.oneTimeQueueLength) + int64(tl.heldTimerCount())
+	if err == nil && len(abandonedTimers) <= 0 && abandonedOneTimeObservers <= 0 {
+		return nil
+	}
+	return &ShutdownError{
+		AbandonedTimers:           abandonedTimers,
+		AbandonedOneTimeObservers: abandonedOneTimeObservers,
+		Err:                       err,
+	}
+}
+
+// 等待一次性observer队列为空并且没有到期的计时器
+func (t *OneLogicThread) drain(ctx context.Context, tl *timeline, timer *sceneTimer) error {
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if atomic.LoadInt64(&tl.oneTimeQueueLength) <= 0 && !timer.hasDueTimers(time.Now()) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
 	}
-	t.logicThread.Stop()
 }
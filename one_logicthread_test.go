@@ -0,0 +1,216 @@
+package timelinex
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abmpio/timelinex/threading"
+)
+
+func TestOneLogicThreadShutdownDrain(t *testing.T) {
+	thread := NewOneLogicThread()
+
+	var count int32
+	for i := 0; i < 5; i++ {
+		thread.SubscribeAsOneTime(Observer(func() {
+			atomic.AddInt32(&count, 1)
+		}), nil)
+	}
+	thread.StartNewOneTimer(30*time.Millisecond, func() {
+		atomic.AddInt32(&count, 1)
+	})
+	time.Sleep(40 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := thread.Shutdown(ctx, ShutdownOptionWithDrain()); err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
+	}
+	if v := atomic.LoadInt32(&count); v != 6 {
+		t.Fatalf("expected all 6 callbacks to run, got %d", v)
+	}
+	if thread.Subscribe(Observer(func() {})) != nil {
+		t.Fatalf("expected Subscribe to be rejected after shutdown")
+	}
+	if key := thread.StartNewOneTimer(time.Millisecond, func() {}); key != "" {
+		t.Fatalf("expected StartNewOneTimer to be rejected after shutdown, got %s", key)
+	}
+	if err := thread.Shutdown(ctx); err != nil {
+		t.Fatalf("expected second shutdown to be a no-op, got %v", err)
+	}
+}
+
+func TestOneLogicThreadShutdownReportsAbandoned(t *testing.T) {
+	// 没有到期的计时器随关闭一起停止,不视为被放弃
+	thread := NewOneLogicThread()
+	thread.StartRecurNewTimer(time.Hour, func() {}, SceneTimerOptionWithKey("hourly-save"))
+	if err := thread.Shutdown(context.Background(), ShutdownOptionWithDrain()); err != nil {
+		t.Fatalf("expected clean shutdown with a pending hourly timer, got %v", err)
+	}
+
+	// 暂停期间到期的计时器派发被暂存,关闭时被放弃
+	paused := NewOneLogicThread()
+	paused.Pause()
+	time.Sleep(20 * time.Millisecond)
+	paused.StartNewOneTimer(time.Millisecond, func() {}, SceneTimerOptionWithKey("held-save"))
+	time.Sleep(30 * time.Millisecond)
+
+	err := paused.Shutdown(context.Background())
+	var shutdownErr *ShutdownError
+	if !errors.As(err, &shutdownErr) {
+		t.Fatalf("expected *ShutdownError, got %v", err)
+	}
+	if shutdownErr.AbandonedOneTimeObservers != 1 || len(shutdownErr.AbandonedTimers) != 0 {
+		t.Fatalf("expected the held dispatch to be abandoned, got %+v", shutdownErr)
+	}
+	if shutdownErr.Err != nil {
+		t.Fatalf("expected no ctx error, got %v", shutdownErr.Err)
+	}
+}
+
+func TestOneLogicThreadShutdownFromLogicThread(t *testing.T) {
+	thread := NewOneLogicThread()
+
+	result := make(chan error, 1)
+	thread.StartNewOneTimer(time.Millisecond, func() {
+		result <- thread.Shutdown(context.Background(), ShutdownOptionWithDrain())
+	})
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Shutdown on the logic thread not to wait for its own frame")
+	}
+	if err := thread.LogicThread().Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOneLogicThreadShutdownWaitsForCurrentFrame(t *testing.T) {
+	thread := NewOneLogicThread()
+
+	started := make(chan struct{})
+	var finished int32
+	thread.SubscribeAsOneTime(Observer(func() {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+	}), nil)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := thread.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if err := thread.logicThread.Wait(context.Background()); err != nil {
+		t.Fatalf("expected worker goroutine to exit, got %v", err)
+	}
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Fatalf("expected the running frame to finish before the goroutine exits")
+	}
+}
+
+type idleTestObserver struct {
+	calls int32
+	idle  atomic.Bool
+}
+
+func (o *idleTestObserver) OnNext(deltaMS float64) {
+	atomic.AddInt32(&o.calls, 1)
+}
+
+func (o *idleTestObserver) IsIdle() bool {
+	return o.idle.Load()
+}
+
+func TestOneLogicThreadIdleFrameRate(t *testing.T) {
+	thread := NewOneLogicThread(threading.ThreadOptionWithIdleInterval(time.Hour))
+	defer thread.Shutdown(context.Background())
+
+	time.Sleep(50 * time.Millisecond)
+	if !thread.LogicThread().Idling() {
+		t.Fatalf("expected thread without observers to be idling")
+	}
+
+	// 一次性observer立即唤醒线程
+	ran := make(chan struct{})
+	thread.SubscribeAsOneTime(Observer(func() { close(ran) }), nil)
+	select {
+	case <-ran:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatalf("expected one-time observer to wake the idle thread")
+	}
+
+	// 计时器的派发同样会唤醒线程
+	fired := make(chan struct{})
+	thread.StartNewOneTimer(20*time.Millisecond, func() { close(fired) })
+	select {
+	case <-fired:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatalf("expected scene timer dispatch to wake the idle thread")
+	}
+
+	// 报告空闲的observer不会阻止降频,报告忙碌后恢复到正常的帧率
+	observer := &idleTestObserver{}
+	observer.idle.Store(true)
+	thread.Subscribe(observer)
+	time.Sleep(50 * time.Millisecond)
+	if !thread.LogicThread().Idling() {
+		t.Fatalf("expected thread with idle observers to be idling")
+	}
+	observer.idle.Store(false)
+	thread.LogicThread().Wake()
+	time.Sleep(100 * time.Millisecond)
+	if thread.LogicThread().Idling() {
+		t.Fatalf("expected busy observer to keep the thread at full rate")
+	}
+	if v := atomic.LoadInt32(&observer.calls); v < 4 {
+		t.Fatalf("expected observer to be ticked at full rate, got %d calls", v)
+	}
+}
+
+type stallingDeltaRecorder struct {
+	deltaRecorder
+	stalled atomic.Bool
+}
+
+func (r *stallingDeltaRecorder) OnNext(deltaMS float64) {
+	r.deltaRecorder.OnNext(deltaMS)
+	if len(r.snapshot()) == 5 && r.stalled.CompareAndSwap(false, true) {
+		// 卡顿3帧多,之后的帧需要追赶
+		time.Sleep(70 * time.Millisecond)
+	}
+}
+
+func TestOneLogicThreadCatchUpFramesUseFrameInterval(t *testing.T) {
+	thread := NewOneLogicThread(threading.ThreadOptionWithFPS(50),
+		threading.ThreadOptionWithFramePolicy(threading.FramePolicyCatchUp, 5))
+	defer thread.Shutdown(context.Background())
+
+	recorder := &stallingDeltaRecorder{}
+	thread.Subscribe(recorder)
+	time.Sleep(300 * time.Millisecond)
+
+	deltaList := recorder.snapshot()
+	if len(deltaList) < 12 {
+		t.Fatalf("expected frames to keep running, got %v", deltaList)
+	}
+	// 第一帧之后,追赶的帧也按照帧间隔计算,不会出现接近0的间隔
+	total := 0.0
+	for _, eachDelta := range deltaList[1:] {
+		if eachDelta < 15 {
+			t.Fatalf("expected catch-up frames to use the frame interval, got %v", deltaList)
+		}
+		total += eachDelta
+	}
+	if expected := float64(len(deltaList)-1) * 20; total < expected-5 || total > expected+5 {
+		t.Fatalf("expected deltas to add up to %vms, got %vms (%v)", expected, total, deltaList)
+	}
+}
@@ -0,0 +1,84 @@
+package timelinex
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type deltaRecorder struct {
+	lock      sync.Mutex
+	deltaList []float64
+}
+
+func (r *deltaRecorder) OnNext(deltaMS float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.deltaList = append(r.deltaList, deltaMS)
+}
+
+func (r *deltaRecorder) snapshot() []float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]float64(nil), r.deltaList...)
+}
+
+func TestOneLogicThreadPauseResumeStep(t *testing.T) {
+	thread := NewOneLogicThread()
+	defer thread.Shutdown(context.Background())
+
+	recorder := &deltaRecorder{}
+	thread.Subscribe(recorder)
+	time.Sleep(50 * time.Millisecond)
+
+	thread.Pause()
+	time.Sleep(40 * time.Millisecond)
+	if !thread.Paused() {
+		t.Fatalf("expected thread to be paused")
+	}
+	frames := len(recorder.snapshot())
+
+	var oneShot, recurring int32
+	thread.StartNewOneTimer(10*time.Millisecond, func() { atomic.AddInt32(&oneShot, 1) })
+	thread.StartRecurNewTimer(10*time.Millisecond, func() { atomic.AddInt32(&recurring, 1) })
+	time.Sleep(150 * time.Millisecond)
+	if got := len(recorder.snapshot()); got != frames {
+		t.Fatalf("expected no frames while paused, got %d new frames", got-frames)
+	}
+
+	// 单步执行3帧,暂停的时长不计入帧间隔,计时器的派发仍然暂存
+	thread.Step(3)
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.snapshot()) < frames+3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	deltaList := recorder.snapshot()
+	if len(deltaList) != frames+3 {
+		t.Fatalf("expected exactly 3 stepped frames, got %d", len(deltaList)-frames)
+	}
+	if deltaList[frames] > 100 {
+		t.Fatalf("expected pause to be excluded from delta, got %vms", deltaList[frames])
+	}
+	if atomic.LoadInt32(&oneShot) != 0 || atomic.LoadInt32(&recurring) != 0 {
+		t.Fatalf("expected timer dispatches to be held while paused")
+	}
+
+	thread.Resume()
+	time.Sleep(10 * time.Millisecond)
+	if atomic.LoadInt32(&oneShot) != 1 {
+		t.Fatalf("expected held one-shot timer to run once after resume")
+	}
+	if v := atomic.LoadInt32(&recurring); v < 1 || v > 3 {
+		t.Fatalf("expected held recurring timer to be coalesced, got %d runs", v)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if v := atomic.LoadInt32(&recurring); v < 4 {
+		t.Fatalf("expected recurring timer to keep running after resume, got %d runs", v)
+	}
+	if len(recorder.snapshot()) <= frames+3 {
+		t.Fatalf("expected frames to continue after resume")
+	}
+}
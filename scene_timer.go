@@ -1,9 +1,11 @@
 package timelinex
 
 import (
+	"sync"
 	"time"
 
 	"github.com/abmpio/libx/lang/tuple"
+	"github.com/abmpio/threadingx/lang"
 	"github.com/abmpio/timelinex/scheduler"
 )
 
@@ -28,8 +30,29 @@ type ISceneTimer interface {
 	// action:回调
 	StartRecurNewTimer(timerInterval time.Duration, action func(), opts ...SceneTimerOption) string
 
-	//移除一个定时器
+	// 启动一个按照调度规则触发的计时器,直到schedule没有下一次触发时间
+	// schedule 如scheduler.ParseCron、scheduler.ParseOnCalendar、scheduler.ParseRRule的结果
+	// action:回调
+	StartScheduledTimer(schedule scheduler.Schedule, action func(), opts ...SceneTimerOption) string
+
+	//移除一个定时器,同时移除以timerId为key的防抖与节流
 	RemoveTimer(timerId string)
+
+	// 修改计时器的间隔并从当前时间重新计时,timerId与回调保持不变
+	// 一次性的计时器已经触发或者timerId不存在时返回false
+	RescheduleTimer(timerId string, interval time.Duration) bool
+
+	// 不修改计时器的间隔,从当前时间重新计时
+	ResetTimer(timerId string) bool
+
+	// 防抖: 同一个key在d时间内被重复触发时,只在最后一次触发后静默d时间才执行一次action
+	// action总是运行在时间轴线程中
+	Debounce(key string, d time.Duration, action func())
+
+	// 节流: 同一个key在每个d时间窗口内最多执行一次action
+	// mode 控制在窗口的开始(ThrottleLeading)和/或结束(ThrottleTrailing)时执行,为0时等同于ThrottleLeading
+	// action总是运行在时间轴线程中
+	Throttle(key string, d time.Duration, action func(), mode ThrottleMode)
 }
 
 type SceneTimerOption = func(i *scheduler.TaskItem)
@@ -53,11 +76,18 @@ type sceneTimer struct {
 	taskScheduler scheduler.ITaskScheduler
 
 	timeline ITimeline
+	// 关闭后不再接受新的计时器
+	closed lang.SafeBool
+
+	// 保护Debounce与Throttle基于key的计时器状态
+	keyedTimerLock sync.Mutex
+	throttleList   map[string]*throttleState
 }
 
 func newSceneTimer() *sceneTimer {
 	t := &sceneTimer{
 		taskScheduler: scheduler.NewTaskScheduler(),
+		throttleList:  make(map[string]*throttleState),
 	}
 	return t
 }
@@ -71,6 +101,50 @@ func (t *sceneTimer) Stop() {
 	t.taskScheduler.Stop()
 }
 
+// 不再接受新的计时器,已经存在的计时器继续运行
+func (s *sceneTimer) close() {
+	s.closed.Set(true)
+}
+
+// 是否存在已经到期但还没有执行完成的计时器
+func (s *sceneTimer) hasDueTimers(now time.Time) bool {
+	return len(s.dueTimers(now)) > 0
+}
+
+// 在now时已经到期但还没有触发的计时器
+func (s *sceneTimer) dueTimers(now time.Time) []scheduler.TaskInfo {
+	dueList := make([]scheduler.TaskInfo, 0)
+	for _, eachTask := range s.taskScheduler.Tasks() {
+		if !eachTask.NextFireTime.IsZero() && !eachTask.NextFireTime.After(now) {
+			dueList = append(dueList, eachTask)
+		}
+	}
+	return dueList
+}
+
+// 将计时器的回调派发到时间轴线程中执行
+// recurring 为true时,逻辑线程暂停期间同一个计时器只保留一次派发
+func (s *sceneTimer) dispatch(key string, recurring bool, observer ITimelineObserver) {
+	observer = &timerDispatchObserver{timeline: s.timeline, inner: observer}
+	if tl, ok := s.timeline.(*timeline); ok {
+		// 绕过时间轴的关闭检查,保证关闭过程中到期的计时器仍然可以被排空
+		tl.dispatchTimer(key, recurring, observer)
+		return
+	}
+	s.timeline.SubscribeAsOneTime(observer, nil)
+}
+
+// 派发到时间轴中的计时器回调,执行前检查线程亲和性
+type timerDispatchObserver struct {
+	timeline ITimeline
+	inner    ITimelineObserver
+}
+
+func (o *timerDispatchObserver) OnNext(deltaMS float64) {
+	AssertOnLogicThread(o.timeline)
+	o.inner.OnNext(deltaMS)
+}
+
 // #region ISceneTimerService Members
 
 // 启动一个新的计时器(一次性触发的)
@@ -80,6 +154,9 @@ func (t *sceneTimer) Stop() {
 func (s *sceneTimer) StartNewOneTimer(delayInterval time.Duration,
 	action func(),
 	opts ...SceneTimerOption) string {
+	if s.closed.Get() {
+		return ""
+	}
 
 	taskItem := scheduler.NewTaskItem()
 	taskItem.Value = action
@@ -96,7 +173,7 @@ func (s *sceneTimer) StartNewOneTimer(delayInterval time.Duration,
 			v()
 		} else {
 			// 将这个回调执行在时间轴中
-			s.timeline.SubscribeAsOneTime(Observer(v), nil)
+			s.dispatch(ti.GetKey(), false, Observer(v))
 		}
 		return nil
 	})
@@ -111,6 +188,9 @@ func (s *sceneTimer) StartNewOneTimerWithData(delayInterval time.Duration,
 	action func(interface{}),
 	data interface{},
 	opts ...SceneTimerOption) string {
+	if s.closed.Get() {
+		return ""
+	}
 
 	taskItem := scheduler.NewTaskItem()
 	taskItemValue := tuple.New2(action, data)
@@ -128,7 +208,7 @@ func (s *sceneTimer) StartNewOneTimerWithData(delayInterval time.Duration,
 			tValue.V1(tValue.V2)
 		} else {
 			// 将这个回调执行在时间轴中
-			s.timeline.SubscribeAsOneTime(ObserverFromActionWithT[any](tValue.V1, tValue.V2), nil)
+			s.dispatch(ti.GetKey(), false, ObserverFromActionWithT[any](tValue.V1, tValue.V2))
 		}
 		return nil
 	})
@@ -137,6 +217,9 @@ func (s *sceneTimer) StartNewOneTimerWithData(delayInterval time.Duration,
 
 // 启动一个新的计时器(一直在运行的)
 func (s *sceneTimer) StartRecurNewTimer(timerInterval time.Duration, action func(), opts ...SceneTimerOption) string {
+	if s.closed.Get() {
+		return ""
+	}
 
 	taskItem := scheduler.NewTaskItem()
 	taskItem.Value = action
@@ -155,15 +238,71 @@ func (s *sceneTimer) StartRecurNewTimer(timerInterval time.Duration, action func
 			aValue()
 		} else {
 			// 将这个回调执行在时间轴中
-			s.timeline.SubscribeAsOneTime(Observer(aValue), nil)
+			s.dispatch(ti.GetKey(), true, Observer(aValue))
 		}
 		return nil
 	})
 	return observer.GetKey()
 }
 
+// 启动一个按照调度规则触发的计时器,schedule没有下一次触发时间时返回空字符串
+func (s *sceneTimer) StartScheduledTimer(schedule scheduler.Schedule, action func(), opts ...SceneTimerOption) string {
+	if s.closed.Get() {
+		return ""
+	}
+
+	taskItem := scheduler.NewTaskItem()
+	taskItem.Value = action
+	for _, eachOpt := range opts {
+		eachOpt(taskItem)
+	}
+
+	observer := s.taskScheduler.ScheduleFunc(schedule, taskItem, func(ti *scheduler.TaskItem) error {
+		aValue := ti.Value.(func())
+
+		pValue := ti.GetProperty(taskItem_PropertiesKey_DontRunInTimelineThread)
+		dontRunInTimelineThread, ok := pValue.(bool)
+		if ok && dontRunInTimelineThread {
+			// 不运行在时间轴
+			aValue()
+		} else {
+			// 将这个回调执行在时间轴中
+			s.dispatch(ti.GetKey(), true, Observer(aValue))
+		}
+		return nil
+	})
+	if observer == nil {
+		return ""
+	}
+	return observer.GetKey()
+}
+
+func (s *sceneTimer) RescheduleTimer(timerId string, interval time.Duration) bool {
+	return s.taskScheduler.Reschedule(timerId, interval)
+}
+
+func (s *sceneTimer) ResetTimer(timerId string) bool {
+	return s.taskScheduler.ResetTimer(timerId)
+}
+
 func (s *sceneTimer) RemoveTimer(timerId string) {
-	s.taskScheduler.StopScheduler(timerId)
+	s.keyedTimerLock.Lock()
+	defer s.keyedTimerLock.Unlock()
+
+	delete(s.throttleList, timerId)
+	// 同时移除这个key上的防抖与节流计时器
+	for _, eachKey := range []string{timerId, debounceTimerKey(timerId), throttleTimerKey(timerId)} {
+		s.taskScheduler.StopScheduler(eachKey)
+		// 暂停期间已经到期但还没有执行的派发同样移除
+		s.dropHeldTimer(eachKey)
+	}
+}
+
+// 移除暂停期间暂存的计时器派发
+func (s *sceneTimer) dropHeldTimer(key string) {
+	if tl, ok := s.timeline.(*timeline); ok {
+		tl.dropHeldTimer(key)
+	}
 }
 
 // #endregion
@@ -0,0 +1,116 @@
+package timelinex
+
+import "time"
+
+// 节流模式,可以组合使用,如 ThrottleLeading | ThrottleTrailing
+type ThrottleMode int
+
+const (
+	// 在时间窗口开始时立即执行
+	ThrottleLeading ThrottleMode = 1 << iota
+	// 在时间窗口结束时执行窗口内最后一次的触发
+	ThrottleTrailing
+)
+
+// 一个key对应的节流窗口状态
+type throttleState struct {
+	mode     ThrottleMode
+	interval time.Duration
+	// 窗口内最后一次触发的回调,窗口结束时执行
+	pending func()
+}
+
+// Debounce与Throttle使用各自的计时器key,同一个key上的防抖与节流互不影响
+func debounceTimerKey(key string) string {
+	return "debounce:" + key
+}
+
+func throttleTimerKey(key string) string {
+	return "throttle:" + key
+}
+
+// #region ISceneTimer Members
+
+// 防抖: 同一个key在d时间内被重复触发时,只在最后一次触发后静默d时间才执行一次action
+// 每次调用都会移除这个key上原有的防抖计时器并重新开始计时,暂停期间已经到期但还没有执行的派发同样被移除
+func (s *sceneTimer) Debounce(key string, d time.Duration, action func()) {
+	if len(key) <= 0 || action == nil || s.closed.Get() {
+		return
+	}
+	s.keyedTimerLock.Lock()
+	defer s.keyedTimerLock.Unlock()
+
+	timerKey := debounceTimerKey(key)
+	s.taskScheduler.StopScheduler(timerKey)
+	s.dropHeldTimer(timerKey)
+	s.StartNewOneTimer(d, action, SceneTimerOptionWithKey(timerKey))
+}
+
+// 节流: 同一个key在每个d时间窗口内最多执行一次action
+func (s *sceneTimer) Throttle(key string, d time.Duration, action func(), mode ThrottleMode) {
+	if len(key) <= 0 || action == nil || s.closed.Get() {
+		return
+	}
+	if mode&(ThrottleLeading|ThrottleTrailing) == 0 {
+		mode = ThrottleLeading
+	}
+	s.keyedTimerLock.Lock()
+	defer s.keyedTimerLock.Unlock()
+
+	if state, ok := s.throttleList[key]; ok {
+		// 仍在窗口内,只记录最后一次触发
+		if state.mode&ThrottleTrailing != 0 {
+			state.pending = action
+		}
+		return
+	}
+
+	state := &throttleState{
+		mode:     mode,
+		interval: d,
+	}
+	s.throttleList[key] = state
+	if mode&ThrottleLeading != 0 {
+		// 与计时器的派发一样执行在时间轴中,暂停时暂存,关闭过程中仍然可以被排空
+		s.dispatch(throttleTimerKey(key), false, Observer(action))
+	} else {
+		state.pending = action
+	}
+	s.startThrottleWindow(key, state)
+}
+
+// #endregion
+
+func (s *sceneTimer) startThrottleWindow(key string, state *throttleState) {
+	s.StartNewOneTimer(state.interval, func() {
+		s.onThrottleWindowEnd(key, state)
+	}, SceneTimerOptionWithKey(throttleTimerKey(key)))
+}
+
+// 窗口结束,运行在时间轴线程中
+func (s *sceneTimer) onThrottleWindowEnd(key string, state *throttleState) {
+	s.keyedTimerLock.Lock()
+	current, ok := s.throttleList[key]
+	if !ok || current != state {
+		// 已经被RemoveTimer移除
+		s.keyedTimerLock.Unlock()
+		return
+	}
+	pending := state.pending
+	state.pending = nil
+	if pending == nil {
+		delete(s.throttleList, key)
+		s.keyedTimerLock.Unlock()
+		return
+	}
+	if s.closed.Get() {
+		// 已经关闭,不再开启新的窗口
+		delete(s.throttleList, key)
+	} else {
+		// 执行了尾部触发后需要开启一个新的窗口,保证两次执行之间至少间隔d时间
+		s.startThrottleWindow(key, state)
+	}
+	s.keyedTimerLock.Unlock()
+
+	pending()
+}
@@ -0,0 +1,127 @@
+package timelinex
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestTimelineWithTimer() (*timeline, *sceneTimer) {
+	tl := newTimeline()
+	timer := newSceneTimer()
+	tl.setSceneTimer(timer)
+	timer.setTimeline(tl)
+	return tl, timer
+}
+
+func TestSceneTimerDebounceCollapsesRepeatedTriggers(t *testing.T) {
+	tl, timer := newTestTimelineWithTimer()
+	defer timer.Stop()
+
+	hits := 0
+	for i := 0; i < 5; i++ {
+		timer.Debounce("save", 30*time.Millisecond, func() {
+			hits++
+		})
+		time.Sleep(5 * time.Millisecond)
+	}
+	tl._notifyRegistedObserver(16)
+	if hits != 0 {
+		t.Fatalf("expected debounce to wait for quiet period, got %d hits", hits)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	tl._notifyRegistedObserver(16)
+	if hits != 1 {
+		t.Fatalf("expected exactly one call after quiet period, got %d", hits)
+	}
+}
+
+func TestSceneTimerThrottleLeadingAndTrailing(t *testing.T) {
+	tl, timer := newTestTimelineWithTimer()
+	defer timer.Stop()
+
+	calls := make([]int, 0)
+	for i := 0; i < 3; i++ {
+		v := i
+		timer.Throttle("save", 40*time.Millisecond, func() {
+			calls = append(calls, v)
+		}, ThrottleLeading|ThrottleTrailing)
+	}
+	tl._notifyRegistedObserver(16)
+	if len(calls) != 1 || calls[0] != 0 {
+		t.Fatalf("expected leading call with first value, got %v", calls)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	tl._notifyRegistedObserver(16)
+	if len(calls) != 2 || calls[1] != 2 {
+		t.Fatalf("expected trailing call with last value, got %v", calls)
+	}
+}
+
+func TestSceneTimerRemoveTimerCancelsThrottleWindow(t *testing.T) {
+	tl, timer := newTestTimelineWithTimer()
+	defer timer.Stop()
+
+	hits := 0
+	timer.Throttle("save", 30*time.Millisecond, func() {
+		hits++
+	}, ThrottleTrailing)
+	timer.RemoveTimer("save")
+
+	time.Sleep(60 * time.Millisecond)
+	tl._notifyRegistedObserver(16)
+	if hits != 0 {
+		t.Fatalf("expected removed throttle not to fire, got %d hits", hits)
+	}
+	if len(timer.throttleList) != 0 {
+		t.Fatalf("expected throttle state to be cleared")
+	}
+}
+
+func TestSceneTimerDebounceAndThrottleUseSeparateKeys(t *testing.T) {
+	tl, timer := newTestTimelineWithTimer()
+	defer timer.Stop()
+
+	calls := make([]string, 0)
+	timer.Throttle("save", 30*time.Millisecond, func() {
+		calls = append(calls, "throttle")
+	}, ThrottleTrailing)
+	timer.Debounce("save", 10*time.Millisecond, func() {
+		calls = append(calls, "debounce")
+	})
+	if len(timer.throttleList) != 1 {
+		t.Fatal("expected debounce not to touch the throttle window")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	tl._notifyRegistedObserver(16)
+	time.Sleep(60 * time.Millisecond)
+	tl._notifyRegistedObserver(16)
+	if len(calls) != 2 {
+		t.Fatalf("expected both debounce and throttle to fire once, got %v", calls)
+	}
+}
+
+func TestSceneTimerDebounceDropsHeldDispatch(t *testing.T) {
+	tl, timer := newTestTimelineWithTimer()
+	defer timer.Stop()
+
+	calls := make([]string, 0)
+	tl.OnPaused(time.Now())
+	timer.Debounce("save", time.Millisecond, func() {
+		calls = append(calls, "first")
+	})
+	time.Sleep(30 * time.Millisecond)
+	if tl.heldTimerCount() != 1 {
+		t.Fatalf("expected the first debounce to be held while paused, got %d", tl.heldTimerCount())
+	}
+	timer.Debounce("save", time.Hour, func() {
+		calls = append(calls, "second")
+	})
+	tl.OnResumed(time.Now(), false)
+	tl._notifyRegistedObserver(16)
+	if len(calls) != 0 {
+		t.Fatalf("expected the superseded debounce not to run, got %v", calls)
+	}
+}
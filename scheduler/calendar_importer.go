@@ -0,0 +1,194 @@
+package scheduler
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCalendarKeyPrefix = "ics:"
+)
+
+type calendarImporterOptions struct {
+	keyPrefix string
+	location  *time.Location
+}
+
+type CalendarImporterOption func(o *calendarImporterOptions)
+
+// 调度项key的前缀,key为前缀加上事件的UID,默认为"ics:"
+func CalendarImporterOptionWithKeyPrefix(prefix string) CalendarImporterOption {
+	return func(o *calendarImporterOptions) {
+		o.keyPrefix = prefix
+	}
+}
+
+// 没有时区的时间所使用的时区,默认为time.Local
+func CalendarImporterOptionWithLocation(loc *time.Location) CalendarImporterOption {
+	return func(o *calendarImporterOptions) {
+		if loc != nil {
+			o.location = loc
+		}
+	}
+}
+
+// 一次导入的结果,均为事件的UID
+type CalendarImportResult struct {
+	// 新增调度的事件
+	Added []string
+	// 被修改并重新调度的事件
+	Updated []string
+	// 不再存在或者被取消的事件
+	Removed []string
+	// 没有变化的事件
+	Unchanged []string
+	// 已经没有下一次触发时间的事件
+	Expired []string
+}
+
+type importedCalendarEvent struct {
+	event       CalendarEvent
+	fingerprint string
+	observer    ITaskSchedulerObserver
+}
+
+// 将.ics文件中的VEVENT导入为调度项,以UID作为调度项的key
+// 再次导入时,修改过的事件会被重新调度,不再存在或者被取消的事件会被停止
+// 回调中TaskItem.Value为对应的CalendarEvent
+type CalendarImporter struct {
+	scheduler ITaskScheduler
+	callback  func(*TaskItem) error
+	options   calendarImporterOptions
+
+	lock      sync.Mutex
+	eventList map[string]*importedCalendarEvent
+}
+
+func NewCalendarImporter(scheduler ITaskScheduler,
+	callback func(*TaskItem) error,
+	opts ...CalendarImporterOption) *CalendarImporter {
+
+	options := calendarImporterOptions{
+		keyPrefix: defaultCalendarKeyPrefix,
+		location:  time.Local,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(&options)
+	}
+	return &CalendarImporter{
+		scheduler: scheduler,
+		callback:  callback,
+		options:   options,
+		eventList: make(map[string]*importedCalendarEvent),
+	}
+}
+
+// 从.ics文件导入事件,解析失败或者有无效的RRULE时不会修改已有的调度项
+func (i *CalendarImporter) Import(r io.Reader) (*CalendarImportResult, error) {
+	events, err := ParseICS(r, i.options.location)
+	if err != nil {
+		return nil, err
+	}
+	return i.ImportEvents(events)
+}
+
+// 导入事件,events为日历中的全部事件,之前导入过但不在events中的事件会被停止
+func (i *CalendarImporter) ImportEvents(events []CalendarEvent) (*CalendarImportResult, error) {
+	// 先检查所有的调度规则,避免只导入了一部分
+	latest := make(map[string]CalendarEvent, len(events))
+	scheduleList := make(map[string]Schedule, len(events))
+	for _, eachEvent := range events {
+		if eachEvent.Cancelled {
+			delete(latest, eachEvent.UID)
+			continue
+		}
+		schedule, err := eachEvent.Schedule()
+		if err != nil {
+			return nil, err
+		}
+		latest[eachEvent.UID] = eachEvent
+		scheduleList[eachEvent.UID] = schedule
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	result := &CalendarImportResult{}
+	for uid, eachImported := range i.eventList {
+		if _, ok := latest[uid]; ok {
+			continue
+		}
+		eachImported.observer.Stop()
+		delete(i.eventList, uid)
+		result.Removed = append(result.Removed, uid)
+	}
+
+	now := time.Now()
+	for uid, eachEvent := range latest {
+		fingerprint := eachEvent.fingerprint()
+		imported, exists := i.eventList[uid]
+		if exists && imported.fingerprint == fingerprint && !imported.observer.IsStopped() {
+			result.Unchanged = append(result.Unchanged, uid)
+			continue
+		}
+		if exists {
+			imported.observer.Stop()
+			delete(i.eventList, uid)
+		}
+
+		schedule := scheduleList[uid]
+		if schedule.Next(now).IsZero() {
+			result.Expired = append(result.Expired, uid)
+			continue
+		}
+		taskItem := NewTaskItem()
+		taskItem.SetKey(i.options.keyPrefix + uid)
+		taskItem.Value = eachEvent
+		observer := i.scheduler.ScheduleFunc(schedule, taskItem, i.callback)
+		if observer == nil {
+			result.Expired = append(result.Expired, uid)
+			continue
+		}
+		i.eventList[uid] = &importedCalendarEvent{
+			event:       eachEvent,
+			fingerprint: fingerprint,
+			observer:    observer,
+		}
+		if exists {
+			result.Updated = append(result.Updated, uid)
+		} else {
+			result.Added = append(result.Added, uid)
+		}
+	}
+
+	for _, eachList := range [][]string{result.Added, result.Updated, result.Removed, result.Unchanged, result.Expired} {
+		sort.Strings(eachList)
+	}
+	return result, nil
+}
+
+// 当前已经调度的事件,按照UID排序
+func (i *CalendarImporter) Events() []CalendarEvent {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	result := make([]CalendarEvent, 0, len(i.eventList))
+	for _, eachImported := range i.eventList {
+		result = append(result, eachImported.event)
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].UID < result[b].UID })
+	return result
+}
+
+// 停止所有导入的调度项
+func (i *CalendarImporter) Stop() {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for uid, eachImported := range i.eventList {
+		eachImported.observer.Stop()
+		delete(i.eventList, uid)
+	}
+}
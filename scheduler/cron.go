@@ -0,0 +1,372 @@
+//the Next search (CronSchedule.Next, dayMatches) and the field range parsing in this file are adapted from
+//https://github.com/robfig/cron/blob/master/spec.go and https://github.com/robfig/cron/blob/master/parser.go
+//
+//Copyright (C) 2012 Rob Figueroa
+//All rights reserved.
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cron表达式中每个字段的取值范围
+type cronBounds struct {
+	name     string
+	min, max uint
+	// 字段支持的名称,如月份JAN与星期SUN
+	names map[string]uint
+}
+
+var (
+	cronSeconds = cronBounds{name: "second", min: 0, max: 59}
+	cronMinutes = cronBounds{name: "minute", min: 0, max: 59}
+	cronHours   = cronBounds{name: "hour", min: 0, max: 23}
+	cronDom     = cronBounds{name: "day of month", min: 1, max: 31}
+	cronMonths  = cronBounds{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 星期中的7同样表示星期日
+	cronDow = cronBounds{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+const (
+	// 超过这个年数仍然找不到匹配的时间时认为没有下一次触发
+	cronSearchYears = 5
+)
+
+var _ Schedule = (*CronSchedule)(nil)
+
+// cron表达式描述的调度规则
+// 支持5个字段(分 时 日 月 周)与6个字段(秒 分 时 日 月 周)的标准写法,
+// @yearly、@monthly、@weekly、@daily、@hourly与@every <duration>等描述符,
+// 以及CRON_TZ=<时区>或TZ=<时区>前缀指定的时区
+type CronSchedule struct {
+	spec string
+
+	second, minute, hour, dom, month, dow uint64
+	// 日与星期是否为*,两者都有限制时满足其一即可
+	domStar, dowStar bool
+	// @every描述符的间隔
+	every    time.Duration
+	location *time.Location
+}
+
+// 解析cron表达式,没有指定时区时使用time.Local
+func ParseCron(spec string) (*CronSchedule, error) {
+	return ParseCronInLocation(spec, time.Local)
+}
+
+// 解析cron表达式,没有通过CRON_TZ前缀指定时区时使用loc
+func ParseCronInLocation(spec string, loc *time.Location) (*CronSchedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	s := &CronSchedule{
+		spec:     spec,
+		location: loc,
+	}
+	expr := strings.TrimSpace(spec)
+	if strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=") {
+		i := strings.IndexAny(expr, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("cron: missing fields after time zone in %q", spec)
+		}
+		name := expr[strings.Index(expr, "=")+1 : i]
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("cron: invalid time zone %q: %w", name, err)
+		}
+		s.location = location
+		expr = strings.TrimSpace(expr[i:])
+	}
+
+	if strings.HasPrefix(expr, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(expr[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("cron: invalid duration in %q: %w", spec, err)
+		}
+		if every <= 0 {
+			return nil, fmt.Errorf("cron: @every duration must be positive in %q", spec)
+		}
+		s.every = every
+		return s, nil
+	}
+	if strings.HasPrefix(expr, "@") {
+		descriptor, ok := cronDescriptors[strings.ToLower(expr)]
+		if !ok {
+			return nil, fmt.Errorf("cron: unknown descriptor %q", expr)
+		}
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron: expected 5 or 6 fields, got %d in %q", len(fields), spec)
+	}
+
+	var err error
+	if s.second, err = parseCronField(fields[0], cronSeconds); err != nil {
+		return nil, err
+	}
+	if s.minute, err = parseCronField(fields[1], cronMinutes); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseCronField(fields[2], cronHours); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseCronField(fields[3], cronDom); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseCronField(fields[4], cronMonths); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseCronField(fields[5], cronDow); err != nil {
+		return nil, err
+	}
+	// 7与0都表示星期日
+	if s.dow&(1<<7) != 0 {
+		s.dow = (s.dow | 1) &^ (1 << 7)
+	}
+	s.domStar = fields[3] == "*" || fields[3] == "?"
+	s.dowStar = fields[5] == "*" || fields[5] == "?"
+	return s, nil
+}
+
+// 预览cron表达式从from开始接下来的n次触发时间
+func NextN(spec string, from time.Time, n int) ([]time.Time, error) {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+	return NextTimes(schedule, from, n), nil
+}
+
+func (s *CronSchedule) String() string {
+	return "cron " + s.spec
+}
+
+// 调度规则使用的时区
+func (s *CronSchedule) Location() *time.Location {
+	return s.location
+}
+
+// #region Schedule Members
+
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	origLocation := t.Location()
+	t = t.In(s.location)
+	// 从下一秒开始查找
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	// 是否已经把低位的字段归零
+	added := false
+	yearLimit := t.Year() + cronSearchYears
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for 1<<uint(t.Month())&s.month == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.location)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+		}
+		t = t.AddDate(0, 0, 1)
+		// 夏令时切换时午夜可能不存在,修正到当天的0点
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Hour())&s.hour == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.location)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Minute())&s.minute == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Second())&s.second == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+
+	return t.In(origLocation)
+}
+
+// #endregion
+
+// 日与星期都有限制时满足其一即可,否则两者都需要满足
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.dom != 0
+	dowMatch := 1<<uint(t.Weekday())&s.dow != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// 解析一个字段,如"*/5"、"1-10/2"、"mon-fri"、"1,15,30",返回每个取值对应的位
+func parseCronField(field string, bounds cronBounds) (uint64, error) {
+	var bits uint64
+	for _, eachExpr := range strings.Split(field, ",") {
+		b, err := parseCronRange(eachExpr, bounds)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+func parseCronRange(expr string, bounds cronBounds) (uint64, error) {
+	rangeAndStep := strings.Split(expr, "/")
+	if len(rangeAndStep) > 2 {
+		return 0, fmt.Errorf("cron: invalid %s %q", bounds.name, expr)
+	}
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+	if len(lowAndHigh) > 2 {
+		return 0, fmt.Errorf("cron: invalid %s %q", bounds.name, expr)
+	}
+
+	var start, end uint
+	var err error
+	if lowAndHigh[0] == "*" || lowAndHigh[0] == "?" {
+		if len(lowAndHigh) > 1 {
+			return 0, fmt.Errorf("cron: invalid %s %q", bounds.name, expr)
+		}
+		start, end = bounds.min, bounds.max
+		if bounds.max == cronDow.max && bounds.name == cronDow.name {
+			// *表示星期日到星期六,不需要包含7
+			end = 6
+		}
+	} else {
+		if start, err = parseCronValue(lowAndHigh[0], bounds); err != nil {
+			return 0, err
+		}
+		end = start
+		if len(lowAndHigh) == 2 {
+			if end, err = parseCronValue(lowAndHigh[1], bounds); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	step := uint(1)
+	if len(rangeAndStep) == 2 {
+		v, err := strconv.ParseUint(rangeAndStep[1], 10, 32)
+		if err != nil || v == 0 {
+			return 0, fmt.Errorf("cron: invalid step in %s %q", bounds.name, expr)
+		}
+		step = uint(v)
+		if len(lowAndHigh) == 1 && lowAndHigh[0] != "*" && lowAndHigh[0] != "?" {
+			// "a/n"表示从a开始到最大值
+			end = bounds.max
+		}
+	}
+	if start > end {
+		return 0, fmt.Errorf("cron: range start %d is after end %d in %s %q", start, end, bounds.name, expr)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << i
+	}
+	return bits, nil
+}
+
+func parseCronValue(expr string, bounds cronBounds) (uint, error) {
+	if v, ok := bounds.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+	v, err := strconv.ParseUint(expr, 10, 32)
+	if err != nil || v > math.MaxUint8 {
+		return 0, fmt.Errorf("cron: invalid %s %q", bounds.name, expr)
+	}
+	if uint(v) < bounds.min || uint(v) > bounds.max {
+		return 0, fmt.Errorf("cron: %s %d out of range [%d, %d]", bounds.name, v, bounds.min, bounds.max)
+	}
+	return uint(v), nil
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	from := time.Date(2024, time.March, 15, 10, 7, 30, 0, time.UTC)
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, time.March, 15, 10, 15, 0, 0, time.UTC)},
+		{"30 * * * * *", time.Date(2024, time.March, 15, 10, 8, 30, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2024, time.March, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2024, time.March, 22, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+	}
+	for _, c := range cases {
+		schedule, err := ParseCronInLocation(c.spec, time.UTC)
+		if err != nil {
+			t.Fatalf("parse %q: %v", c.spec, err)
+		}
+		if got := schedule.Next(from); !got.Equal(c.want) {
+			t.Fatalf("%q: expected %v, got %v", c.spec, c.want, got)
+		}
+	}
+}
+
+func TestCronScheduleTimeZone(t *testing.T) {
+	schedule, err := ParseCronInLocation("CRON_TZ=Asia/Shanghai 0 9 * * *", time.UTC)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	from := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)
+	want := time.Date(2024, time.March, 15, 1, 0, 0, 0, time.UTC)
+	if got := schedule.Next(from); !got.Equal(want) || got.Location() != time.UTC {
+		t.Fatalf("expected %v in UTC, got %v", want, got)
+	}
+}
+
+func TestCronScheduleInvalidSpec(t *testing.T) {
+	for _, spec := range []string{"", "* * *", "60 * * * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "@often", "CRON_TZ=Nowhere/City * * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestNextN(t *testing.T) {
+	from := time.Date(2024, time.March, 15, 10, 7, 30, 0, time.Local)
+	times, err := NextN("0 */6 * * *", from, 3)
+	if err != nil {
+		t.Fatalf("NextN: %v", err)
+	}
+	want := []time.Time{
+		time.Date(2024, time.March, 15, 12, 0, 0, 0, time.Local),
+		time.Date(2024, time.March, 15, 18, 0, 0, 0, time.Local),
+		time.Date(2024, time.March, 16, 0, 0, 0, 0, time.Local),
+	}
+	if len(times) != len(want) {
+		t.Fatalf("expected %d times, got %v", len(want), times)
+	}
+	for i := range want {
+		if !times[i].Equal(want[i]) {
+			t.Fatalf("expected %v at %d, got %v", want[i], i, times[i])
+		}
+	}
+}
+
+func TestScheduleCronRunsCallback(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	var count int32
+	taskItem := NewTaskItem()
+	taskItem.SetKey("cron")
+	observer, err := s.ScheduleCron("* * * * * *", taskItem, func(*TaskItem) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScheduleCron: %v", err)
+	}
+	defer observer.Stop()
+
+	info := observer.Info()
+	if info.Kind != TaskKindSchedule || info.Schedule != "cron * * * * * *" || info.NextFireTime.IsZero() {
+		t.Fatalf("unexpected task info %+v", info)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for atomic.LoadInt32(&count) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&count) == 0 {
+		t.Fatal("expected cron callback to run")
+	}
+
+	if observer, err := s.ScheduleCron("0 0 30 2 *", NewTaskItem(), nil); !errors.Is(err, ErrScheduleNeverFires) || observer != nil {
+		t.Fatalf("expected a spec that never fires to be rejected, got %v", err)
+	}
+	if _, err := s.ScheduleCron("bad", NewTaskItem(), nil); err == nil {
+		t.Fatal("expected invalid spec to be rejected")
+	}
+}
@@ -0,0 +1,312 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// 恢复时统计或补触发错过的次数的上限
+	maxMisfireRuns = 1000
+	// 补触发调度项key的后缀
+	misfireKeySuffix = ":misfire"
+)
+
+var (
+	ErrJobTypeNotRegistered = errors.New("scheduler: job type not registered")
+)
+
+// 一次恢复的结果,均为任务的key
+type RecoverResult struct {
+	// 重新调度的任务
+	Recovered []string
+	// 有错过的触发的任务及其错过的次数
+	Misfired map[string]int
+	// 类型没有注册回调的任务,保留在JobStore中不调度
+	Unregistered []string
+	// 已经没有下一次触发时间的任务,从JobStore中删除
+	Finished []string
+	// 调度规则无效的任务,保留在JobStore中不调度
+	Invalid []string
+	// 写入JobStore失败的任务及其错误,任务已经按照内存中的状态调度,再次调用Recover时会重试
+	StoreErrors map[string]error
+}
+
+type durableSchedulerOptions struct {
+	storeErrorHandler func(key string, err error)
+}
+
+type DurableSchedulerOption func(o *durableSchedulerOptions)
+
+// 任务执行后写入JobStore失败时的回调,默认忽略
+// 失败时JobStore中保存的状态与内存中的不一致,下一次重启时会按照保存的状态恢复
+func DurableSchedulerOptionWithStoreErrorHandler(handler func(key string, err error)) DurableSchedulerOption {
+	return func(o *durableSchedulerOptions) {
+		o.storeErrorHandler = handler
+	}
+}
+
+// 将任务保存在JobStore中的调度器,进程重启后通过Recover恢复
+// 回调按照任务的类型名注册,回调中TaskItem.Value为任务的JobRecord
+type DurableScheduler struct {
+	scheduler ITaskScheduler
+	store     JobStore
+	options   *durableSchedulerOptions
+
+	lock     sync.Mutex
+	typeList map[string]func(*TaskItem) error
+	jobList  map[string]JobRecord
+}
+
+func NewDurableScheduler(scheduler ITaskScheduler, store JobStore, opts ...DurableSchedulerOption) *DurableScheduler {
+	options := &durableSchedulerOptions{}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+	return &DurableScheduler{
+		scheduler: scheduler,
+		store:     store,
+		options:   options,
+		typeList:  make(map[string]func(*TaskItem) error),
+		jobList:   make(map[string]JobRecord),
+	}
+}
+
+// 注册任务类型对应的回调,需要在Schedule与Recover之前调用
+func (s *DurableScheduler) RegisterJobType(name string, callback func(*TaskItem) error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.typeList[name] = callback
+}
+
+// 调度并保存一个任务,key相同的任务会被替换
+// record中的运行状态被忽略,下一次触发时间由调度规则计算
+func (s *DurableScheduler) Schedule(record JobRecord) (ITaskSchedulerObserver, error) {
+	if len(record.Key) <= 0 {
+		return nil, fmt.Errorf("scheduler: job key is required")
+	}
+	schedule, err := ParseScheduleSpec(record.ScheduleKind, record.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	callback, ok := s.getCallback(record.Type)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrJobTypeNotRegistered, record.Type)
+	}
+
+	record.NextFireTime = time.Time{}
+	record.LastRunTime = time.Time{}
+	record.LastError = ""
+	record.RunCount = 0
+	return s.schedule(record, schedule, callback)
+}
+
+// 停止并删除一个任务,从JobStore中删除失败时任务仍然保留在Jobs中
+func (s *DurableScheduler) Remove(key string) error {
+	s.scheduler.StopScheduler(key)
+	s.scheduler.StopScheduler(key + misfireKeySuffix)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.store.Delete(key); err != nil {
+		return err
+	}
+	delete(s.jobList, key)
+	return nil
+}
+
+// 当前调度中的任务,按照key排序
+func (s *DurableScheduler) Jobs() []JobRecord {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return sortedJobRecords(s.jobList)
+}
+
+// 从JobStore中恢复所有的任务,按照任务的MisfirePolicy处理进程没有运行期间错过的触发
+// 只有加载失败时返回错误,单个任务写入JobStore失败时记录在RecoverResult.StoreErrors中并继续恢复其它任务
+// 可以重复调用,已经调度的任务会被替换
+func (s *DurableScheduler) Recover() (*RecoverResult, error) {
+	records, err := s.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RecoverResult{
+		Misfired:    make(map[string]int),
+		StoreErrors: make(map[string]error),
+	}
+	now := time.Now()
+	for _, eachRecord := range records {
+		callback, ok := s.getCallback(eachRecord.Type)
+		if !ok {
+			result.Unregistered = append(result.Unregistered, eachRecord.Key)
+			continue
+		}
+		schedule, err := ParseScheduleSpec(eachRecord.ScheduleKind, eachRecord.Schedule)
+		if err != nil {
+			result.Invalid = append(result.Invalid, eachRecord.Key)
+			continue
+		}
+
+		// 从保存的下一次触发时间开始,统计错过的次数并找到now之后的第一次触发
+		next := eachRecord.NextFireTime
+		if next.IsZero() {
+			next = schedule.Next(now)
+		}
+		missed := 0
+		for !next.IsZero() && !next.After(now) && missed < maxMisfireRuns {
+			missed++
+			next = schedule.Next(next)
+		}
+		if !next.IsZero() && !next.After(now) {
+			// 超过上限的部分不再统计,直接计算now之后的第一次触发
+			next = schedule.Next(now)
+		}
+
+		if next.IsZero() {
+			result.Finished = append(result.Finished, eachRecord.Key)
+			if missed <= 0 {
+				if err := s.store.Delete(eachRecord.Key); err != nil {
+					result.StoreErrors[eachRecord.Key] = err
+				}
+			}
+		} else {
+			// 先恢复调度再补触发,补触发完成后保存的运行状态中才有下一次触发时间
+			resumed := &resumeSchedule{inner: schedule, first: next}
+			if _, err := s.schedule(eachRecord, resumed, callback); err != nil {
+				result.StoreErrors[eachRecord.Key] = err
+			}
+			result.Recovered = append(result.Recovered, eachRecord.Key)
+		}
+		if missed > 0 {
+			result.Misfired[eachRecord.Key] = missed
+			s.fireMissed(eachRecord, callback, missed)
+		}
+	}
+	return result, nil
+}
+
+// 按照MisfirePolicy补触发错过的运行
+func (s *DurableScheduler) fireMissed(record JobRecord, callback func(*TaskItem) error, missed int) {
+	runs := 0
+	switch record.MisfirePolicy {
+	case MisfireSkip:
+		return
+	case MisfireFireAll:
+		runs = missed
+	default:
+		runs = 1
+	}
+
+	s.lock.Lock()
+	if _, ok := s.jobList[record.Key]; !ok {
+		// 已经没有下一次触发的任务,补触发完成后删除
+		s.jobList[record.Key] = record
+	}
+	s.lock.Unlock()
+
+	s.scheduler.StopScheduler(record.Key + misfireKeySuffix)
+	taskItem := NewTaskItem()
+	taskItem.SetKey(record.Key + misfireKeySuffix)
+	taskItem.Value = record
+	s.scheduler.AfterFunc(0, taskItem, func(ti *TaskItem) error {
+		var err error
+		for i := 0; i < runs; i++ {
+			err = s.run(record.Key, ti, callback)
+		}
+		return err
+	})
+}
+
+func (s *DurableScheduler) schedule(record JobRecord,
+	schedule Schedule,
+	callback func(*TaskItem) error) (ITaskSchedulerObserver, error) {
+
+	// 替换已经调度的同名任务,否则旧的计时器会继续触发
+	s.scheduler.StopScheduler(record.Key)
+	taskItem := NewTaskItem()
+	taskItem.SetKey(record.Key)
+	taskItem.Value = record
+	s.lock.Lock()
+	s.jobList[record.Key] = record
+	s.lock.Unlock()
+
+	observer := s.scheduler.ScheduleFunc(schedule, taskItem, func(ti *TaskItem) error {
+		return s.run(record.Key, ti, callback)
+	})
+	if observer == nil {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if err := s.store.Delete(record.Key); err != nil {
+			return nil, err
+		}
+		delete(s.jobList, record.Key)
+		return nil, nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if current, ok := s.jobList[record.Key]; ok {
+		current.NextFireTime = observer.Info().NextFireTime
+		s.jobList[record.Key] = current
+		return observer, s.store.Save(current)
+	}
+	return observer, nil
+}
+
+// 执行回调并保存运行状态
+func (s *DurableScheduler) run(key string, ti *TaskItem, callback func(*TaskItem) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("scheduler: job %s panic: %v", key, p)
+		}
+		s.onRun(key, err)
+	}()
+	return callback(ti)
+}
+
+func (s *DurableScheduler) onRun(key string, runErr error) {
+	if err := s.saveRunState(key, runErr); err != nil && s.options.storeErrorHandler != nil {
+		s.options.storeErrorHandler(key, err)
+	}
+}
+
+// 保存一次执行后的运行状态,返回写入JobStore的错误
+func (s *DurableScheduler) saveRunState(key string, runErr error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	record, ok := s.jobList[key]
+	if !ok {
+		// 已经被删除
+		return nil
+	}
+	record.LastRunTime = time.Now()
+	record.RunCount++
+	record.LastError = ""
+	if runErr != nil {
+		record.LastError = runErr.Error()
+	}
+	observer, ok := s.scheduler.GetTask(key)
+	if !ok || observer.IsStopped() {
+		// 没有下一次触发,删除失败时保留在内存中,可以通过Remove重试
+		s.jobList[key] = record
+		if err := s.store.Delete(key); err != nil {
+			return err
+		}
+		delete(s.jobList, key)
+		return nil
+	}
+	record.NextFireTime = observer.Info().NextFireTime
+	s.jobList[key] = record
+	return s.store.Save(record)
+}
+
+func (s *DurableScheduler) getCallback(name string) (func(*TaskItem) error, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	callback, ok := s.typeList[name]
+	return callback, ok
+}
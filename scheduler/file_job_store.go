@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+const (
+	fileJobStoreOpSave   = "save"
+	fileJobStoreOpDelete = "delete"
+
+	// 日志条目超过存活任务数的这个倍数时压缩
+	fileJobStoreCompactFactor = 4
+	// 日志条目少于这个数量时不压缩
+	fileJobStoreCompactMinEntries = 256
+)
+
+// 追加日志中的一条记录
+type fileJobStoreEntry struct {
+	Op  string     `json:"op"`
+	Key string     `json:"key,omitempty"`
+	Job *JobRecord `json:"job,omitempty"`
+}
+
+var _ JobStore = (*FileJobStore)(nil)
+
+// 以追加日志的方式保存在文件中的JobStore,每一行为一条JSON格式的保存或删除记录
+// 打开时重放日志,最后一行不完整(如写入时进程崩溃)时忽略这一行;日志过长时自动压缩为每个任务一条记录
+type FileJobStore struct {
+	path string
+
+	lock    sync.Mutex
+	file    *os.File
+	jobList map[string]JobRecord
+	// 当前日志中的条目数
+	entries int
+}
+
+// 打开或者创建path对应的任务文件
+func NewFileJobStore(path string) (*FileJobStore, error) {
+	s := &FileJobStore{
+		path:    path,
+		jobList: make(map[string]JobRecord),
+	}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// 重放日志
+func (s *FileJobStore) replay() error {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var pendingErr error
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if pendingErr != nil {
+			// 无效的行之后还有内容,不是因为崩溃而不完整的最后一行
+			return pendingErr
+		}
+		line := scanner.Bytes()
+		if len(line) <= 0 {
+			continue
+		}
+		var entry fileJobStoreEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			pendingErr = fmt.Errorf("jobstore: %s line %d: %w", s.path, lineNo, err)
+			continue
+		}
+		s.apply(entry)
+	}
+	return scanner.Err()
+}
+
+func (s *FileJobStore) apply(entry fileJobStoreEntry) {
+	switch entry.Op {
+	case fileJobStoreOpSave:
+		if entry.Job != nil {
+			s.jobList[entry.Job.Key] = *entry.Job
+		}
+	case fileJobStoreOpDelete:
+		delete(s.jobList, entry.Key)
+	}
+}
+
+// 将当前的任务写入临时文件后替换原来的文件
+// 临时文件的句柄在替换后直接作为新的追加句柄,任何一步失败时保留原来的文件与句柄
+func (s *FileJobStore) compact() error {
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	discard := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	records := sortedJobRecords(s.jobList)
+	for i := range records {
+		if err := writeFileJobStoreEntry(writer, fileJobStoreEntry{Op: fileJobStoreOpSave, Job: &records[i]}); err != nil {
+			return discard(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return discard(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return discard(err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return discard(err)
+	}
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file = tmp
+	s.entries = len(records)
+	return nil
+}
+
+func writeFileJobStoreEntry(writer *bufio.Writer, entry fileJobStoreEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	return writer.WriteByte('\n')
+}
+
+func (s *FileJobStore) appendEntry(entry fileJobStoreEntry) error {
+	if s.file == nil {
+		return fmt.Errorf("jobstore: %s is closed", s.path)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.apply(entry)
+	s.entries++
+	if s.entries >= fileJobStoreCompactMinEntries && s.entries > len(s.jobList)*fileJobStoreCompactFactor {
+		return s.compact()
+	}
+	return nil
+}
+
+// #region JobStore Members
+
+func (s *FileJobStore) Save(record JobRecord) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.appendEntry(fileJobStoreEntry{Op: fileJobStoreOpSave, Job: &record})
+}
+
+func (s *FileJobStore) Delete(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.jobList[key]; !ok {
+		return nil
+	}
+	return s.appendEntry(fileJobStoreEntry{Op: fileJobStoreOpDelete, Key: key})
+}
+
+func (s *FileJobStore) Load() ([]JobRecord, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return sortedJobRecords(s.jobList), nil
+}
+
+// #endregion
+
+// 压缩日志文件
+func (s *FileJobStore) Compact() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.compact()
+}
+
+// 关闭文件,之后的Save与Delete返回错误
+func (s *FileJobStore) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
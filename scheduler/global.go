@@ -64,3 +64,18 @@ func AfterFunc(d time.Duration,
 	})
 	return observer
 }
+
+// 按照cron表达式调度一个函数,这个定时器的回调会存在着并行执行的
+// 表达式无效时返回错误
+func SchedulerCronTask(spec string,
+	taskId string,
+	timerFunc func(string) error,
+	finishedCallback ...func(ITaskSchedulerObserver)) (ITaskSchedulerObserver, error) {
+
+	taskItem := NewTaskItem()
+	taskItem.Value = taskId
+	return _taskScheduler.ScheduleCron(spec, taskItem, func(ti *TaskItem) error {
+		currentItemValue := ti.Value.(string)
+		return timerFunc(currentItemValue)
+	}, finishedCallback...)
+}
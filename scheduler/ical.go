@@ -0,0 +1,274 @@
+package scheduler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	icalDateFormat      = "20060102"
+	icalDateTimeFormat  = "20060102T150405"
+	icalUTCTimeFormat   = "20060102T150405Z"
+	icalStatusCancelled = "CANCELLED"
+)
+
+// .ics文件中的一个VEVENT
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	// 开始时间,没有RRULE时只在这个时间触发一次
+	Start time.Time
+	End   time.Time
+	// 是否为全天事件(DTSTART;VALUE=DATE)
+	AllDay bool
+	// 不带"RRULE:"前缀的重复规则,为空时不重复
+	RRule   string
+	ExDates []time.Time
+	// 事件被修改的版本号
+	Sequence int
+	// STATUS:CANCELLED
+	Cancelled bool
+}
+
+// 事件对应的调度规则,有RRULE时按照RRULE重复,否则只在Start触发一次
+func (e CalendarEvent) Schedule() (Schedule, error) {
+	if len(e.RRule) > 0 {
+		return ParseRRule(e.RRule, e.Start, e.ExDates...)
+	}
+	return &onceSchedule{at: e.Start}, nil
+}
+
+// 用于判断重新导入时事件是否被修改
+func (e CalendarEvent) fingerprint() string {
+	exDates := make([]int64, 0, len(e.ExDates))
+	for _, eachExDate := range e.ExDates {
+		exDates = append(exDates, eachExDate.Unix())
+	}
+	sort.Slice(exDates, func(i, j int) bool { return exDates[i] < exDates[j] })
+	return fmt.Sprintf("%d|%d|%t|%s|%v|%d|%t|%q|%q|%q",
+		e.Start.Unix(), e.End.Unix(), e.AllDay, e.RRule, exDates, e.Sequence, e.Cancelled,
+		e.Summary, e.Description, e.Location)
+}
+
+var _ Schedule = (*onceSchedule)(nil)
+
+// 只在指定时间触发一次的调度规则
+type onceSchedule struct {
+	at time.Time
+}
+
+func (s *onceSchedule) String() string {
+	return "at " + s.at.Format(time.RFC3339)
+}
+
+// #region Schedule Members
+
+func (s *onceSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.at) {
+		return s.at
+	}
+	return time.Time{}
+}
+
+// #endregion
+
+// 解析.ics文件中的所有VEVENT,没有时区的时间使用loc
+// 带有RECURRENCE-ID的VEVENT(重复事件中被单独修改的某一次)不被支持,会被忽略
+func ParseICS(r io.Reader, loc *time.Location) ([]CalendarEvent, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []CalendarEvent
+	var current *CalendarEvent
+	skip := false
+	// 当前打开的组件,属性只有在最内层的组件为VEVENT时才属于事件,VALARM等嵌套组件中的属性被忽略
+	var componentList []string
+	for lineNo, eachLine := range unfoldICalLines(string(content)) {
+		prop, err := parseICalContentLine(eachLine)
+		if err != nil {
+			return nil, fmt.Errorf("ics: content line %d: %w", lineNo+1, err)
+		}
+		switch prop.name {
+		case "BEGIN":
+			component := strings.ToUpper(prop.value)
+			componentList = append(componentList, component)
+			if component == "VEVENT" {
+				current = &CalendarEvent{}
+				skip = false
+			}
+			continue
+		case "END":
+			component := strings.ToUpper(prop.value)
+			if len(componentList) <= 0 || componentList[len(componentList)-1] != component {
+				return nil, fmt.Errorf("ics: content line %d: unexpected END:%s", lineNo+1, prop.value)
+			}
+			componentList = componentList[:len(componentList)-1]
+			if component == "VEVENT" && current != nil {
+				if !skip {
+					if len(current.UID) <= 0 {
+						return nil, fmt.Errorf("ics: content line %d: VEVENT without UID", lineNo+1)
+					}
+					if current.Start.IsZero() {
+						return nil, fmt.Errorf("ics: VEVENT %q without DTSTART", current.UID)
+					}
+					result = append(result, *current)
+				}
+				current = nil
+			}
+			continue
+		}
+		if current == nil || componentList[len(componentList)-1] != "VEVENT" {
+			continue
+		}
+		if err := current.setProperty(prop, loc); err != nil {
+			return nil, fmt.Errorf("ics: content line %d: %w", lineNo+1, err)
+		}
+		if prop.name == "RECURRENCE-ID" {
+			skip = true
+		}
+	}
+	return result, nil
+}
+
+func (e *CalendarEvent) setProperty(prop icalProperty, loc *time.Location) error {
+	var err error
+	switch prop.name {
+	case "UID":
+		e.UID = prop.value
+	case "SUMMARY":
+		e.Summary = unescapeICalText(prop.value)
+	case "DESCRIPTION":
+		e.Description = unescapeICalText(prop.value)
+	case "LOCATION":
+		e.Location = unescapeICalText(prop.value)
+	case "DTSTART":
+		e.Start, e.AllDay, err = parseICalTime(prop.value, prop.params, loc)
+	case "DTEND":
+		e.End, _, err = parseICalTime(prop.value, prop.params, loc)
+	case "RRULE":
+		e.RRule = prop.value
+	case "EXDATE":
+		var times []time.Time
+		times, err = parseICalTimeList(prop.value, prop.params, loc)
+		e.ExDates = append(e.ExDates, times...)
+	case "SEQUENCE":
+		e.Sequence, err = strconv.Atoi(prop.value)
+	case "STATUS":
+		e.Cancelled = strings.EqualFold(prop.value, icalStatusCancelled)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", prop.name, prop.value, err)
+	}
+	return nil
+}
+
+// 一行内容,如"DTSTART;TZID=Asia/Shanghai:20240101T090000"
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// 将折叠的行展开,以空格或制表符开始的行是上一行的延续
+func unfoldICalLines(text string) []string {
+	var result []string
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(result) > 0 {
+			result[len(result)-1] += line[1:]
+			continue
+		}
+		if len(strings.TrimSpace(line)) <= 0 {
+			continue
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+func parseICalContentLine(line string) (icalProperty, error) {
+	// 参数值可以用双引号包含冒号与分号
+	inQuote := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			inQuote = !inQuote
+		case ':':
+			if !inQuote {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return icalProperty{}, fmt.Errorf("invalid content line %q", line)
+	}
+	prop := icalProperty{
+		value: line[colon+1:],
+	}
+	parts := strings.Split(line[:colon], ";")
+	prop.name = strings.ToUpper(strings.TrimSpace(parts[0]))
+	for _, eachParam := range parts[1:] {
+		name, value, ok := strings.Cut(eachParam, "=")
+		if !ok {
+			return icalProperty{}, fmt.Errorf("invalid parameter %q in %q", eachParam, line)
+		}
+		if prop.params == nil {
+			prop.params = make(map[string]string)
+		}
+		prop.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+// 解析DATE或DATE-TIME,以Z结尾的为UTC时间,带有TZID参数的使用该时区,否则使用loc
+// 第二个返回值表示是否为不带时间的日期
+func parseICalTime(value string, params map[string]string, loc *time.Location) (time.Time, bool, error) {
+	if tzid, ok := params["TZID"]; ok {
+		location, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		loc = location
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icalUTCTimeFormat, value)
+		return t, false, err
+	}
+	if params["VALUE"] == "DATE" || len(value) == len(icalDateFormat) {
+		t, err := time.ParseInLocation(icalDateFormat, value, loc)
+		return t, true, err
+	}
+	t, err := time.ParseInLocation(icalDateTimeFormat, value, loc)
+	return t, false, err
+}
+
+func parseICalTimeList(value string, params map[string]string, loc *time.Location) ([]time.Time, error) {
+	var result []time.Time
+	for _, eachValue := range strings.Split(value, ",") {
+		t, _, err := parseICalTime(strings.TrimSpace(eachValue), params, loc)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func unescapeICalText(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func icsCalendar(events ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.Join(events, "") + "END:VCALENDAR\r\n"
+}
+
+func icsEvent(uid string, lines ...string) string {
+	return "BEGIN:VEVENT\r\nUID:" + uid + "\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VEVENT\r\n"
+}
+
+func TestParseICS(t *testing.T) {
+	calendar := icsCalendar(
+		icsEvent("maintenance",
+			"SUMMARY:Weekly\\, maintenance",
+			"DESCRIPTION:line one\\n",
+			" line two",
+			"DTSTART;TZID=Asia/Shanghai:20240101T030000",
+			"RRULE:FREQ=WEEKLY;BYDAY=MO",
+			"EXDATE;TZID=Asia/Shanghai:20240108T030000,20240115T030000",
+			"SEQUENCE:2"),
+		icsEvent("holiday", "DTSTART;VALUE=DATE:20241001", "STATUS:CANCELLED"),
+		icsEvent("maintenance", "RECURRENCE-ID:20240122T030000", "DTSTART:20240122T040000"),
+	)
+	events, err := ParseICS(strings.NewReader(calendar), time.UTC)
+	if err != nil {
+		t.Fatalf("ParseICS: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", events)
+	}
+	e := events[0]
+	if e.UID != "maintenance" || e.Summary != "Weekly, maintenance" || e.Description != "line one\nline two" || e.Sequence != 2 {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if e.Start.In(time.UTC).Hour() != 19 || len(e.ExDates) != 2 || e.RRule != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Fatalf("unexpected event times %+v", e)
+	}
+	if !events[1].AllDay || !events[1].Cancelled {
+		t.Fatalf("expected cancelled all-day event, got %+v", events[1])
+	}
+
+	schedule, err := e.Schedule()
+	if err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	got := NextTimes(schedule, e.Start, 1)
+	if len(got) != 1 || got[0].In(e.Start.Location()).Day() != 22 {
+		t.Fatalf("expected excluded dates to be skipped, got %v", got)
+	}
+}
+
+func TestParseICSIgnoresNestedAlarm(t *testing.T) {
+	calendar := icsCalendar(icsEvent("standup",
+		"SUMMARY:Standup",
+		"DTSTART:20240101T090000Z",
+		"BEGIN:VALARM",
+		"UID:alarm-1",
+		"ACTION:DISPLAY",
+		"SUMMARY:Reminder",
+		"DESCRIPTION:Reminder",
+		"TRIGGER:-PT10M",
+		"END:VALARM",
+		"DESCRIPTION:Daily sync"))
+	events, err := ParseICS(strings.NewReader(calendar), time.UTC)
+	if err != nil {
+		t.Fatalf("ParseICS: %v", err)
+	}
+	if len(events) != 1 || events[0].UID != "standup" || events[0].Summary != "Standup" || events[0].Description != "Daily sync" {
+		t.Fatalf("expected VALARM properties to be ignored, got %+v", events)
+	}
+}
+
+func TestCalendarImporterReimport(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+	importer := NewCalendarImporter(s, nil, CalendarImporterOptionWithLocation(time.UTC))
+	defer importer.Stop()
+
+	future := time.Now().Add(24 * time.Hour).UTC().Format(icalUTCTimeFormat)
+	later := time.Now().Add(48 * time.Hour).UTC().Format(icalUTCTimeFormat)
+	result, err := importer.Import(strings.NewReader(icsCalendar(
+		icsEvent("a", "DTSTART:"+future),
+		icsEvent("b", "DTSTART:"+future, "RRULE:FREQ=DAILY"),
+		icsEvent("c", "DTSTART:20000101T000000Z"),
+	)))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if strings.Join(result.Added, ",") != "a,b" || strings.Join(result.Expired, ",") != "c" {
+		t.Fatalf("unexpected first import %+v", result)
+	}
+	if _, ok := s.GetTask("ics:a"); !ok {
+		t.Fatal("expected event to be scheduled by UID")
+	}
+
+	result, err = importer.Import(strings.NewReader(icsCalendar(
+		icsEvent("b", "DTSTART:"+later, "RRULE:FREQ=DAILY"),
+		icsEvent("d", "DTSTART:"+future),
+	)))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if strings.Join(result.Added, ",") != "d" || strings.Join(result.Updated, ",") != "b" || strings.Join(result.Removed, ",") != "a" {
+		t.Fatalf("unexpected re-import %+v", result)
+	}
+	if _, ok := s.GetTask("ics:a"); ok {
+		t.Fatal("expected removed event to be stopped")
+	}
+	info, ok := s.GetTask("ics:b")
+	if !ok || info.Info().Kind != TaskKindSchedule {
+		t.Fatal("expected updated event to be rescheduled")
+	}
+
+	if _, err := importer.Import(strings.NewReader(icsCalendar(icsEvent("b", "DTSTART:"+later, "RRULE:FREQ=NEVER")))); err == nil {
+		t.Fatal("expected invalid RRULE to fail the import")
+	}
+	if len(importer.Events()) != 2 {
+		t.Fatalf("expected failed import to keep existing events, got %+v", importer.Events())
+	}
+}
@@ -0,0 +1,318 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 平均每月与每年的时长,只用于估算,最终结果总是按照日历计算
+const (
+	isoAverageMonth = time.Duration(30.436875 * float64(24*time.Hour))
+	isoAverageYear  = time.Duration(365.2425 * float64(24*time.Hour))
+)
+
+var isoTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ISO 8601中的时长,如P1Y2M10DT2H30M、P2W、PT0.5S
+// 年、月、日按照日历计算,时、分、秒按照固定时长计算
+type ISODuration struct {
+	Years, Months, Days int
+	Time                time.Duration
+}
+
+// 解析ISO 8601时长,如"PT1H"、"P1M"、"P1DT12H"、"P2W"
+func ParseISODuration(value string) (ISODuration, error) {
+	var d ISODuration
+	if len(value) < 2 || (value[0] != 'P' && value[0] != 'p') {
+		return d, fmt.Errorf("iso8601: invalid duration %q", value)
+	}
+	inTime := false
+	number := ""
+	hasComponent := false
+	for _, r := range strings.ToUpper(value[1:]) {
+		switch {
+		case r >= '0' && r <= '9' || r == '.' || r == ',':
+			number += string(r)
+			continue
+		case r == 'T':
+			if inTime || len(number) > 0 {
+				return d, fmt.Errorf("iso8601: invalid duration %q", value)
+			}
+			inTime = true
+			continue
+		}
+		if len(number) <= 0 {
+			return d, fmt.Errorf("iso8601: invalid duration %q", value)
+		}
+		n, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", "."), 64)
+		if err != nil {
+			return d, fmt.Errorf("iso8601: invalid duration %q", value)
+		}
+		whole := n == math.Trunc(n)
+		number = ""
+		hasComponent = true
+		switch {
+		case !inTime && r == 'Y' && whole:
+			d.Years += int(n)
+		case !inTime && r == 'M' && whole:
+			d.Months += int(n)
+		case !inTime && r == 'W' && whole:
+			d.Days += int(n) * 7
+		case !inTime && r == 'D' && whole:
+			d.Days += int(n)
+		case inTime && r == 'H':
+			d.Time += time.Duration(n * float64(time.Hour))
+		case inTime && r == 'M':
+			d.Time += time.Duration(n * float64(time.Minute))
+		case inTime && r == 'S':
+			d.Time += time.Duration(n * float64(time.Second))
+		default:
+			return d, fmt.Errorf("iso8601: invalid duration %q", value)
+		}
+	}
+	if len(number) > 0 || !hasComponent {
+		return d, fmt.Errorf("iso8601: invalid duration %q", value)
+	}
+	if d.IsZero() {
+		return d, fmt.Errorf("iso8601: duration must be positive in %q", value)
+	}
+	return d, nil
+}
+
+func (d ISODuration) IsZero() bool {
+	return d.Years == 0 && d.Months == 0 && d.Days == 0 && d.Time == 0
+}
+
+// 在t上增加n倍的时长,n可以为负数
+// 增加月份后的日期超出当月天数时取当月最后一天,如1月31日加1个月为2月28日或29日
+func (d ISODuration) AddTo(t time.Time, n int) time.Time {
+	if months := (d.Years*12 + d.Months) * n; months != 0 {
+		first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		daysInMonth := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		day := t.Day()
+		if day > daysInMonth {
+			day = daysInMonth
+		}
+		t = first.AddDate(0, 0, day-1)
+	}
+	return t.AddDate(0, 0, d.Days*n).Add(d.Time * time.Duration(n))
+}
+
+// 估算的固定时长
+func (d ISODuration) approximate() time.Duration {
+	return time.Duration(d.Years)*isoAverageYear +
+		time.Duration(d.Months)*isoAverageMonth +
+		time.Duration(d.Days)*24*time.Hour +
+		d.Time
+}
+
+func (d ISODuration) String() string {
+	var b strings.Builder
+	b.WriteString("P")
+	if d.Years != 0 {
+		fmt.Fprintf(&b, "%dY", d.Years)
+	}
+	if d.Months != 0 {
+		fmt.Fprintf(&b, "%dM", d.Months)
+	}
+	if d.Days != 0 {
+		fmt.Fprintf(&b, "%dD", d.Days)
+	}
+	if d.Time != 0 {
+		b.WriteString("T")
+		rest := d.Time
+		if h := rest / time.Hour; h > 0 {
+			fmt.Fprintf(&b, "%dH", h)
+			rest -= h * time.Hour
+		}
+		if m := rest / time.Minute; m > 0 {
+			fmt.Fprintf(&b, "%dM", m)
+			rest -= m * time.Minute
+		}
+		if rest > 0 {
+			b.WriteString(strconv.FormatFloat(rest.Seconds(), 'f', -1, 64) + "S")
+		}
+	}
+	return b.String()
+}
+
+var _ Schedule = (*RepeatingInterval)(nil)
+
+// ISO 8601重复时间间隔,如"R5/2026-01-01T00:00:00Z/PT1H"、"R/P1D"
+// 支持以下形式,Rn表示触发n次,R表示不限次数:
+//
+//	Rn/开始时间/时长  从开始时间起每隔时长触发一次
+//	Rn/开始时间/结束时间  以两者之差作为时长
+//	Rn/时长/结束时间  最后一个间隔在结束时间结束,触发时间为每个间隔的开始
+//	Rn/时长  从第一次计算触发时间时开始,每隔时长触发一次
+type RepeatingInterval struct {
+	spec string
+
+	// 触发次数,小于0表示不限次数
+	repetitions int
+	start       time.Time
+	end         time.Time
+	duration    ISODuration
+
+	// 只有时长时,在第一次调用Next时确定开始时间
+	anchorLock sync.Mutex
+}
+
+// 解析ISO 8601重复时间间隔,没有时区的时间使用time.Local
+func ParseRepeatingInterval(spec string) (*RepeatingInterval, error) {
+	spec = strings.TrimSpace(spec)
+	parts := strings.Split(spec, "/")
+	if len(parts) < 2 || len(parts) > 3 || len(parts[0]) <= 0 || (parts[0][0] != 'R' && parts[0][0] != 'r') {
+		return nil, fmt.Errorf("iso8601: invalid repeating interval %q", spec)
+	}
+	r := &RepeatingInterval{
+		spec:        spec,
+		repetitions: -1,
+	}
+	if len(parts[0]) > 1 {
+		n, err := strconv.Atoi(parts[0][1:])
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("iso8601: invalid repetitions %q in %q", parts[0], spec)
+		}
+		r.repetitions = n
+	}
+
+	var err error
+	if len(parts) == 2 {
+		if r.duration, err = ParseISODuration(parts[1]); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
+	first, second := parts[1], parts[2]
+	switch {
+	case isISODuration(first) && isISODuration(second):
+		return nil, fmt.Errorf("iso8601: missing start or end in %q", spec)
+	case isISODuration(first):
+		if r.duration, err = ParseISODuration(first); err != nil {
+			return nil, err
+		}
+		if r.end, err = parseISOTime(second); err != nil {
+			return nil, err
+		}
+	case isISODuration(second):
+		if r.start, err = parseISOTime(first); err != nil {
+			return nil, err
+		}
+		if r.duration, err = ParseISODuration(second); err != nil {
+			return nil, err
+		}
+	default:
+		if r.start, err = parseISOTime(first); err != nil {
+			return nil, err
+		}
+		if r.end, err = parseISOTime(second); err != nil {
+			return nil, err
+		}
+		if !r.end.After(r.start) {
+			return nil, fmt.Errorf("iso8601: end must be after start in %q", spec)
+		}
+		r.duration = ISODuration{Time: r.end.Sub(r.start)}
+		// 开始与结束只用于确定时长,不限制触发时间
+		r.end = time.Time{}
+	}
+	return r, nil
+}
+
+func isISODuration(value string) bool {
+	return len(value) > 0 && (value[0] == 'P' || value[0] == 'p')
+}
+
+func parseISOTime(value string) (time.Time, error) {
+	for _, eachLayout := range isoTimeLayouts {
+		if t, err := time.ParseInLocation(eachLayout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("iso8601: invalid time %q", value)
+}
+
+// 以ISO 8601的表示法返回,与解析时的写法相同
+func (r *RepeatingInterval) String() string {
+	return r.spec
+}
+
+// 触发次数,小于0表示不限次数
+func (r *RepeatingInterval) Repetitions() int {
+	return r.repetitions
+}
+
+func (r *RepeatingInterval) Duration() ISODuration {
+	return r.duration
+}
+
+// #region Schedule Members
+
+func (r *RepeatingInterval) Next(t time.Time) time.Time {
+	if !r.end.IsZero() {
+		return r.nextBeforeEnd(t)
+	}
+	start := r.anchor(t)
+	// 估算t所在的序号再修正,日历时长的实际长度并不固定
+	k := 0
+	if approx := r.duration.approximate(); t.After(start) && approx > 0 {
+		k = int(t.Sub(start) / approx)
+	}
+	for k > 0 && r.duration.AddTo(start, k-1).After(t) {
+		k--
+	}
+	for !r.duration.AddTo(start, k).After(t) {
+		k++
+	}
+	if r.repetitions >= 0 && k >= r.repetitions {
+		return time.Time{}
+	}
+	return r.duration.AddTo(start, k)
+}
+
+// #endregion
+
+// 以结束时间为锚点,触发时间为end-k*duration,k从n递减到1
+func (r *RepeatingInterval) nextBeforeEnd(t time.Time) time.Time {
+	if !r.duration.AddTo(r.end, -1).After(t) {
+		return time.Time{}
+	}
+	// 找到晚于t的最大的k
+	k := 1
+	if approx := r.duration.approximate(); approx > 0 && r.end.After(t) {
+		k = int(r.end.Sub(t) / approx)
+	}
+	if k < 1 {
+		k = 1
+	}
+	if r.repetitions >= 0 && k > r.repetitions {
+		k = r.repetitions
+	}
+	for k > 1 && !r.duration.AddTo(r.end, -k).After(t) {
+		k--
+	}
+	for (r.repetitions < 0 || k < r.repetitions) && r.duration.AddTo(r.end, -(k+1)).After(t) {
+		k++
+	}
+	return r.duration.AddTo(r.end, -k)
+}
+
+// 开始时间,只有时长时第一次触发在第一次调用Next的时间之后一个时长
+func (r *RepeatingInterval) anchor(t time.Time) time.Time {
+	r.anchorLock.Lock()
+	defer r.anchorLock.Unlock()
+	if r.start.IsZero() {
+		r.start = r.duration.AddTo(t, 1)
+	}
+	return r.start
+}
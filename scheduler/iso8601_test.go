@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseISODuration(t *testing.T) {
+	cases := map[string]ISODuration{
+		"PT1H":           {Time: time.Hour},
+		"P1M":            {Months: 1},
+		"P1Y2M10DT2H30M": {Years: 1, Months: 2, Days: 10, Time: 2*time.Hour + 30*time.Minute},
+		"P2W":            {Days: 14},
+		"PT0.5S":         {Time: 500 * time.Millisecond},
+	}
+	for value, want := range cases {
+		got, err := ParseISODuration(value)
+		if err != nil || got != want {
+			t.Fatalf("%q: expected %+v, got %+v (%v)", value, want, got, err)
+		}
+	}
+	for _, value := range []string{"", "P", "PT", "1H", "P1H", "PT1D", "P0D", "P1.5M"} {
+		if _, err := ParseISODuration(value); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestRepeatingIntervalNext(t *testing.T) {
+	from := time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	r, err := ParseRepeatingInterval("R3/2026-01-01T00:00:00Z/PT1H")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	got := NextTimes(r, from, 10)
+	if len(got) != 3 || !got[0].Equal(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)) ||
+		!got[2].Equal(time.Date(2026, time.January, 1, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected bounded hourly times %v", got)
+	}
+
+	r, _ = ParseRepeatingInterval("R/2026-01-31T00:00:00Z/P1M")
+	got = NextTimes(r, time.Date(2026, time.June, 15, 0, 0, 0, 0, time.UTC), 1)
+	if len(got) != 1 || !got[0].Equal(time.Date(2026, time.June, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected calendar month times %v", got)
+	}
+
+	r, _ = ParseRepeatingInterval("R2/P1D/2026-01-10T00:00:00Z")
+	got = NextTimes(r, from, 10)
+	if len(got) != 2 || got[0].Day() != 8 || got[1].Day() != 9 {
+		t.Fatalf("unexpected end-anchored times %v", got)
+	}
+
+	r, _ = ParseRepeatingInterval("R2/2026-01-01T00:00:00Z/2026-01-01T00:30:00Z")
+	got = NextTimes(r, from, 10)
+	if len(got) != 2 || got[1].Sub(got[0]) != 30*time.Minute {
+		t.Fatalf("unexpected start/end times %v", got)
+	}
+
+	r, _ = ParseRepeatingInterval("R/P1D")
+	if next := r.Next(from); !next.Equal(from.AddDate(0, 0, 1)) {
+		t.Fatalf("expected duration-only interval to start after one period, got %v", next)
+	}
+	if next := r.Next(from.AddDate(0, 0, 1)); !next.Equal(from.AddDate(0, 0, 2)) {
+		t.Fatalf("expected anchor to stay fixed, got %v", next)
+	}
+}
+
+func TestParseRepeatingIntervalInvalid(t *testing.T) {
+	for _, spec := range []string{"", "P1D", "R0/P1D", "Rx/P1D", "R/P1D/P1D", "R/2026-01-01/2025-01-01", "R/2026-13-01/PT1H"} {
+		if _, err := ParseRepeatingInterval(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestScheduleRepeatingIntervalReportsNotation(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	taskItem := NewTaskItem()
+	taskItem.SetKey("iso")
+	observer, err := s.ScheduleRepeatingInterval("R/PT1H", taskItem, nil)
+	if err != nil {
+		t.Fatalf("ScheduleRepeatingInterval: %v", err)
+	}
+	defer observer.Stop()
+
+	tasks := s.Tasks()
+	if len(tasks) != 1 || tasks[0].Schedule != "R/PT1H" || tasks[0].Kind != TaskKindSchedule {
+		t.Fatalf("expected schedule to be reported in ISO 8601 notation, got %+v", tasks)
+	}
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 进程没有运行期间错过的触发的处理方式
+type MisfirePolicy string
+
+const (
+	// 恢复时立即补触发一次,默认值
+	MisfireFireOnce MisfirePolicy = "fire_once"
+	// 恢复时依次补上所有错过的触发,最多maxMisfireRuns次
+	MisfireFireAll MisfirePolicy = "fire_all"
+	// 忽略错过的触发,从下一次触发时间继续
+	MisfireSkip MisfirePolicy = "skip"
+)
+
+// 保存在JobStore中的任务,包括任务的定义与运行状态
+type JobRecord struct {
+	Key string `json:"key"`
+	// 回调的类型名,恢复时根据这个名称找到通过RegisterJobType注册的回调
+	Type         string       `json:"type"`
+	ScheduleKind ScheduleKind `json:"scheduleKind"`
+	// 调度规则的表示法,与ScheduleKind一起通过ParseScheduleSpec解析
+	Schedule string `json:"schedule"`
+	// 回调所需的数据
+	Data          json.RawMessage `json:"data,omitempty"`
+	MisfirePolicy MisfirePolicy   `json:"misfirePolicy,omitempty"`
+
+	// 下一次触发的时间
+	NextFireTime time.Time `json:"nextFireTime"`
+	// 最后一次执行回调的时间
+	LastRunTime time.Time `json:"lastRunTime"`
+	// 最后一次回调返回的错误
+	LastError string `json:"lastError,omitempty"`
+	RunCount  int64  `json:"runCount"`
+}
+
+// 任务的持久化存储
+type JobStore interface {
+	// 保存任务,key相同时覆盖
+	Save(record JobRecord) error
+	// 删除任务,key不存在时不返回错误
+	Delete(key string) error
+	// 加载所有的任务,按照key排序
+	Load() ([]JobRecord, error)
+}
+
+var _ JobStore = (*MemoryJobStore)(nil)
+
+// 保存在内存中的JobStore,进程退出后丢失,用于测试或者不需要持久化的场合
+type MemoryJobStore struct {
+	lock    sync.Mutex
+	jobList map[string]JobRecord
+}
+
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{
+		jobList: make(map[string]JobRecord),
+	}
+}
+
+// #region JobStore Members
+
+func (s *MemoryJobStore) Save(record JobRecord) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.jobList[record.Key] = record
+	return nil
+}
+
+func (s *MemoryJobStore) Delete(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.jobList, key)
+	return nil
+}
+
+func (s *MemoryJobStore) Load() ([]JobRecord, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return sortedJobRecords(s.jobList), nil
+}
+
+// #endregion
+
+func sortedJobRecords(jobList map[string]JobRecord) []JobRecord {
+	result := make([]JobRecord, 0, len(jobList))
+	for _, eachRecord := range jobList {
+		result = append(result, eachRecord)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
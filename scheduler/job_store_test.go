@@ -0,0 +1,221 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileJobStoreReplaysLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.log")
+	store, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatalf("NewFileJobStore: %v", err)
+	}
+	store.Save(JobRecord{Key: "a", Type: "report", ScheduleKind: ScheduleKindInterval, Schedule: "1h", Data: json.RawMessage(`{"n":1}`)})
+	store.Save(JobRecord{Key: "b", Type: "report", ScheduleKind: ScheduleKindCron, Schedule: "@daily"})
+	store.Save(JobRecord{Key: "a", Type: "report", ScheduleKind: ScheduleKindInterval, Schedule: "2h", RunCount: 3, Data: json.RawMessage(`{"n":1}`)})
+	store.Delete("b")
+	store.Close()
+
+	// 模拟写入时崩溃留下的不完整的最后一行
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	file.WriteString(`{"op":"save","job":{"key":"c"`)
+	file.Close()
+
+	store, err = NewFileJobStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer store.Close()
+	records, _ := store.Load()
+	if len(records) != 1 || records[0].Key != "a" || records[0].Schedule != "2h" || records[0].RunCount != 3 || string(records[0].Data) != `{"n":1}` {
+		t.Fatalf("unexpected records after replay %+v", records)
+	}
+}
+
+func TestFileJobStoreKeepsFileWhenCompactFails(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jobs.log")
+	store, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatalf("NewFileJobStore: %v", err)
+	}
+	defer store.Close()
+	store.Save(JobRecord{Key: "a", Type: "report", ScheduleKind: ScheduleKindInterval, Schedule: "1h"})
+
+	// 替换的目标是一个非空目录,重命名失败
+	blocked := filepath.Join(dir, "blocked")
+	os.MkdirAll(filepath.Join(blocked, "child"), 0o755)
+	store.path = blocked
+	if err := store.Compact(); err == nil {
+		t.Fatal("expected compact to fail")
+	}
+	store.path = path
+	if _, err := os.Stat(blocked + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected the temporary file to be removed, got %v", err)
+	}
+
+	if err := store.Save(JobRecord{Key: "b", Type: "report", ScheduleKind: ScheduleKindCron, Schedule: "@daily"}); err != nil {
+		t.Fatalf("expected the store to stay open after a failed compact, got %v", err)
+	}
+	store.Close()
+	reopened, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if records, _ := reopened.Load(); len(records) != 2 {
+		t.Fatalf("expected both records to be kept, got %+v", records)
+	}
+}
+
+func TestDurableSchedulerRecoverMisfirePolicies(t *testing.T) {
+	store := NewMemoryJobStore()
+	past := time.Now().Add(-time.Hour - 30*time.Second)
+	store.Save(JobRecord{Key: "once", Type: "count", ScheduleKind: ScheduleKindInterval, Schedule: "10m", NextFireTime: past})
+	store.Save(JobRecord{Key: "all", Type: "count", ScheduleKind: ScheduleKindInterval, Schedule: "10m", NextFireTime: past, MisfirePolicy: MisfireFireAll})
+	store.Save(JobRecord{Key: "skip", Type: "count", ScheduleKind: ScheduleKindInterval, Schedule: "10m", NextFireTime: past, MisfirePolicy: MisfireSkip})
+	store.Save(JobRecord{Key: "done", Type: "count", ScheduleKind: ScheduleKindOnce, Schedule: past.Add(-time.Hour).Format(time.RFC3339), NextFireTime: time.Time{}})
+	store.Save(JobRecord{Key: "unknown", Type: "missing", ScheduleKind: ScheduleKindInterval, Schedule: "1h"})
+
+	s := NewTaskScheduler()
+	defer s.Stop()
+	durable := NewDurableScheduler(s, store)
+	var counts = map[string]*int32{"once": new(int32), "all": new(int32), "skip": new(int32)}
+	durable.RegisterJobType("count", func(ti *TaskItem) error {
+		record := ti.Value.(JobRecord)
+		atomic.AddInt32(counts[record.Key], 1)
+		return nil
+	})
+
+	result, err := durable.Recover()
+	if err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if len(result.Recovered) != 3 || len(result.Unregistered) != 1 || len(result.Finished) != 1 {
+		t.Fatalf("unexpected recover result %+v", result)
+	}
+	if result.Misfired["all"] != 7 {
+		t.Fatalf("expected 7 missed runs, got %+v", result.Misfired)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(counts["all"]) < 7 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(counts["once"]) != 1 || atomic.LoadInt32(counts["all"]) != 7 || atomic.LoadInt32(counts["skip"]) != 0 {
+		t.Fatalf("unexpected misfire runs once=%d all=%d skip=%d",
+			atomic.LoadInt32(counts["once"]), atomic.LoadInt32(counts["all"]), atomic.LoadInt32(counts["skip"]))
+	}
+
+	records, _ := store.Load()
+	for _, eachRecord := range records {
+		switch eachRecord.Key {
+		case "done":
+			t.Fatal("expected finished job to be removed from the store")
+		case "all":
+			if eachRecord.RunCount != 7 || !eachRecord.NextFireTime.After(time.Now()) {
+				t.Fatalf("expected run state to be persisted, got %+v", eachRecord)
+			}
+		}
+	}
+
+	if err := durable.Remove("once"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, ok := s.GetTask("once"); ok {
+		t.Fatal("expected removed job to be stopped")
+	}
+	if records, _ := store.Load(); len(records) != 3 {
+		t.Fatalf("expected removed job to be deleted from the store, got %+v", records)
+	}
+}
+
+// Save与Delete总是失败的JobStore
+type failingJobStore struct {
+	*MemoryJobStore
+}
+
+func (s *failingJobStore) Save(record JobRecord) error {
+	return errors.New("disk full")
+}
+
+func (s *failingJobStore) Delete(key string) error {
+	return errors.New("disk full")
+}
+
+func TestDurableSchedulerReportsStoreErrors(t *testing.T) {
+	memory := NewMemoryJobStore()
+	memory.Save(JobRecord{Key: "tick", Type: "count", ScheduleKind: ScheduleKindInterval, Schedule: "20ms"})
+	store := &failingJobStore{MemoryJobStore: memory}
+
+	s := NewTaskScheduler()
+	defer s.Stop()
+	handled := make(chan string, 10)
+	durable := NewDurableScheduler(s, store, DurableSchedulerOptionWithStoreErrorHandler(func(key string, err error) {
+		handled <- key
+	}))
+	var runs int32
+	durable.RegisterJobType("count", func(ti *TaskItem) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	result, err := durable.Recover()
+	if err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if result.StoreErrors["tick"] == nil || len(result.Recovered) != 1 {
+		t.Fatalf("expected store error to be reported, got %+v", result)
+	}
+	select {
+	case key := <-handled:
+		if key != "tick" {
+			t.Fatalf("unexpected key %q", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected store error handler to be called after a run")
+	}
+
+	// 再次恢复时替换已经调度的任务,不会产生重复的计时器
+	durable.Recover()
+	atomic.StoreInt32(&runs, 0)
+	time.Sleep(110 * time.Millisecond)
+	if n := atomic.LoadInt32(&runs); n > 6 {
+		t.Fatalf("expected a single timer after recovering twice, got %d runs", n)
+	}
+
+	if err := durable.Remove("tick"); err == nil || len(durable.Jobs()) != 1 {
+		t.Fatalf("expected failed delete to keep the job, err=%v jobs=%+v", err, durable.Jobs())
+	}
+}
+
+func TestDurableSchedulerRecoverLongDowntime(t *testing.T) {
+	store := NewMemoryJobStore()
+	store.Save(JobRecord{Key: "fast", Type: "noop", ScheduleKind: ScheduleKindInterval, Schedule: "1s",
+		NextFireTime: time.Now().Add(-30 * 24 * time.Hour), MisfirePolicy: MisfireSkip})
+
+	s := NewTaskScheduler()
+	defer s.Stop()
+	durable := NewDurableScheduler(s, store)
+	durable.RegisterJobType("noop", func(ti *TaskItem) error { return nil })
+
+	start := time.Now()
+	result, err := durable.Recover()
+	if err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if result.Misfired["fast"] != maxMisfireRuns || time.Since(start) > time.Second {
+		t.Fatalf("expected capped misfire count without scanning the whole downtime, got %+v in %s", result.Misfired, time.Since(start))
+	}
+	records, _ := store.Load()
+	if len(records) != 1 || !records[0].NextFireTime.After(time.Now()) {
+		t.Fatalf("expected next fire time after now, got %+v", records)
+	}
+}
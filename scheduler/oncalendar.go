@@ -0,0 +1,356 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// 超过这个年数仍然找不到匹配的时间时认为没有下一次触发
+	calendarSearchYears = 100
+)
+
+var (
+	calendarShorthands = map[string]string{
+		"minutely":     "*-*-* *:*:00",
+		"hourly":       "*-*-* *:00:00",
+		"daily":        "*-*-* 00:00:00",
+		"weekly":       "Mon *-*-* 00:00:00",
+		"monthly":      "*-*-01 00:00:00",
+		"quarterly":    "*-01,04,07,10-01 00:00:00",
+		"semiannually": "*-01,07-01 00:00:00",
+		"yearly":       "*-01-01 00:00:00",
+		"annually":     "*-01-01 00:00:00",
+	}
+	calendarWeekdayNames = map[string]time.Weekday{
+		"sun": time.Sunday, "sunday": time.Sunday,
+		"mon": time.Monday, "monday": time.Monday,
+		"tue": time.Tuesday, "tuesday": time.Tuesday,
+		"wed": time.Wednesday, "wednesday": time.Wednesday,
+		"thu": time.Thursday, "thursday": time.Thursday,
+		"fri": time.Friday, "friday": time.Friday,
+		"sat": time.Saturday, "saturday": time.Saturday,
+	}
+)
+
+// 日历表达式中一个字段的取值,如"1..5"、"0/15"、"*/2"
+type calendarRange struct {
+	start, end, step int
+}
+
+// 日历表达式中的一个字段,为空时匹配所有值
+type calendarComponent struct {
+	ranges []calendarRange
+	// 日期字段中以~表示的从月末开始倒数的日期
+	fromEnd bool
+}
+
+func (c calendarComponent) matches(v int) bool {
+	if len(c.ranges) <= 0 {
+		return true
+	}
+	for _, eachRange := range c.ranges {
+		if v < eachRange.start || v > eachRange.end {
+			continue
+		}
+		if (v-eachRange.start)%eachRange.step == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+var _ Schedule = (*CalendarSpec)(nil)
+
+// systemd OnCalendar=格式的日历表达式,格式为"[星期] [年-月-日] [时:分[:秒]] [时区]"
+// 如"Mon..Fri *-*-* 09:00:00"、"*:0/15"、"*-02~01 12:00"(2月最后一天)与"quarterly"等简写
+// 省略日期时为每天,省略时间时为00:00:00,省略时区时使用time.Local
+type CalendarSpec struct {
+	expr string
+
+	weekdays map[time.Weekday]struct{}
+	year     calendarComponent
+	month    calendarComponent
+	day      calendarComponent
+	hour     calendarComponent
+	minute   calendarComponent
+	second   calendarComponent
+	location *time.Location
+}
+
+// 解析systemd OnCalendar=格式的日历表达式
+func ParseOnCalendar(expr string) (*CalendarSpec, error) {
+	spec := &CalendarSpec{
+		expr:     expr,
+		location: time.Local,
+	}
+	fields := strings.Fields(expr)
+	if len(fields) <= 0 {
+		return nil, fmt.Errorf("oncalendar: empty expression")
+	}
+
+	// 最后一个字段可以是时区
+	if last := fields[len(fields)-1]; len(fields) > 1 && isCalendarTimeZone(last) {
+		location, err := time.LoadLocation(last)
+		if err != nil {
+			return nil, fmt.Errorf("oncalendar: invalid time zone %q: %w", last, err)
+		}
+		spec.location = location
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) == 1 {
+		if shorthand, ok := calendarShorthands[strings.ToLower(fields[0])]; ok {
+			fields = strings.Fields(shorthand)
+		}
+	}
+
+	dateSet, timeSet := false, false
+	for i, eachField := range fields {
+		var err error
+		switch {
+		case strings.Contains(eachField, ":"):
+			if timeSet {
+				return nil, fmt.Errorf("oncalendar: duplicate time in %q", expr)
+			}
+			timeSet = true
+			err = spec.parseTime(eachField)
+		case i == 0 && isCalendarWeekdayField(eachField):
+			err = spec.parseWeekdays(eachField)
+		case strings.Contains(eachField, "-") || strings.Contains(eachField, "~"):
+			if dateSet {
+				return nil, fmt.Errorf("oncalendar: duplicate date in %q", expr)
+			}
+			dateSet = true
+			err = spec.parseDate(eachField)
+		default:
+			err = fmt.Errorf("oncalendar: unexpected %q in %q", eachField, expr)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !timeSet {
+		// 省略时间时为00:00:00
+		spec.hour = calendarComponent{ranges: []calendarRange{{0, 0, 1}}}
+		spec.minute = spec.hour
+		spec.second = spec.hour
+	}
+	return spec, nil
+}
+
+func isCalendarTimeZone(field string) bool {
+	if strings.EqualFold(field, "UTC") {
+		return true
+	}
+	return strings.Contains(field, "/") && len(field) > 0 && (field[0] < '0' || field[0] > '9') && field[0] != '*'
+}
+
+func isCalendarWeekdayField(field string) bool {
+	for _, eachPart := range strings.FieldsFunc(field, func(r rune) bool { return r == ',' || r == '.' }) {
+		if _, ok := calendarWeekdayNames[strings.ToLower(eachPart)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// 解析"Mon..Fri"、"Sat,Sun"格式的星期
+func (s *CalendarSpec) parseWeekdays(field string) error {
+	s.weekdays = make(map[time.Weekday]struct{})
+	for _, eachPart := range strings.Split(field, ",") {
+		from, to, isRange := strings.Cut(eachPart, "..")
+		start, ok := calendarWeekdayNames[strings.ToLower(from)]
+		if !ok {
+			return fmt.Errorf("oncalendar: invalid weekday %q", eachPart)
+		}
+		end := start
+		if isRange {
+			if end, ok = calendarWeekdayNames[strings.ToLower(to)]; !ok {
+				return fmt.Errorf("oncalendar: invalid weekday %q", eachPart)
+			}
+		}
+		// Sat..Mon这样跨越周日的范围同样有效
+		for d := start; ; d = (d + 1) % 7 {
+			s.weekdays[d] = struct{}{}
+			if d == end {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// 解析"年-月-日"或"月-日"格式的日期,日可以用~表示从月末开始倒数
+func (s *CalendarSpec) parseDate(field string) error {
+	fromEnd := false
+	if i := strings.Index(field, "~"); i >= 0 {
+		fromEnd = true
+		field = field[:i] + "-" + field[i+1:]
+	}
+	parts := strings.Split(field, "-")
+	if len(parts) == 2 {
+		parts = append([]string{"*"}, parts...)
+	}
+	if len(parts) != 3 {
+		return fmt.Errorf("oncalendar: invalid date %q", field)
+	}
+	var err error
+	if s.year, err = parseCalendarComponent(parts[0], "year", 1970, math.MaxInt32); err != nil {
+		return err
+	}
+	if s.month, err = parseCalendarComponent(parts[1], "month", 1, 12); err != nil {
+		return err
+	}
+	if s.day, err = parseCalendarComponent(parts[2], "day", 1, 31); err != nil {
+		return err
+	}
+	s.day.fromEnd = fromEnd
+	return nil
+}
+
+// 解析"时:分[:秒]"格式的时间,省略秒时为0
+func (s *CalendarSpec) parseTime(field string) error {
+	parts := strings.Split(field, ":")
+	if len(parts) == 2 {
+		parts = append(parts, "00")
+	}
+	if len(parts) != 3 {
+		return fmt.Errorf("oncalendar: invalid time %q", field)
+	}
+	var err error
+	if s.hour, err = parseCalendarComponent(parts[0], "hour", 0, 23); err != nil {
+		return err
+	}
+	if s.minute, err = parseCalendarComponent(parts[1], "minute", 0, 59); err != nil {
+		return err
+	}
+	if s.second, err = parseCalendarComponent(parts[2], "second", 0, 59); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 解析"*"、"5"、"1,15"、"1..5"、"0/15"、"*/2"、"1..10/3"
+func parseCalendarComponent(expr string, name string, min, max int) (calendarComponent, error) {
+	var component calendarComponent
+	if expr == "*" {
+		return component, nil
+	}
+	for _, eachPart := range strings.Split(expr, ",") {
+		r := calendarRange{start: min, end: max, step: 1}
+		rangeExpr, stepExpr, hasStep := strings.Cut(eachPart, "/")
+		if hasStep {
+			step, err := strconv.Atoi(stepExpr)
+			if err != nil || step <= 0 {
+				return component, fmt.Errorf("oncalendar: invalid %s repetition %q", name, eachPart)
+			}
+			r.step = step
+		}
+		if rangeExpr != "*" {
+			from, to, isRange := strings.Cut(rangeExpr, "..")
+			start, err := parseCalendarValue(from, name, min, max)
+			if err != nil {
+				return component, err
+			}
+			r.start = start
+			switch {
+			case isRange:
+				if r.end, err = parseCalendarValue(to, name, min, max); err != nil {
+					return component, err
+				}
+				if r.end < r.start {
+					return component, fmt.Errorf("oncalendar: invalid %s range %q", name, eachPart)
+				}
+			case !hasStep:
+				r.end = start
+			}
+		}
+		component.ranges = append(component.ranges, r)
+	}
+	return component, nil
+}
+
+func parseCalendarValue(expr string, name string, min, max int) (int, error) {
+	v, err := strconv.Atoi(expr)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("oncalendar: invalid %s %q", name, expr)
+	}
+	return v, nil
+}
+
+func (s *CalendarSpec) String() string {
+	return "oncalendar " + s.expr
+}
+
+// 日历表达式使用的时区
+func (s *CalendarSpec) Location() *time.Location {
+	return s.location
+}
+
+// #region Schedule Members
+
+// 下一次到期的时间
+func (s *CalendarSpec) Next(t time.Time) time.Time {
+	origLocation := t.Location()
+	t = t.In(s.location)
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + calendarSearchYears
+
+	for t.Year() <= yearLimit {
+		loc := s.location
+		if !s.year.matches(t.Year()) {
+			t = time.Date(t.Year()+1, time.January, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.month.matches(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.hour.matches(t.Hour()) {
+			t = nextCalendarStep(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc), time.Hour)
+			continue
+		}
+		if !s.minute.matches(t.Minute()) {
+			t = nextCalendarStep(t, t.Truncate(time.Minute).Add(time.Minute), time.Minute)
+			continue
+		}
+		if !s.second.matches(t.Second()) {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t.In(origLocation)
+	}
+	return time.Time{}
+}
+
+// #endregion
+
+func (s *CalendarSpec) dayMatches(t time.Time) bool {
+	if len(s.weekdays) > 0 {
+		if _, ok := s.weekdays[t.Weekday()]; !ok {
+			return false
+		}
+	}
+	day := t.Day()
+	if s.day.fromEnd {
+		// ~1表示月末最后一天
+		daysInMonth := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		day = daysInMonth - day + 1
+	}
+	return s.day.matches(day)
+}
+
+// 夏令时切换时time.Date可能得到不晚于当前的时间,此时按照固定时长前进
+func nextCalendarStep(current, next time.Time, fallback time.Duration) time.Time {
+	if next.After(current) {
+		return next
+	}
+	return current.Truncate(fallback).Add(fallback)
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnCalendarNext(t *testing.T) {
+	// 2024-03-15是星期五
+	from := time.Date(2024, time.March, 15, 10, 7, 30, 0, time.UTC)
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"Mon..Fri *-*-* 09:00:00 UTC", time.Date(2024, time.March, 18, 9, 0, 0, 0, time.UTC)},
+		{"*:0/15 UTC", time.Date(2024, time.March, 15, 10, 15, 0, 0, time.UTC)},
+		{"quarterly UTC", time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"Sat,Sun 12:00 UTC", time.Date(2024, time.March, 16, 12, 0, 0, 0, time.UTC)},
+		{"*-02~01 UTC", time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		{"2025-01-01..07 UTC", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"*-*-* *:*:10,40 UTC", time.Date(2024, time.March, 15, 10, 7, 40, 0, time.UTC)},
+		{"*-*-* 06:00 Asia/Shanghai", time.Date(2024, time.March, 15, 22, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		spec, err := ParseOnCalendar(c.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", c.expr, err)
+		}
+		if got := spec.Next(from); !got.Equal(c.want) {
+			t.Fatalf("%q: expected %v, got %v", c.expr, c.want, got)
+		}
+	}
+
+	spec, _ := ParseOnCalendar("2020-01-01 UTC")
+	if got := spec.Next(from); !got.IsZero() {
+		t.Fatalf("expected past calendar to have no next elapse, got %v", got)
+	}
+}
+
+func TestOnCalendarInvalid(t *testing.T) {
+	for _, expr := range []string{"", "Mon..Funday", "*-13-01", "25:00", "*:0/0", "*-*-* 10:00 Nowhere/City", "10..5:00"} {
+		if _, err := ParseOnCalendar(expr); err == nil {
+			t.Fatalf("expected %q to be rejected", expr)
+		}
+	}
+}
+
+func TestRandomizedDelayKeepsBaseSchedule(t *testing.T) {
+	base, _ := ParseOnCalendar("*:*:00 UTC")
+	schedule := RandomizedDelay(base, 10*time.Second)
+	from := time.Date(2024, time.March, 15, 10, 7, 30, 0, time.UTC)
+	prev := from
+	for i := 1; i <= 5; i++ {
+		next := schedule.Next(prev)
+		want := time.Date(2024, time.March, 15, 10, 7+i, 0, 0, time.UTC)
+		if next.Before(want) || !next.Before(want.Add(10*time.Second)) {
+			t.Fatalf("expected %v within delay of %v", next, want)
+		}
+		// 与时间轮相同,以精确到毫秒的上一次触发时间调用
+		prev = next.Truncate(time.Millisecond)
+	}
+	if first, again := schedule.Next(from), schedule.Next(from); !first.Equal(again) {
+		t.Fatalf("expected Next to be stateless, got %v and %v", first, again)
+	}
+}
+
+func TestTimeIntervalSchedulerKeepsRandomizedDelayBase(t *testing.T) {
+	schedule := RandomizedDelay(Every(time.Hour), 10*time.Minute)
+	scheduler := &timeIntervalScheduler{schedule: schedule}
+	from := time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC)
+	prev := from
+	for i := 1; i <= 5; i++ {
+		next := scheduler.Next(prev)
+		want := from.Add(time.Duration(i) * time.Hour)
+		if next.Before(want) || !next.Before(want.Add(10*time.Minute)) {
+			t.Fatalf("expected %v within delay of %v", next, want)
+		}
+		prev = next.Truncate(time.Millisecond)
+	}
+}
+
+func TestPersistentCatchesUpMissedRun(t *testing.T) {
+	daily, _ := ParseOnCalendar("daily UTC")
+	now := time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC)
+
+	schedule := Persistent(daily, time.Date(2024, time.March, 13, 0, 0, 0, 0, time.UTC))
+	if got := schedule.Next(now); !got.After(now) || got.Sub(now) > time.Millisecond {
+		t.Fatalf("expected missed run to fire immediately after now, got %v", got)
+	}
+	if got := schedule.Next(now); !got.Equal(time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected normal schedule after catch-up, got %v", got)
+	}
+
+	schedule = Persistent(daily, time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC))
+	if got := schedule.Next(now); !got.Equal(time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected no catch-up when nothing was missed, got %v", got)
+	}
+}
@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// 周期任务的上一次回调还没有完成时,新一次触发的处理方式
+type OverlapPolicy string
+
+const (
+	// 不限制,回调可能并行执行,默认值
+	OverlapAllow OverlapPolicy = "allow"
+	// 正在执行的回调达到并发上限时跳过本次触发
+	OverlapSkip OverlapPolicy = "skip"
+	// 正在执行的回调达到并发上限时排队,有回调完成后立即执行,排队数超过上限的触发被跳过
+	OverlapQueue OverlapPolicy = "queue"
+	// 正在执行的回调达到并发上限时取消最早的一次并立即执行本次
+	// 取消通过TaskItem.Context通知,回调需要自行检查并退出
+	OverlapReplace OverlapPolicy = "replace"
+)
+
+// 周期任务计算下一次触发时间的方式
+type ScheduleMode string
+
+const (
+	// 固定频率,按照上一次计划的触发时间计算下一次,与回调的执行时间无关,默认值
+	ScheduleModeFixedRate ScheduleMode = "fixed_rate"
+	// 固定延迟,上一次回调完成后按照完成的时间计算下一次,回调不会并行执行,OverlapPolicy不起作用
+	ScheduleModeFixedDelay ScheduleMode = "fixed_delay"
+)
+
+type recurringOptions struct {
+	mode           ScheduleMode
+	overlapPolicy  OverlapPolicy
+	maxConcurrency int
+	maxQueue       int
+	completeOpts   []func(ITaskSchedulerObserver)
+}
+
+func newRecurringOptions(opts ...RecurringOption) *recurringOptions {
+	options := &recurringOptions{
+		mode:           ScheduleModeFixedRate,
+		overlapPolicy:  OverlapAllow,
+		maxConcurrency: 1,
+		maxQueue:       1,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+	return options
+}
+
+type RecurringOption func(o *recurringOptions)
+
+// 固定频率或者固定延迟,默认为ScheduleModeFixedRate
+func RecurringOptionWithMode(mode ScheduleMode) RecurringOption {
+	return func(o *recurringOptions) {
+		o.mode = mode
+	}
+}
+
+// 上一次回调还没有完成时的处理方式,默认为OverlapAllow
+func RecurringOptionWithOverlapPolicy(policy OverlapPolicy) RecurringOption {
+	return func(o *recurringOptions) {
+		o.overlapPolicy = policy
+	}
+}
+
+// 同时执行的回调数量上限,默认为1,OverlapAllow时不起作用
+func RecurringOptionWithMaxConcurrency(n int) RecurringOption {
+	return func(o *recurringOptions) {
+		if n > 0 {
+			o.maxConcurrency = n
+		}
+	}
+}
+
+// OverlapQueue时最多排队的触发数量,默认为1
+func RecurringOptionWithMaxQueue(n int) RecurringOption {
+	return func(o *recurringOptions) {
+		if n > 0 {
+			o.maxQueue = n
+		}
+	}
+}
+
+// 每次回调完成后的回调
+func RecurringOptionWithCompleteCallbacks(callbacks ...func(ITaskSchedulerObserver)) RecurringOption {
+	return func(o *recurringOptions) {
+		o.completeOpts = append(o.completeOpts, callbacks...)
+	}
+}
+
+// 一次正在执行的回调
+type overlapRun struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newOverlapRun() *overlapRun {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &overlapRun{ctx: ctx, cancel: cancel}
+}
+
+// 按照OverlapPolicy控制周期任务的回调是否执行
+type overlapGuard struct {
+	policy         OverlapPolicy
+	maxConcurrency int
+	maxQueue       int
+
+	lock    sync.Mutex
+	running []*overlapRun
+	// 当前排队的数量
+	queued int
+
+	skippedCount  int64
+	queuedCount   int64
+	replacedCount int64
+}
+
+func newOverlapGuard(options *recurringOptions) *overlapGuard {
+	return &overlapGuard{
+		policy:         options.overlapPolicy,
+		maxConcurrency: options.maxConcurrency,
+		maxQueue:       options.maxQueue,
+	}
+}
+
+// 一次触发是否立即执行,返回nil时被跳过或者进入了排队
+func (g *overlapGuard) acquire() *overlapRun {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.policy == OverlapAllow || len(g.running) < g.maxConcurrency {
+		return g.start()
+	}
+	switch g.policy {
+	case OverlapQueue:
+		if g.queued < g.maxQueue {
+			g.queued++
+			atomic.AddInt64(&g.queuedCount, 1)
+			return nil
+		}
+	case OverlapReplace:
+		oldest := g.running[0]
+		g.running = g.running[1:]
+		oldest.cancel()
+		atomic.AddInt64(&g.replacedCount, 1)
+		return g.start()
+	}
+	atomic.AddInt64(&g.skippedCount, 1)
+	return nil
+}
+
+// 回调完成,有排队的触发时返回下一次要执行的回调
+func (g *overlapGuard) release(run *overlapRun) *overlapRun {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	run.cancel()
+	for i, eachRun := range g.running {
+		if eachRun == run {
+			g.running = append(g.running[:i], g.running[i+1:]...)
+			break
+		}
+	}
+	if g.queued > 0 && len(g.running) < g.maxConcurrency {
+		g.queued--
+		return g.start()
+	}
+	return nil
+}
+
+// 调度项停止时取消所有正在执行的回调并丢弃排队的触发
+func (g *overlapGuard) stop() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for _, eachRun := range g.running {
+		eachRun.cancel()
+	}
+	g.queued = 0
+}
+
+func (g *overlapGuard) start() *overlapRun {
+	run := newOverlapRun()
+	g.running = append(g.running, run)
+	return run
+}
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOverlapGuardPolicies(t *testing.T) {
+	skip := newOverlapGuard(newRecurringOptions(RecurringOptionWithOverlapPolicy(OverlapSkip), RecurringOptionWithMaxConcurrency(2)))
+	first, second := skip.acquire(), skip.acquire()
+	if first == nil || second == nil || skip.acquire() != nil {
+		t.Fatal("expected third run to be skipped at concurrency 2")
+	}
+	if skip.release(first) != nil || skip.acquire() == nil || skip.skippedCount != 1 {
+		t.Fatalf("unexpected skip guard state, skipped=%d", skip.skippedCount)
+	}
+
+	queue := newOverlapGuard(newRecurringOptions(RecurringOptionWithOverlapPolicy(OverlapQueue)))
+	running := queue.acquire()
+	if queue.acquire() != nil || queue.acquire() != nil {
+		t.Fatal("expected runs to wait while one is running")
+	}
+	if queue.queuedCount != 1 || queue.skippedCount != 1 {
+		t.Fatalf("expected 1 queued and 1 skipped, got %d and %d", queue.queuedCount, queue.skippedCount)
+	}
+	next := queue.release(running)
+	if next == nil || queue.release(next) != nil {
+		t.Fatal("expected the queued run to start once")
+	}
+
+	replace := newOverlapGuard(newRecurringOptions(RecurringOptionWithOverlapPolicy(OverlapReplace)))
+	old := replace.acquire()
+	if replace.acquire() == nil || old.ctx.Err() == nil || replace.replacedCount != 1 {
+		t.Fatal("expected the running run to be cancelled and replaced")
+	}
+}
+
+func TestScheduleRecurringSkipsOverlappingRuns(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	var running, maxRunning int32
+	observer := s.ScheduleRecurring(Every(20*time.Millisecond), NewTaskItem(), func(ti *TaskItem) error {
+		current := atomic.AddInt32(&running, 1)
+		if current > atomic.LoadInt32(&maxRunning) {
+			atomic.StoreInt32(&maxRunning, current)
+		}
+		time.Sleep(70 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}, RecurringOptionWithOverlapPolicy(OverlapSkip))
+	time.Sleep(300 * time.Millisecond)
+	observer.Stop()
+
+	info := observer.Info()
+	if atomic.LoadInt32(&maxRunning) != 1 {
+		t.Fatalf("expected no overlapping runs, got %d", maxRunning)
+	}
+	if info.Skipped <= 0 {
+		t.Fatalf("expected skipped runs, got %+v", info)
+	}
+}
+
+func TestScheduleRecurringFixedDelay(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	var starts []time.Time
+	done := make(chan struct{})
+	observer := s.ScheduleRecurring(Every(30*time.Millisecond), NewTaskItem(), func(ti *TaskItem) error {
+		starts = append(starts, time.Now())
+		if len(starts) == 3 {
+			close(done)
+		}
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}, RecurringOptionWithMode(ScheduleModeFixedDelay))
+	if observer.Info().Kind != TaskKindFixedDelay || observer.Info().Schedule != "every 30ms after completion" {
+		t.Fatalf("unexpected info %+v", observer.Info())
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected three runs")
+	}
+	observer.Stop()
+	for i := 1; i < 3; i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < 75*time.Millisecond {
+			t.Fatalf("expected next run to wait for completion plus delay, gap %s", gap)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package scheduler
+
+// 恢复被暂停的调度项时,暂停期间到期的触发的处理方式
+type ResumePolicy string
+
+const (
+	// 不补触发,等待下一次触发时间,默认值
+	// 上一次回调完成后才开始计时的调度项从恢复的时间重新计时,只执行一次的调度项仍然会在恢复时执行
+	ResumeWaitNextSlot ResumePolicy = "wait_next_slot"
+	// 暂停期间有到期的触发时,恢复后立即执行一次
+	ResumeFireImmediately ResumePolicy = "fire_immediately"
+)
+
+// 处理暂停期间到期的触发,调度项仍然处于暂停状态时不处理
+func (s *taskScheduler) resumeObserver(observer *taskSchedulerObserver, policy ResumePolicy) {
+	run := observer.takeResumeRun()
+	if run == nil {
+		return
+	}
+	kind, _, _ := observer.scheduleState()
+	switch {
+	case policy == ResumeFireImmediately || kind == TaskKindAfter:
+		go run()
+	case observer.rearm != nil:
+		observer.rearm()
+	}
+}
@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPauseResumeRecurringTask(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	var count int32
+	taskItem := NewTaskItem()
+	taskItem.SetKey("report")
+	s.SchedulerFunc(50*time.Millisecond, taskItem, func(ti *TaskItem) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})
+	if !s.Pause("report") || s.Pause("missing") {
+		t.Fatal("expected Pause to report whether the key exists")
+	}
+	observer, _ := s.GetTask("report")
+	if !observer.IsPaused() || !observer.Info().Paused {
+		t.Fatal("expected observer to be paused")
+	}
+	time.Sleep(120 * time.Millisecond)
+	if n := atomic.LoadInt32(&count); n != 0 {
+		t.Fatalf("expected no runs while paused, got %d", n)
+	}
+
+	s.Resume("report", ResumeFireImmediately)
+	time.Sleep(10 * time.Millisecond)
+	if n := atomic.LoadInt32(&count); n != 1 || observer.IsPaused() {
+		t.Fatalf("expected one catch-up run after resume, got %d", n)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if atomic.LoadInt32(&count) < 2 {
+		t.Fatal("expected task to continue after resume")
+	}
+}
+
+func TestPauseAllKeepsOneByOneChain(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	var count int32
+	s.PauseAll()
+	taskItem := NewTaskItem()
+	taskItem.SetKey("poll")
+	s.SchedulerFuncOneByOne(20*time.Millisecond, taskItem, func(ti *TaskItem) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})
+	time.Sleep(60 * time.Millisecond)
+	// 单独恢复不能解除PauseAll
+	s.Resume("poll", ResumeFireImmediately)
+	time.Sleep(30 * time.Millisecond)
+	if n := atomic.LoadInt32(&count); n != 0 {
+		t.Fatalf("expected no runs while all tasks are paused, got %d", n)
+	}
+
+	s.ResumeAll(ResumeWaitNextSlot)
+	time.Sleep(10 * time.Millisecond)
+	if atomic.LoadInt32(&count) != 0 {
+		t.Fatal("expected to wait for the next slot after resume")
+	}
+	time.Sleep(80 * time.Millisecond)
+	if n := atomic.LoadInt32(&count); n < 2 {
+		t.Fatalf("expected the chain to restart after resume, got %d", n)
+	}
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultRetryInitialBackoff = time.Second
+	defaultRetryMultiplier     = 2
+)
+
+// 回调返回错误时的重试策略,通过TaskItem.SetRetryPolicy为每个任务单独设置
+// 重试通过时间轮重新调度,回调中可以通过TaskItem.Attempt获取当前是第几次尝试
+type RetryPolicy struct {
+	// 最多尝试的次数,包括第一次执行,小于等于1时不重试
+	MaxAttempts int
+	// 第一次重试前的等待时间,为0时使用1秒
+	InitialBackoff time.Duration
+	// 等待时间的上限,为0时不限制
+	MaxBackoff time.Duration
+	// 每次重试后等待时间的倍数,小于1时使用2
+	Multiplier float64
+	// 随机抖动的比例,取值[0,1],等待时间在[d*(1-Jitter), d*(1+Jitter)]之间
+	Jitter float64
+	// 判断错误是否可以重试,为空时所有的错误都可以重试
+	Retryable func(err error) bool
+	// 最后一次尝试仍然失败或者错误不可重试时的回调
+	OnFinalFailure func(taskItem *TaskItem, err error)
+}
+
+// 第attempt次尝试失败后,到下一次尝试之前的等待时间,不包括随机抖动
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = defaultRetryInitialBackoff
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultRetryMultiplier
+	}
+	backoff := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	if backoff > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(backoff)
+}
+
+// 第attempt次尝试返回err后是否重试,以及重试前的等待时间
+func (p *RetryPolicy) nextRetry(attempt int, err error) (time.Duration, bool) {
+	if p == nil || err == nil || attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	if p.Retryable != nil && !p.Retryable(err) {
+		return 0, false
+	}
+	backoff := p.Backoff(attempt)
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		backoff = time.Duration(float64(backoff) * (1 + jitter*(2*rand.Float64()-1)))
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+	return backoff, true
+}
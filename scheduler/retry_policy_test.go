@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+	expected := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second}
+	for i, eachExpected := range expected {
+		if got := policy.Backoff(i + 1); got != eachExpected {
+			t.Fatalf("attempt %d: expected %s, got %s", i+1, eachExpected, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	policy.MaxAttempts = 5
+	for i := 0; i < 100; i++ {
+		backoff, ok := policy.nextRetry(1, errors.New("failed"))
+		if !ok || backoff < 50*time.Millisecond || backoff > 150*time.Millisecond {
+			t.Fatalf("unexpected jittered backoff %s", backoff)
+		}
+	}
+	if _, ok := policy.nextRetry(5, errors.New("failed")); ok {
+		t.Fatal("expected no retry after the last attempt")
+	}
+}
+
+func TestAfterFuncRetriesUntilSuccess(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	var lock sync.Mutex
+	var attempts []int
+	completed := make(chan struct{})
+	taskItem := NewTaskItem()
+	taskItem.SetRetryPolicy(&RetryPolicy{MaxAttempts: 5, InitialBackoff: 10 * time.Millisecond})
+	s.AfterFunc(0, taskItem, func(ti *TaskItem) error {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts = append(attempts, ti.Attempt())
+		if ti.Attempt() < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	}, func(ITaskSchedulerObserver) {
+		close(completed)
+	})
+
+	select {
+	case <-completed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected task to complete after retries")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if len(attempts) != 3 || attempts[0] != 1 || attempts[2] != 3 {
+		t.Fatalf("unexpected attempts %v", attempts)
+	}
+}
+
+func TestRetryPolicyFinalFailure(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	permanent := errors.New("permanent")
+	finalErr := make(chan error, 1)
+	taskItem := NewTaskItem()
+	taskItem.SetRetryPolicy(&RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 10 * time.Millisecond,
+		Retryable:      func(err error) bool { return !errors.Is(err, permanent) },
+		OnFinalFailure: func(ti *TaskItem, err error) {
+			if ti.Attempt() != 2 {
+				t.Errorf("expected final failure on attempt 2, got %d", ti.Attempt())
+			}
+			finalErr <- err
+		},
+	})
+	s.AfterFunc(0, taskItem, func(ti *TaskItem) error {
+		if ti.Attempt() == 1 {
+			return errors.New("temporary")
+		}
+		return permanent
+	})
+
+	select {
+	case err := <-finalErr:
+		if !errors.Is(err, permanent) {
+			t.Fatalf("unexpected final error %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected final failure callback")
+	}
+}
@@ -0,0 +1,565 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RRULE的重复频率
+type rruleFrequency int
+
+const (
+	rruleSecondly rruleFrequency = iota
+	rruleMinutely
+	rruleHourly
+	rruleDaily
+	rruleWeekly
+	rruleMonthly
+	rruleYearly
+)
+
+var (
+	rruleFrequencyNames = map[string]rruleFrequency{
+		"SECONDLY": rruleSecondly,
+		"MINUTELY": rruleMinutely,
+		"HOURLY":   rruleHourly,
+		"DAILY":    rruleDaily,
+		"WEEKLY":   rruleWeekly,
+		"MONTHLY":  rruleMonthly,
+		"YEARLY":   rruleYearly,
+	}
+	rruleWeekdayNames = map[string]time.Weekday{
+		"SU": time.Sunday,
+		"MO": time.Monday,
+		"TU": time.Tuesday,
+		"WE": time.Wednesday,
+		"TH": time.Thursday,
+		"FR": time.Friday,
+		"SA": time.Saturday,
+	}
+)
+
+const (
+	// 超过这个年数仍然找不到下一次触发时认为没有下一次触发
+	rruleSearchYears = 5
+)
+
+// BYDAY中的一项,如MO、1MO、-1FR
+type rruleWeekday struct {
+	weekday time.Weekday
+	// 第几个,负数表示倒数第几个,0表示所有
+	n int
+}
+
+var _ Schedule = (*RRule)(nil)
+
+// RFC 5545中RRULE描述的重复规则
+// 支持FREQ、INTERVAL、COUNT、UNTIL、BYDAY、BYMONTHDAY、BYMONTH与WKST,
+// 每次触发的时分秒与dtstart相同,EXDATE中的时间不会触发但仍然计入COUNT
+type RRule struct {
+	rule    string
+	dtstart time.Time
+
+	freq       rruleFrequency
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []rruleWeekday
+	byMonthDay []int
+	byMonth    []time.Month
+	wkst       time.Weekday
+	exDates    map[int64]struct{}
+}
+
+// 解析RRULE,如"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10",可以带有"RRULE:"前缀
+// dtstart为第一次触发的时间,同时决定每次触发的时分秒与没有时区的UNTIL所使用的时区
+func ParseRRule(rule string, dtstart time.Time, exDates ...time.Time) (*RRule, error) {
+	rule = strings.TrimSpace(rule)
+	rule = strings.TrimPrefix(rule, "RRULE:")
+	if dtstart.IsZero() {
+		return nil, fmt.Errorf("rrule: dtstart is required")
+	}
+	r := &RRule{
+		rule:     rule,
+		dtstart:  dtstart.Truncate(time.Second),
+		freq:     -1,
+		interval: 1,
+		wkst:     time.Monday,
+		exDates:  make(map[int64]struct{}, len(exDates)),
+	}
+	for _, eachExDate := range exDates {
+		r.exDates[eachExDate.Unix()] = struct{}{}
+	}
+
+	for _, eachPart := range strings.Split(rule, ";") {
+		if len(eachPart) <= 0 {
+			continue
+		}
+		name, value, ok := strings.Cut(eachPart, "=")
+		if !ok {
+			return nil, fmt.Errorf("rrule: invalid part %q", eachPart)
+		}
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			freq, ok := rruleFrequencyNames[strings.ToUpper(value)]
+			if !ok {
+				return nil, fmt.Errorf("rrule: unsupported FREQ %q", value)
+			}
+			r.freq = freq
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(value)
+			if err != nil || r.interval <= 0 {
+				return nil, fmt.Errorf("rrule: invalid INTERVAL %q", value)
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(value)
+			if err != nil || r.count <= 0 {
+				return nil, fmt.Errorf("rrule: invalid COUNT %q", value)
+			}
+		case "UNTIL":
+			r.until, _, err = parseICalTime(value, nil, r.dtstart.Location())
+			if err != nil {
+				return nil, fmt.Errorf("rrule: invalid UNTIL %q: %w", value, err)
+			}
+		case "BYDAY":
+			for _, eachDay := range strings.Split(value, ",") {
+				day, err := parseRRuleWeekday(eachDay)
+				if err != nil {
+					return nil, err
+				}
+				r.byDay = append(r.byDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, eachDay := range strings.Split(value, ",") {
+				day, err := strconv.Atoi(eachDay)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return nil, fmt.Errorf("rrule: invalid BYMONTHDAY %q", eachDay)
+				}
+				r.byMonthDay = append(r.byMonthDay, day)
+			}
+		case "BYMONTH":
+			for _, eachMonth := range strings.Split(value, ",") {
+				month, err := strconv.Atoi(eachMonth)
+				if err != nil || month < 1 || month > 12 {
+					return nil, fmt.Errorf("rrule: invalid BYMONTH %q", eachMonth)
+				}
+				r.byMonth = append(r.byMonth, time.Month(month))
+			}
+		case "WKST":
+			wkst, ok := rruleWeekdayNames[strings.ToUpper(value)]
+			if !ok {
+				return nil, fmt.Errorf("rrule: invalid WKST %q", value)
+			}
+			r.wkst = wkst
+		default:
+			return nil, fmt.Errorf("rrule: unsupported part %q", name)
+		}
+	}
+
+	if r.freq < 0 {
+		return nil, fmt.Errorf("rrule: FREQ is required in %q", rule)
+	}
+	if r.count > 0 && !r.until.IsZero() {
+		return nil, fmt.Errorf("rrule: COUNT and UNTIL must not both be set in %q", rule)
+	}
+	if r.freq != rruleMonthly && r.freq != rruleYearly {
+		for _, eachDay := range r.byDay {
+			if eachDay.n != 0 {
+				return nil, fmt.Errorf("rrule: BYDAY with ordinal requires FREQ=MONTHLY or FREQ=YEARLY in %q", rule)
+			}
+		}
+	}
+	return r, nil
+}
+
+// 解析DTSTART、RRULE与EXDATE组成的多行重复规则,如
+//
+//	DTSTART;TZID=Asia/Shanghai:20240101T090000
+//	RRULE:FREQ=DAILY;COUNT=5
+//	EXDATE;TZID=Asia/Shanghai:20240103T090000
+//
+// 只有一行时作为RRULE解析,没有DTSTART时以当前时间作为第一次触发的时间
+// 没有时区的时间使用loc
+func ParseRecurrence(text string, loc *time.Location) (*RRule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	var dtstart time.Time
+	var rule string
+	var exDates []time.Time
+	for _, eachLine := range unfoldICalLines(text) {
+		if !strings.Contains(eachLine, ":") {
+			// 没有属性名时作为RRULE的值
+			rule = eachLine
+			continue
+		}
+		prop, err := parseICalContentLine(eachLine)
+		if err != nil {
+			return nil, err
+		}
+		switch prop.name {
+		case "DTSTART":
+			dtstart, _, err = parseICalTime(prop.value, prop.params, loc)
+			if err != nil {
+				return nil, fmt.Errorf("rrule: invalid DTSTART %q: %w", prop.value, err)
+			}
+		case "RRULE":
+			rule = prop.value
+		case "EXDATE":
+			times, err := parseICalTimeList(prop.value, prop.params, loc)
+			if err != nil {
+				return nil, fmt.Errorf("rrule: invalid EXDATE %q: %w", prop.value, err)
+			}
+			exDates = append(exDates, times...)
+		default:
+			return nil, fmt.Errorf("rrule: unsupported property %q", prop.name)
+		}
+	}
+	if len(rule) <= 0 {
+		return nil, fmt.Errorf("rrule: missing RRULE in %q", text)
+	}
+	if dtstart.IsZero() {
+		dtstart = time.Now().In(loc)
+	}
+	return ParseRRule(rule, dtstart, exDates...)
+}
+
+func parseRRuleWeekday(value string) (rruleWeekday, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return rruleWeekday{}, fmt.Errorf("rrule: invalid BYDAY %q", value)
+	}
+	weekday, ok := rruleWeekdayNames[value[len(value)-2:]]
+	if !ok {
+		return rruleWeekday{}, fmt.Errorf("rrule: invalid BYDAY %q", value)
+	}
+	result := rruleWeekday{weekday: weekday}
+	if len(value) > 2 {
+		n, err := strconv.Atoi(value[:len(value)-2])
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return rruleWeekday{}, fmt.Errorf("rrule: invalid BYDAY %q", value)
+		}
+		result.n = n
+	}
+	return result, nil
+}
+
+func (r *RRule) String() string {
+	return "rrule " + r.rule
+}
+
+// 第一次触发的时间
+func (r *RRule) DTStart() time.Time {
+	return r.dtstart
+}
+
+// #region Schedule Members
+
+func (r *RRule) Next(t time.Time) time.Time {
+	if !r.until.IsZero() && !t.Before(r.until) {
+		return time.Time{}
+	}
+	origLocation := t.Location()
+	t = t.In(r.dtstart.Location())
+
+	k := 0
+	if r.count <= 0 && t.After(r.dtstart) {
+		// 没有COUNT时不需要从头计数,直接跳到t所在的周期
+		k = r.periodIndex(t) - 1
+		if k < 0 {
+			k = 0
+		}
+	}
+	generated := 0
+	limit := t.AddDate(rruleSearchYears, 0, 0)
+	for ; ; k++ {
+		start := r.periodStart(k)
+		if start.After(limit) {
+			return time.Time{}
+		}
+		if !r.until.IsZero() && start.After(r.until) {
+			return time.Time{}
+		}
+		for _, eachTime := range r.expand(start) {
+			if eachTime.Before(r.dtstart) {
+				continue
+			}
+			if !r.until.IsZero() && eachTime.After(r.until) {
+				return time.Time{}
+			}
+			if r.count > 0 {
+				generated++
+				if generated > r.count {
+					return time.Time{}
+				}
+			}
+			if !eachTime.After(t) {
+				continue
+			}
+			if _, ok := r.exDates[eachTime.Unix()]; ok {
+				continue
+			}
+			return eachTime.In(origLocation)
+		}
+	}
+}
+
+// #endregion
+
+// t所在的周期序号
+func (r *RRule) periodIndex(t time.Time) int {
+	switch r.freq {
+	case rruleSecondly:
+		return int(t.Sub(r.dtstart) / (time.Duration(r.interval) * time.Second))
+	case rruleMinutely:
+		return int(t.Sub(r.dtstart) / (time.Duration(r.interval) * time.Minute))
+	case rruleHourly:
+		return int(t.Sub(r.dtstart) / (time.Duration(r.interval) * time.Hour))
+	case rruleDaily:
+		return civilDaysBetween(r.dtstart, t) / r.interval
+	case rruleWeekly:
+		return civilDaysBetween(r.weekStart(r.dtstart), t) / 7 / r.interval
+	case rruleMonthly:
+		return ((t.Year()-r.dtstart.Year())*12 + int(t.Month()-r.dtstart.Month())) / r.interval
+	default:
+		return (t.Year() - r.dtstart.Year()) / r.interval
+	}
+}
+
+// 第k个周期的开始时间
+func (r *RRule) periodStart(k int) time.Time {
+	d := r.dtstart
+	n := k * r.interval
+	switch r.freq {
+	case rruleSecondly:
+		return d.Add(time.Duration(n) * time.Second)
+	case rruleMinutely:
+		return d.Add(time.Duration(n) * time.Minute)
+	case rruleHourly:
+		return d.Add(time.Duration(n) * time.Hour)
+	case rruleDaily:
+		return time.Date(d.Year(), d.Month(), d.Day()+n, d.Hour(), d.Minute(), d.Second(), 0, d.Location())
+	case rruleWeekly:
+		w := r.weekStart(d)
+		return time.Date(w.Year(), w.Month(), w.Day()+7*n, 0, 0, 0, 0, d.Location())
+	case rruleMonthly:
+		return time.Date(d.Year(), d.Month()+time.Month(n), 1, 0, 0, 0, 0, d.Location())
+	default:
+		return time.Date(d.Year()+n, time.January, 1, 0, 0, 0, 0, d.Location())
+	}
+}
+
+// 周期内按时间排序的所有触发时间
+func (r *RRule) expand(start time.Time) []time.Time {
+	switch r.freq {
+	case rruleWeekly:
+		result := make([]time.Time, 0, 7)
+		for i := 0; i < 7; i++ {
+			day := time.Date(start.Year(), start.Month(), start.Day()+i, 0, 0, 0, 0, start.Location())
+			if len(r.byDay) > 0 {
+				if !r.matchesWeekday(day.Weekday()) {
+					continue
+				}
+			} else if day.Weekday() != r.dtstart.Weekday() {
+				continue
+			}
+			if !r.matchesMonth(day.Month()) || !r.matchesMonthDay(day) {
+				continue
+			}
+			result = append(result, r.at(day.Year(), day.Month(), day.Day()))
+		}
+		return result
+	case rruleMonthly:
+		if !r.matchesMonth(start.Month()) {
+			return nil
+		}
+		return r.expandMonth(start.Year(), start.Month())
+	case rruleYearly:
+		return r.expandYear(start.Year())
+	default:
+		if !r.matchesMonth(start.Month()) || !r.matchesMonthDay(start) {
+			return nil
+		}
+		if len(r.byDay) > 0 && !r.matchesWeekday(start.Weekday()) {
+			return nil
+		}
+		return []time.Time{start}
+	}
+}
+
+func (r *RRule) expandYear(year int) []time.Time {
+	if len(r.byMonth) > 0 {
+		months := append([]time.Month(nil), r.byMonth...)
+		sort.Slice(months, func(i, j int) bool { return months[i] < months[j] })
+		var result []time.Time
+		for _, eachMonth := range months {
+			result = append(result, r.expandMonth(year, eachMonth)...)
+		}
+		return result
+	}
+	if len(r.byDay) > 0 && len(r.byMonthDay) <= 0 {
+		// BYDAY中的序号相对于整年
+		first := time.Date(year, time.January, 1, 0, 0, 0, 0, r.dtstart.Location())
+		days := r.daysByWeekday(first, time.Date(year+1, time.January, 1, 0, 0, 0, 0, r.dtstart.Location()))
+		result := make([]time.Time, 0, len(days))
+		for _, eachDay := range days {
+			result = append(result, r.at(year, time.January, eachDay))
+		}
+		return result
+	}
+	if len(r.byMonthDay) > 0 {
+		var result []time.Time
+		for month := time.January; month <= time.December; month++ {
+			result = append(result, r.expandMonth(year, month)...)
+		}
+		return result
+	}
+	// 与dtstart同月同日,不存在的日期(如2月29日)跳过
+	day := r.at(year, r.dtstart.Month(), r.dtstart.Day())
+	if day.Month() != r.dtstart.Month() {
+		return nil
+	}
+	return []time.Time{day}
+}
+
+func (r *RRule) expandMonth(year int, month time.Month) []time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, r.dtstart.Location())
+	next := first.AddDate(0, 1, 0)
+	daysInMonth := next.AddDate(0, 0, -1).Day()
+
+	var days []int
+	switch {
+	case len(r.byMonthDay) > 0 && len(r.byDay) > 0:
+		byWeekday := make(map[int]struct{})
+		for _, eachDay := range r.daysByWeekday(first, next) {
+			byWeekday[eachDay] = struct{}{}
+		}
+		for _, eachDay := range resolveMonthDays(r.byMonthDay, daysInMonth) {
+			if _, ok := byWeekday[eachDay]; ok {
+				days = append(days, eachDay)
+			}
+		}
+	case len(r.byMonthDay) > 0:
+		days = resolveMonthDays(r.byMonthDay, daysInMonth)
+	case len(r.byDay) > 0:
+		days = r.daysByWeekday(first, next)
+	default:
+		if r.dtstart.Day() <= daysInMonth {
+			days = []int{r.dtstart.Day()}
+		}
+	}
+
+	result := make([]time.Time, 0, len(days))
+	for _, eachDay := range days {
+		result = append(result, r.at(year, month, eachDay))
+	}
+	return result
+}
+
+// [from,to)中符合BYDAY的日期,以相对from的日序号(从1开始)返回,已排序
+func (r *RRule) daysByWeekday(from, to time.Time) []int {
+	total := civilDaysBetween(from, to)
+	selected := make(map[int]struct{})
+	for _, eachDay := range r.byDay {
+		var matched []int
+		for i := 0; i < total; i++ {
+			if time.Weekday((int(from.Weekday())+i)%7) == eachDay.weekday {
+				matched = append(matched, i+1)
+			}
+		}
+		switch {
+		case eachDay.n == 0:
+			for _, each := range matched {
+				selected[each] = struct{}{}
+			}
+		case eachDay.n > 0 && eachDay.n <= len(matched):
+			selected[matched[eachDay.n-1]] = struct{}{}
+		case eachDay.n < 0 && -eachDay.n <= len(matched):
+			selected[matched[len(matched)+eachDay.n]] = struct{}{}
+		}
+	}
+	result := make([]int, 0, len(selected))
+	for each := range selected {
+		result = append(result, each)
+	}
+	sort.Ints(result)
+	return result
+}
+
+func (r *RRule) matchesWeekday(weekday time.Weekday) bool {
+	for _, eachDay := range r.byDay {
+		if eachDay.weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchesMonth(month time.Month) bool {
+	if len(r.byMonth) <= 0 {
+		return true
+	}
+	for _, eachMonth := range r.byMonth {
+		if eachMonth == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchesMonthDay(t time.Time) bool {
+	if len(r.byMonthDay) <= 0 {
+		return true
+	}
+	daysInMonth := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	for _, eachDay := range resolveMonthDays(r.byMonthDay, daysInMonth) {
+		if eachDay == t.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// 指定日期与dtstart相同的时分秒
+func (r *RRule) at(year int, month time.Month, day int) time.Time {
+	d := r.dtstart
+	return time.Date(year, month, day, d.Hour(), d.Minute(), d.Second(), 0, d.Location())
+}
+
+// t所在周的第一天(WKST)的0点
+func (r *RRule) weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) - int(r.wkst) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// 将BYMONTHDAY中的负数转换为正数,去掉当月不存在的日期并排序
+func resolveMonthDays(byMonthDay []int, daysInMonth int) []int {
+	seen := make(map[int]struct{}, len(byMonthDay))
+	result := make([]int, 0, len(byMonthDay))
+	for _, eachDay := range byMonthDay {
+		if eachDay < 0 {
+			eachDay = daysInMonth + eachDay + 1
+		}
+		if eachDay < 1 || eachDay > daysInMonth {
+			continue
+		}
+		if _, ok := seen[eachDay]; ok {
+			continue
+		}
+		seen[eachDay] = struct{}{}
+		result = append(result, eachDay)
+	}
+	sort.Ints(result)
+	return result
+}
+
+// 两个时间所在日期之间相差的天数,不受夏令时影响
+func civilDaysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a) / (24 * time.Hour))
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRRuleNext(t *testing.T) {
+	// 2024-01-01是星期一
+	dtstart := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	cases := []struct {
+		rule string
+		want []time.Time
+	}{
+		{"FREQ=DAILY;INTERVAL=2;COUNT=3", []time.Time{
+			dtstart,
+			time.Date(2024, time.January, 3, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, time.January, 5, 9, 0, 0, 0, time.UTC),
+		}},
+		{"RRULE:FREQ=WEEKLY;BYDAY=TU,TH;UNTIL=20240110T000000Z", []time.Time{
+			time.Date(2024, time.January, 2, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, time.January, 4, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, time.January, 9, 9, 0, 0, 0, time.UTC),
+		}},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", []time.Time{
+			time.Date(2024, time.January, 26, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, time.February, 23, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, time.March, 29, 9, 0, 0, 0, time.UTC),
+		}},
+		{"FREQ=MONTHLY;BYMONTHDAY=31,-1;COUNT=3", []time.Time{
+			time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC),
+		}},
+		{"FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;COUNT=2", []time.Time{
+			time.Date(2024, time.November, 28, 9, 0, 0, 0, time.UTC),
+			time.Date(2025, time.November, 27, 9, 0, 0, 0, time.UTC),
+		}},
+		{"FREQ=HOURLY;INTERVAL=12;COUNT=3", []time.Time{
+			dtstart,
+			time.Date(2024, time.January, 1, 21, 0, 0, 0, time.UTC),
+			time.Date(2024, time.January, 2, 9, 0, 0, 0, time.UTC),
+		}},
+	}
+	for _, c := range cases {
+		rule, err := ParseRRule(c.rule, dtstart)
+		if err != nil {
+			t.Fatalf("parse %q: %v", c.rule, err)
+		}
+		got := NextTimes(rule, dtstart.Add(-time.Second), 10)
+		if len(got) != len(c.want) {
+			t.Fatalf("%q: expected %v, got %v", c.rule, c.want, got)
+		}
+		for i := range c.want {
+			if !got[i].Equal(c.want[i]) {
+				t.Fatalf("%q: expected %v at %d, got %v", c.rule, c.want[i], i, got[i])
+			}
+		}
+	}
+}
+
+func TestRRuleExDateCountsTowardsCount(t *testing.T) {
+	rule, err := ParseRecurrence(strings.Join([]string{
+		"DTSTART;TZID=Asia/Shanghai:20240101T090000",
+		"RRULE:FREQ=DAILY;COUNT=3",
+		"EXDATE;TZID=Asia/Shanghai:20240102T090000",
+	}, "\n"), time.UTC)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	got := NextTimes(rule, rule.DTStart().Add(-time.Second), 10)
+	if len(got) != 2 || got[0].Day() != 1 || got[1].Day() != 3 {
+		t.Fatalf("expected Jan 1 and Jan 3, got %v", got)
+	}
+	if got[0].In(time.UTC).Hour() != 1 {
+		t.Fatalf("expected DTSTART to use TZID, got %v", got[0])
+	}
+}
+
+func TestRRuleSkipsAheadWithoutCount(t *testing.T) {
+	rule, err := ParseRRule("FREQ=MINUTELY;INTERVAL=15", time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	from := time.Date(2024, time.June, 1, 10, 7, 0, 0, time.UTC)
+	if got := rule.Next(from); !got.Equal(time.Date(2024, time.June, 1, 10, 15, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected next %v", got)
+	}
+}
+
+func TestParseRRuleInvalid(t *testing.T) {
+	dtstart := time.Now()
+	for _, rule := range []string{"", "INTERVAL=2", "FREQ=SOMETIMES", "FREQ=DAILY;COUNT=0", "FREQ=WEEKLY;BYDAY=1MO", "FREQ=DAILY;COUNT=2;UNTIL=20240101", "FREQ=DAILY;BYMONTHDAY=32"} {
+		if _, err := ParseRRule(rule, dtstart); err == nil {
+			t.Fatalf("expected %q to be rejected", rule)
+		}
+	}
+}
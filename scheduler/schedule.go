@@ -0,0 +1,242 @@
+package scheduler
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// 计算下一次触发时间的调度规则,与timingwheel.Scheduler的签名相同,可以直接用于ScheduleFunc
+type Schedule interface {
+	// 返回严格晚于t的下一次触发时间,没有下一次时返回零值
+	Next(t time.Time) time.Time
+}
+
+// 从from开始预览schedule接下来的n次触发时间,没有更多的触发时间时提前结束
+func NextTimes(schedule Schedule, from time.Time, n int) []time.Time {
+	result := make([]time.Time, 0, n)
+	next := from
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		result = append(result, next)
+	}
+	return result
+}
+
+// 调度规则的可读描述
+func describeSchedule(schedule Schedule) string {
+	if stringer, ok := schedule.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", schedule)
+}
+
+// 在schedule的每次触发时间上增加[0,maxDelay)之间的随机延迟,用于将同一时间到期的大量任务分散开,
+// 与systemd timer的RandomizedDelaySec=相同,下一次触发时间总是根据没有延迟的时间计算
+func RandomizedDelay(schedule Schedule, maxDelay time.Duration) Schedule {
+	if maxDelay <= 0 {
+		return schedule
+	}
+	return &randomizedDelaySchedule{
+		inner:    schedule,
+		maxDelay: maxDelay,
+		seed:     rand.Uint64(),
+	}
+}
+
+var _ Schedule = (*randomizedDelaySchedule)(nil)
+
+// randomizedDelaySchedule.Next最多检查的没有延迟的时间的数量
+const maxRandomizedDelayScan = 10000
+
+// Next没有状态:延迟由没有延迟的时间与seed确定,同一个实例中相同的时间总是得到相同的延迟
+type randomizedDelaySchedule struct {
+	inner    Schedule
+	maxDelay time.Duration
+	// 每个实例不同,使同一时间到期的不同任务得到不同的延迟
+	seed uint64
+}
+
+// 没有延迟的时间base对应的延迟,取值[0,maxDelay)
+func (s *randomizedDelaySchedule) delay(base time.Time) time.Duration {
+	// splitmix64
+	x := uint64(base.UnixNano()) ^ s.seed
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return time.Duration(x % uint64(s.maxDelay))
+}
+
+// base之后的下一次触发,返回没有延迟的时间与带有延迟的时间
+// 带有延迟的时间与时间轮相同精确到毫秒,以时间轮传回的上一次触发时间调用Next时不会再次得到同一个时间
+func (s *randomizedDelaySchedule) nextFromBase(base time.Time) (time.Time, time.Time) {
+	next := s.inner.Next(base)
+	if next.IsZero() {
+		return next, next
+	}
+	return next, next.Add(s.delay(next)).Truncate(time.Millisecond)
+}
+
+func (s *randomizedDelaySchedule) String() string {
+	return fmt.Sprintf("%s with randomized delay %s", describeSchedule(s.inner), s.maxDelay)
+}
+
+// #region Schedule Members
+
+func (s *randomizedDelaySchedule) Next(t time.Time) time.Time {
+	// 延迟小于maxDelay,t之后的第一次触发只可能来自t-maxDelay之后的时间;
+	// 延迟可能使触发的顺序与没有延迟的时间不同,需要找到带有延迟的时间中最早的一个
+	var result time.Time
+	base := t.Add(-s.maxDelay)
+	for i := 0; i < maxRandomizedDelayScan; i++ {
+		var delayed time.Time
+		base, delayed = s.nextFromBase(base)
+		if base.IsZero() || (!result.IsZero() && !base.Before(result)) {
+			break
+		}
+		if delayed.After(t) && (result.IsZero() || delayed.Before(result)) {
+			result = delayed
+		}
+	}
+	return result
+}
+
+// #endregion
+
+// 与systemd timer的Persistent=true相同:lastRun之后本应触发但因为进程没有运行而错过的那一次,
+// 在第一次计算触发时间时立即补上一次,之后按照schedule正常触发
+// lastRun通常来自持久化的TaskInfo.LastRunTime,为零值时不补触发
+func Persistent(schedule Schedule, lastRun time.Time) Schedule {
+	return &persistentSchedule{
+		inner:   schedule,
+		lastRun: lastRun,
+	}
+}
+
+var _ Schedule = (*persistentSchedule)(nil)
+
+type persistentSchedule struct {
+	inner   Schedule
+	lastRun time.Time
+
+	checked atomic.Bool
+}
+
+func (s *persistentSchedule) String() string {
+	return describeSchedule(s.inner) + " persistent"
+}
+
+// #region Schedule Members
+
+func (s *persistentSchedule) Next(t time.Time) time.Time {
+	if s.checked.CompareAndSwap(false, true) && !s.lastRun.IsZero() {
+		missed := s.inner.Next(s.lastRun)
+		if !missed.IsZero() && missed.Before(t) {
+			// 错过了一次,在时间轮的下一个刻度立即触发,Next的结果必须晚于t
+			return t.Add(time.Millisecond)
+		}
+	}
+	return s.inner.Next(t)
+}
+
+// #endregion
+
+// 调度规则的表示法,用于从字符串创建调度规则,如JobStore中保存的任务
+type ScheduleKind string
+
+const (
+	// Go的时长,如"1h30m",按照固定的间隔触发
+	ScheduleKindInterval ScheduleKind = "interval"
+	// RFC3339格式的时间,只触发一次
+	ScheduleKindOnce ScheduleKind = "once"
+	// cron表达式,见ParseCron
+	ScheduleKindCron ScheduleKind = "cron"
+	// systemd OnCalendar=格式的日历表达式,见ParseOnCalendar
+	ScheduleKindOnCalendar ScheduleKind = "oncalendar"
+	// RFC 5545的重复规则,见ParseRecurrence
+	ScheduleKindRRule ScheduleKind = "rrule"
+	// ISO 8601重复时间间隔,见ParseRepeatingInterval
+	ScheduleKindISO8601 ScheduleKind = "iso8601"
+)
+
+// 根据表示法解析调度规则
+func ParseScheduleSpec(kind ScheduleKind, spec string) (Schedule, error) {
+	switch kind {
+	case ScheduleKindInterval:
+		interval, err := time.ParseDuration(spec)
+		if err != nil {
+			return nil, err
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("schedule: interval must be positive, got %q", spec)
+		}
+		return &intervalSchedule{interval: interval}, nil
+	case ScheduleKindOnce:
+		at, err := time.Parse(time.RFC3339Nano, spec)
+		if err != nil {
+			return nil, err
+		}
+		return &onceSchedule{at: at}, nil
+	case ScheduleKindCron:
+		return ParseCron(spec)
+	case ScheduleKindOnCalendar:
+		return ParseOnCalendar(spec)
+	case ScheduleKindRRule:
+		return ParseRecurrence(spec, time.Local)
+	case ScheduleKindISO8601:
+		return ParseRepeatingInterval(spec)
+	}
+	return nil, fmt.Errorf("schedule: unknown schedule kind %q", kind)
+}
+
+var _ Schedule = (*intervalSchedule)(nil)
+
+// 按照固定间隔触发的调度规则,用于ScheduleRecurring
+func Every(interval time.Duration) Schedule {
+	return &intervalSchedule{interval: interval}
+}
+
+// 按照固定间隔触发的调度规则
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func (s *intervalSchedule) String() string {
+	return describeInterval(TaskKindInterval, s.interval)
+}
+
+// #region Schedule Members
+
+func (s *intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// #endregion
+
+// 第一次返回first,之后按照inner计算,用于从保存的下一次触发时间继续调度
+type resumeSchedule struct {
+	inner Schedule
+	first time.Time
+
+	resumed atomic.Bool
+}
+
+func (s *resumeSchedule) String() string {
+	return describeSchedule(s.inner)
+}
+
+// #region Schedule Members
+
+func (s *resumeSchedule) Next(t time.Time) time.Time {
+	if s.resumed.CompareAndSwap(false, true) && s.first.After(t) {
+		return s.first
+	}
+	return s.inner.Next(t)
+}
+
+// #endregion
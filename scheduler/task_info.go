@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// 调度项的类型
+type TaskKind string
+
+const (
+	// 通过AfterFunc调度,只执行一次
+	TaskKindAfter TaskKind = "after"
+	// 通过SchedulerFunc调度,按照固定的间隔执行,回调可能并行执行
+	TaskKindInterval TaskKind = "interval"
+	// 通过SchedulerFuncOneByOne调度,上一次回调完成后再开始计时
+	TaskKindOneByOne TaskKind = "one_by_one"
+	// 通过ScheduleFunc或ScheduleCron调度,由Schedule计算每次触发的时间
+	TaskKindSchedule TaskKind = "schedule"
+	// 通过ScheduleRecurring以ScheduleModeFixedDelay调度,上一次回调完成后由Schedule计算下一次触发的时间
+	TaskKindFixedDelay TaskKind = "fixed_delay"
+)
+
+// 调度项的快照,用于查询与调试
+type TaskInfo struct {
+	Key  string   `json:"key"`
+	Kind TaskKind `json:"kind"`
+	// 可读的调度描述,如"every 1s"
+	Schedule string        `json:"schedule"`
+	Interval time.Duration `json:"interval"`
+	// 下一次触发的时间,未知或已经停止时为零值
+	NextFireTime time.Time `json:"nextFireTime"`
+	// 已经执行回调的次数
+	RunCount int64 `json:"runCount"`
+	// 最后一次执行回调的时间
+	LastRunTime time.Time `json:"lastRunTime"`
+	Stopped     bool      `json:"stopped"`
+	Paused      bool      `json:"paused,omitempty"`
+	// 最后一次回调返回的错误
+	Error string `json:"error,omitempty"`
+	// 按照OverlapPolicy被跳过、排队与替换的触发次数
+	Skipped  int64 `json:"skipped,omitempty"`
+	Queued   int64 `json:"queued,omitempty"`
+	Replaced int64 `json:"replaced,omitempty"`
+}
+
+func describeInterval(kind TaskKind, interval time.Duration) string {
+	switch kind {
+	case TaskKindAfter:
+		return fmt.Sprintf("after %s", interval)
+	case TaskKindOneByOne:
+		return fmt.Sprintf("every %s after completion", interval)
+	}
+	return fmt.Sprintf("every %s", interval)
+}
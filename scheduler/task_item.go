@@ -1,6 +1,8 @@
 package scheduler
 
 import (
+	"context"
+
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -9,6 +11,12 @@ type TaskItem struct {
 	Value interface{}
 
 	Properties map[string]interface{}
+
+	retryPolicy *RetryPolicy
+	// 当前是第几次尝试,0与1都表示第一次
+	attempt int
+	// 当前这次执行的上下文
+	ctx context.Context
 }
 
 func NewTaskItem() *TaskItem {
@@ -23,6 +31,49 @@ func (i *TaskItem) GetKey() string {
 	return i.key
 }
 
+// 设置回调返回错误时的重试策略,为空时不重试
+func (i *TaskItem) SetRetryPolicy(policy *RetryPolicy) {
+	i.retryPolicy = policy
+}
+
+func (i *TaskItem) GetRetryPolicy() *RetryPolicy {
+	return i.retryPolicy
+}
+
+// 当前是第几次尝试执行回调,从1开始,重试时递增
+func (i *TaskItem) Attempt() int {
+	if i.attempt <= 0 {
+		return 1
+	}
+	return i.attempt
+}
+
+// 用于第attempt次尝试的副本
+func (i *TaskItem) withAttempt(attempt int) *TaskItem {
+	if attempt <= 1 {
+		return i
+	}
+	copied := *i
+	copied.attempt = attempt
+	return &copied
+}
+
+// 当前这次执行的上下文,调度项被停止或者按照OverlapReplace被替换时取消
+// 不是通过ScheduleRecurring调度时返回context.Background()
+func (i *TaskItem) Context() context.Context {
+	if i.ctx == nil {
+		return context.Background()
+	}
+	return i.ctx
+}
+
+// 用于一次执行的副本
+func (i *TaskItem) withContext(ctx context.Context) *TaskItem {
+	copied := *i
+	copied.ctx = ctx
+	return &copied
+}
+
 func (s *TaskItem) ensureHasKey() {
 	if len(s.key) > 0 {
 		return
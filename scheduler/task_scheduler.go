@@ -1,6 +1,10 @@
 package scheduler
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/abmpio/threadingx/collection"
@@ -8,6 +12,11 @@ import (
 	"github.com/abmpio/threadingx/timingwheel"
 )
 
+var (
+	// 调度规则在当前时间之后没有任何触发时间,如"0 0 30 2 *"
+	ErrScheduleNeverFires = errors.New("scheduler: schedule never fires")
+)
+
 const (
 	slots                    = 300
 	defaultTimeWheelInterval = time.Millisecond * 16
@@ -18,17 +27,74 @@ type ITaskScheduler interface {
 	AfterFunc(interval time.Duration, taskItem *TaskItem, callback func(*TaskItem) error, completeOpts ...func(ITaskSchedulerObserver)) ITaskSchedulerObserver
 
 	// 调度一个函数，此函数按照interval时间定期执行,这个定时器的回调是可能会存在着并行执行的
+	// 固定频率(fixed-rate),下一次触发时间与回调的执行时间无关
 	//返回用于此任务的调度key
 	SchedulerFunc(interval time.Duration, taskItem *TaskItem, callback func(*TaskItem) error, completeOpts ...func(ITaskSchedulerObserver)) ITaskSchedulerObserver
 
 	// 调度一个函数，此函数按照interval时间定期执行,这个定时器的回调是不会存在着并行执行的
-	// 下一个定时的触发机制是在这个回调执行完成后再开始计时
+	// 下一个定时的触发机制是在这个回调执行完成后再开始计时,即固定延迟(fixed-delay)
 	//返回用于此任务的调度key
 	SchedulerFuncOneByOne(interval time.Duration, taskItem *TaskItem, callback func(*TaskItem) error, completeOpts ...func(ITaskSchedulerObserver)) ITaskSchedulerObserver
 
+	// 调度一个函数,由schedule计算每一次的触发时间,回调可能并行执行
+	// schedule返回零值时调度结束
+	ScheduleFunc(schedule Schedule, taskItem *TaskItem, callback func(*TaskItem) error, completeOpts ...func(ITaskSchedulerObserver)) ITaskSchedulerObserver
+
+	// 调度一个周期任务,通过opts选择固定频率或者固定延迟,以及上一次回调还没有完成时的OverlapPolicy
+	// schedule返回零值时调度结束,被跳过与排队的次数可以通过Info获取
+	ScheduleRecurring(schedule Schedule, taskItem *TaskItem, callback func(*TaskItem) error, opts ...RecurringOption) ITaskSchedulerObserver
+
+	// 按照cron表达式调度一个函数,支持5个或6个字段、@every与@daily等描述符以及CRON_TZ前缀
+	// 表达式无效时返回错误,表达式永远不会触发时返回ErrScheduleNeverFires
+	ScheduleCron(spec string, taskItem *TaskItem, callback func(*TaskItem) error, completeOpts ...func(ITaskSchedulerObserver)) (ITaskSchedulerObserver, error)
+
+	// 按照systemd OnCalendar=格式的日历表达式调度一个函数,如"Mon..Fri *-*-* 09:00:00"、"*:0/15"、"quarterly"
+	// 表达式无效时返回错误,没有下一次触发时间时返回ErrScheduleNeverFires
+	ScheduleOnCalendar(expr string, taskItem *TaskItem, callback func(*TaskItem) error, completeOpts ...func(ITaskSchedulerObserver)) (ITaskSchedulerObserver, error)
+
+	// 按照ISO 8601重复时间间隔调度一个函数,如"R5/2026-01-01T00:00:00Z/PT1H"、"R/P1D"
+	// 调度项的TaskInfo.Schedule同样以ISO 8601的表示法返回,表达式无效时返回错误
+	// 所有的重复都已经过去时返回ErrScheduleNeverFires
+	ScheduleRepeatingInterval(spec string, taskItem *TaskItem, callback func(*TaskItem) error, completeOpts ...func(ITaskSchedulerObserver)) (ITaskSchedulerObserver, error)
+
+	// 按照RFC 5545的重复规则调度一个函数,rule可以是单独的RRULE,也可以是包含DTSTART与EXDATE的多行文本
+	// 规则无效时返回错误,规则已经结束时返回ErrScheduleNeverFires
+	ScheduleRRule(rule string, taskItem *TaskItem, callback func(*TaskItem) error, completeOpts ...func(ITaskSchedulerObserver)) (ITaskSchedulerObserver, error)
+
 	//停止指定的调度项,如果key不存在，则返回false
 	StopScheduler(key string) bool
 
+	// 修改调度项的间隔并从当前时间重新计时,保持key、observer与完成回调不变
+	// 只执行一次的调度项已经执行、调度项已经停止或者key不存在时返回false
+	Reschedule(key string, interval time.Duration) bool
+
+	// 修改调度项的调度规则并从当前时间重新计时,保持key、observer与完成回调不变
+	// SchedulerFuncOneByOne调度的调度项仍然在上一次回调完成后才开始计时
+	RescheduleWith(key string, schedule Schedule) bool
+
+	// 不修改调度规则,从当前时间重新计时
+	ResetTimer(key string) bool
+
+	// 暂停指定的调度项,暂停期间到期的触发不执行回调,调度项的配置保持不变
+	// 如果key不存在，则返回false
+	Pause(key string) bool
+
+	// 恢复指定的调度项,按照policy处理暂停期间到期的触发
+	// PauseAll之后单独恢复的调度项仍然处于暂停状态,如果key不存在，则返回false
+	Resume(key string, policy ResumePolicy) bool
+
+	// 暂停所有的调度项,包括之后新增的调度项
+	PauseAll()
+
+	// 恢复所有的调度项,包括通过Pause单独暂停的调度项
+	ResumeAll(policy ResumePolicy)
+
+	// 根据key获取调度项
+	GetTask(key string) (ITaskSchedulerObserver, bool)
+
+	// 获取所有调度项的快照,按下一次触发时间排序
+	Tasks() []TaskInfo
+
 	// stop scheduler, this will stop all scheduler
 	Stop()
 }
@@ -37,6 +103,8 @@ type taskScheduler struct {
 	timingWheel *timingwheel.TimingWheel
 
 	schedulerObserverList *collection.SafeMap
+	// 通过PauseAll暂停
+	paused atomic.Bool
 
 	_started bool
 }
@@ -61,29 +129,267 @@ func (s *taskScheduler) _afterFunc(interval time.Duration,
 	observer *taskSchedulerObserver,
 	observerItemReseve bool) {
 	taskItem.ensureHasKey()
-	t := s.timingWheel.AfterFunc(interval, func() {
-		if !observerItemReseve {
-			defer func() {
+	observer.setNextFireTime(time.Now().Add(interval))
+	run := func() {
+		s.execute(observer, taskItem, callback, 1, func() {
+			threading.SafeCallFunc(observer.notifyCompleted)
+			if !observerItemReseve {
 				//执行完成后删除key
-				s.schedulerObserverList.Del(taskItem.key)
-			}()
-		}
-		//触发回调
-		threading.SafeCallFunc(func() {
-			if callback != nil {
-				err := callback(taskItem)
-				observer.err = err
+				s.removeObserver(taskItem.key, observer)
 			}
 		})
-		threading.SafeCallFunc(observer.notifyCompleted)
+	}
+	t := s.timingWheel.AfterFunc(interval, func() {
+		if observer.deferIfPaused(run) {
+			return
+		}
+		run()
 	}).SetKey(taskItem.key)
-	observer.timer = t
+	observer.setTimer(t)
 	if !observerItemReseve {
 		//增加到待执行的列表中
 		s.schedulerObserverList.Set(taskItem.key, observer)
 	}
 }
 
+// 将scheduler交给时间轮,每次触发时执行回调
+func (s *taskScheduler) _scheduleFunc(scheduler *timeIntervalScheduler,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	observer *taskSchedulerObserver) ITaskSchedulerObserver {
+	taskItem.ensureHasKey()
+	observer.scheduler = scheduler
+	observer.callback = callback
+
+	run := func() {
+		if observer.overlap == nil {
+			s.execute(observer, taskItem, callback, 1, func() {
+				threading.SafeCallFunc(observer.notifyCompleted)
+			})
+			return
+		}
+		if overlapRun := observer.overlap.acquire(); overlapRun != nil {
+			s.executeOverlap(observer, taskItem, callback, overlapRun)
+		}
+	}
+	observer.fire = func() {
+		if observer.deferIfPaused(run) {
+			return
+		}
+		run()
+	}
+	t := s.timingWheel.ScheduleFuncWith(scheduler, taskItem.key, observer.fire)
+	if t == nil {
+		return nil
+	}
+	observer.setTimer(t)
+	s.schedulerObserverList.Set(taskItem.key, observer)
+	return observer
+}
+
+// 在run的上下文中执行回调,完成后继续执行排队的触发
+func (s *taskScheduler) executeOverlap(observer *taskSchedulerObserver,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	run *overlapRun) {
+	s.execute(observer, taskItem.withContext(run.ctx), callback, 1, func() {
+		threading.SafeCallFunc(observer.notifyCompleted)
+		next := observer.overlap.release(run)
+		if next == nil {
+			return
+		}
+		if observer.IsStopped() {
+			observer.overlap.release(next)
+			return
+		}
+		s.executeOverlap(observer, taskItem, callback, next)
+	})
+}
+
+// 第attempt次执行回调,失败时按照taskItem的重试策略在时间轮上重新调度
+// 所有的尝试结束后调用done,调度项被停止时不再重试也不调用done
+func (s *taskScheduler) execute(observer *taskSchedulerObserver,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	attempt int,
+	done func()) {
+	observer.markRun()
+	current := taskItem.withAttempt(attempt)
+	var err error
+	//触发回调
+	threading.SafeCallFunc(func() {
+		if callback != nil {
+			err = callback(current)
+		}
+	})
+	observer.setErr(err)
+
+	if err != nil {
+		policy := taskItem.GetRetryPolicy()
+		if backoff, ok := policy.nextRetry(attempt, err); ok {
+			if observer.retryCancelled.Load() {
+				return
+			}
+			retryTimer := s.timingWheel.AfterFunc(backoff, func() {
+				if observer.retryCancelled.Load() {
+					return
+				}
+				s.execute(observer, taskItem, callback, attempt+1, done)
+			})
+			observer.setRetryTimer(retryTimer)
+			return
+		}
+		if policy != nil && policy.OnFinalFailure != nil {
+			threading.SafeCallFunc(func() {
+				policy.OnFinalFailure(current, err)
+			})
+		}
+	}
+	done()
+}
+
+// 每次回调完成后,由schedule根据完成的时间计算下一次触发
+func (s *taskScheduler) _scheduleFixedDelay(schedule Schedule,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	observer *taskSchedulerObserver) ITaskSchedulerObserver {
+
+	observer.scheduler = &timeIntervalScheduler{
+		schedule: schedule,
+	}
+	return s._scheduleChain(taskItem, callback, observer)
+}
+
+// 上一次回调完成后再由observer的scheduler计算下一次触发
+func (s *taskScheduler) _scheduleChain(taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	observer *taskSchedulerObserver) ITaskSchedulerObserver {
+
+	taskItem.ensureHasKey()
+	observer.callback = callback
+	observer.rearm = func() {
+		s._rearmChain(observer)
+	}
+	observer.AddCompleteCallbacks(func(to ITaskSchedulerObserver) {
+		//check IsStopped
+		if to.IsStopped() {
+			return
+		}
+		//再次启动
+		observer.rearm()
+	})
+
+	if !s._rearmChain(observer) {
+		return nil
+	}
+	//增加到正在执行的列表中
+	s.schedulerObserverList.Set(taskItem.key, observer)
+	return observer
+}
+
+// 从当前时间计算下一次触发并启动计时器,没有下一次触发时返回false
+func (s *taskScheduler) _rearmChain(observer *taskSchedulerObserver) bool {
+	now := time.Now()
+	next := observer.getScheduler().Next(now)
+	if next.IsZero() {
+		observer.setNextFireTime(time.Time{})
+		return false
+	}
+	s._afterFunc(next.Sub(now), observer.taskItem, observer.callback, observer, true)
+	return true
+}
+
+// 修改调度规则并从当前时间重新计时,interval与schedule都为空时保持原来的调度规则
+func (s *taskScheduler) reschedule(observer *taskSchedulerObserver, interval time.Duration, schedule Schedule) bool {
+	kind, oldInterval, oldSchedule := observer.scheduleState()
+	if interval <= 0 && schedule == nil {
+		interval, schedule = oldInterval, oldSchedule
+	}
+
+	if kind == TaskKindAfter {
+		if schedule != nil {
+			now := time.Now()
+			next := schedule.Next(now)
+			if next.IsZero() {
+				return false
+			}
+			interval = next.Sub(now)
+		}
+		timer := observer.getTimer()
+		if timer == nil || !timer.Stop() {
+			// 已经执行
+			return false
+		}
+		observer.setSchedule(kind, interval, nil, nil)
+		s._afterFunc(interval, observer.taskItem, observer.callback, observer, false)
+		return true
+	}
+	if observer.IsStopped() {
+		return false
+	}
+
+	chained := kind == TaskKindOneByOne || kind == TaskKindFixedDelay
+	switch {
+	case schedule != nil && chained:
+		kind, interval = TaskKindFixedDelay, 0
+	case schedule != nil:
+		kind, interval = TaskKindSchedule, 0
+	case kind == TaskKindSchedule || kind == TaskKindFixedDelay:
+		schedule = Every(interval)
+	}
+	scheduler := &timeIntervalScheduler{
+		interval: interval,
+		schedule: schedule,
+	}
+
+	if chained {
+		observer.setSchedule(kind, interval, schedule, scheduler).stop()
+		if timer := observer.getTimer(); timer != nil && timer.Stop() {
+			return s._rearmChain(observer)
+		}
+		// 回调正在执行,完成后按照新的调度规则计时
+		return true
+	}
+
+	scheduler.observer = observer
+	observer.setSchedule(kind, interval, schedule, scheduler).stop()
+	if timer := observer.getTimer(); timer != nil {
+		timer.Stop()
+	}
+	t := s.timingWheel.ScheduleFuncWith(scheduler, observer.GetKey(), observer.fire)
+	if t == nil {
+		return false
+	}
+	observer.setTimer(t)
+	return true
+}
+
+// 调度解析得到的规则,规则没有下一次触发时间时返回ErrScheduleNeverFires
+func (s *taskScheduler) scheduleParsed(schedule Schedule,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	completeOpts ...func(ITaskSchedulerObserver)) (ITaskSchedulerObserver, error) {
+
+	observer := s.ScheduleFunc(schedule, taskItem, callback, completeOpts...)
+	if observer == nil {
+		return nil, fmt.Errorf("%w: %s", ErrScheduleNeverFires, describeSchedule(schedule))
+	}
+	return observer, nil
+}
+
+// 只有当key对应的仍然是这个observer时才删除,
+// 防止同一个key被重新调度后,旧的计时器在执行完成时把新的调度项删除
+func (s *taskScheduler) removeObserver(key string, observer *taskSchedulerObserver) {
+	v, ok := s.schedulerObserverList.Get(key)
+	if !ok {
+		return
+	}
+	if v.(*taskSchedulerObserver) != observer {
+		return
+	}
+	s.schedulerObserverList.Del(key)
+}
+
 // #region ITaskScheduler Members
 
 // stop timingWheel, this will stop all scheduler
@@ -104,6 +410,9 @@ func (s *taskScheduler) AfterFunc(interval time.Duration,
 
 	observer := newTaskSchedulerObserver(s)
 	observer.taskItem = taskItem
+	observer.kind = TaskKindAfter
+	observer.interval = interval
+	observer.callback = callback
 	observer.AddCompleteCallbacks(completeOpts...)
 
 	s._afterFunc(interval, taskItem, callback, observer, false)
@@ -118,31 +427,112 @@ func (s *taskScheduler) SchedulerFunc(interval time.Duration,
 	callback func(*TaskItem) error,
 	completeOpts ...func(ITaskSchedulerObserver)) ITaskSchedulerObserver {
 
-	taskItem.ensureHasKey()
+	observer := newTaskSchedulerObserver(s)
+	observer.taskItem = taskItem
+	observer.kind = TaskKindInterval
+	observer.interval = interval
+	observer.AddCompleteCallbacks(completeOpts...)
 	scheduler := &timeIntervalScheduler{
 		interval: interval,
+		observer: observer,
 	}
+	return s._scheduleFunc(scheduler, taskItem, callback, observer)
+}
+
+// 调度一个函数,由schedule计算每一次的触发时间
+// 这个函数，可能存在着callback并发执行的情况
+func (s *taskScheduler) ScheduleFunc(schedule Schedule,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	completeOpts ...func(ITaskSchedulerObserver)) ITaskSchedulerObserver {
+
 	observer := newTaskSchedulerObserver(s)
 	observer.taskItem = taskItem
-	observer.scheduler = scheduler
+	observer.kind = TaskKindSchedule
+	observer.schedule = schedule
 	observer.AddCompleteCallbacks(completeOpts...)
+	scheduler := &timeIntervalScheduler{
+		schedule: schedule,
+		observer: observer,
+	}
+	return s._scheduleFunc(scheduler, taskItem, callback, observer)
+}
 
-	t := s.timingWheel.ScheduleFuncWith(scheduler, taskItem.key, func() {
-		//触发回调
-		threading.SafeCallFunc(func() {
-			if callback != nil {
-				err := callback(taskItem)
-				observer.err = err
-			}
-		})
-		threading.SafeCallFunc(observer.notifyCompleted)
-	})
-	if t == nil {
-		return nil
+// 调度一个周期任务,默认为固定频率且允许回调并行执行,与ScheduleFunc相同
+func (s *taskScheduler) ScheduleRecurring(schedule Schedule,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	opts ...RecurringOption) ITaskSchedulerObserver {
+
+	options := newRecurringOptions(opts...)
+	observer := newTaskSchedulerObserver(s)
+	observer.taskItem = taskItem
+	observer.schedule = schedule
+	observer.AddCompleteCallbacks(options.completeOpts...)
+	if options.mode == ScheduleModeFixedDelay {
+		observer.kind = TaskKindFixedDelay
+		return s._scheduleFixedDelay(schedule, taskItem, callback, observer)
 	}
-	observer.timer = t
-	s.schedulerObserverList.Set(taskItem.key, observer)
-	return observer
+
+	observer.kind = TaskKindSchedule
+	observer.overlap = newOverlapGuard(options)
+	scheduler := &timeIntervalScheduler{
+		schedule: schedule,
+		observer: observer,
+	}
+	return s._scheduleFunc(scheduler, taskItem, callback, observer)
+}
+
+// 按照cron表达式调度一个函数
+func (s *taskScheduler) ScheduleCron(spec string,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	completeOpts ...func(ITaskSchedulerObserver)) (ITaskSchedulerObserver, error) {
+
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+	return s.scheduleParsed(schedule, taskItem, callback, completeOpts...)
+}
+
+// 按照systemd OnCalendar=格式的日历表达式调度一个函数
+func (s *taskScheduler) ScheduleOnCalendar(expr string,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	completeOpts ...func(ITaskSchedulerObserver)) (ITaskSchedulerObserver, error) {
+
+	schedule, err := ParseOnCalendar(expr)
+	if err != nil {
+		return nil, err
+	}
+	return s.scheduleParsed(schedule, taskItem, callback, completeOpts...)
+}
+
+// 按照ISO 8601重复时间间隔调度一个函数
+func (s *taskScheduler) ScheduleRepeatingInterval(spec string,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	completeOpts ...func(ITaskSchedulerObserver)) (ITaskSchedulerObserver, error) {
+
+	schedule, err := ParseRepeatingInterval(spec)
+	if err != nil {
+		return nil, err
+	}
+	return s.scheduleParsed(schedule, taskItem, callback, completeOpts...)
+}
+
+// 按照RFC 5545的重复规则调度一个函数,没有DTSTART时从当前时间开始
+func (s *taskScheduler) ScheduleRRule(rule string,
+	taskItem *TaskItem,
+	callback func(*TaskItem) error,
+	completeOpts ...func(ITaskSchedulerObserver)) (ITaskSchedulerObserver, error) {
+
+	schedule, err := ParseRecurrence(rule, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return s.scheduleParsed(schedule, taskItem, callback, completeOpts...)
 }
 
 func (s *taskScheduler) SchedulerFuncOneByOne(interval time.Duration,
@@ -150,29 +540,15 @@ func (s *taskScheduler) SchedulerFuncOneByOne(interval time.Duration,
 	callback func(*TaskItem) error,
 	completeOpts ...func(ITaskSchedulerObserver)) ITaskSchedulerObserver {
 
-	taskItem.ensureHasKey()
-	scheduler := &timeIntervalScheduler{
-		interval: interval,
-	}
 	observer := newTaskSchedulerObserver(s)
 	observer.taskItem = taskItem
-	observer.scheduler = scheduler
-	observer.AddCompleteCallbacks(completeOpts...)
-
-	compCallback := func(to ITaskSchedulerObserver) {
-		//check IsStopped
-		if to.IsStopped() {
-			return
-		}
-		//再次启动
-		s._afterFunc(interval, taskItem, callback, observer, true)
+	observer.kind = TaskKindOneByOne
+	observer.interval = interval
+	observer.scheduler = &timeIntervalScheduler{
+		interval: interval,
 	}
-	observer.AddCompleteCallbacks(compCallback)
-
-	s._afterFunc(interval, taskItem, callback, observer, true)
-	//增加到正在执行的列表中
-	s.schedulerObserverList.Set(taskItem.key, observer)
-	return observer
+	observer.AddCompleteCallbacks(completeOpts...)
+	return s._scheduleChain(taskItem, callback, observer)
 }
 
 // 移除指定的调度项,如果key不存在，则返回false
@@ -186,4 +562,104 @@ func (s *taskScheduler) StopScheduler(key string) bool {
 	return true
 }
 
+// 修改调度项的间隔并从当前时间重新计时
+func (s *taskScheduler) Reschedule(key string, interval time.Duration) bool {
+	observer, ok := s.getObserver(key)
+	if !ok || interval <= 0 {
+		return false
+	}
+	return s.reschedule(observer, interval, nil)
+}
+
+// 修改调度项的调度规则并从当前时间重新计时
+func (s *taskScheduler) RescheduleWith(key string, schedule Schedule) bool {
+	observer, ok := s.getObserver(key)
+	if !ok || schedule == nil {
+		return false
+	}
+	return s.reschedule(observer, 0, schedule)
+}
+
+// 不修改调度规则,从当前时间重新计时
+func (s *taskScheduler) ResetTimer(key string) bool {
+	observer, ok := s.getObserver(key)
+	if !ok {
+		return false
+	}
+	return s.reschedule(observer, 0, nil)
+}
+
+// 暂停指定的调度项,如果key不存在，则返回false
+func (s *taskScheduler) Pause(key string) bool {
+	observer, ok := s.getObserver(key)
+	if !ok {
+		return false
+	}
+	observer.setPaused(true)
+	return true
+}
+
+// 恢复指定的调度项,如果key不存在，则返回false
+func (s *taskScheduler) Resume(key string, policy ResumePolicy) bool {
+	observer, ok := s.getObserver(key)
+	if !ok {
+		return false
+	}
+	observer.setPaused(false)
+	s.resumeObserver(observer, policy)
+	return true
+}
+
+// 暂停所有的调度项
+func (s *taskScheduler) PauseAll() {
+	s.paused.Store(true)
+}
+
+// 恢复所有的调度项
+func (s *taskScheduler) ResumeAll(policy ResumePolicy) {
+	s.paused.Store(false)
+	observerList := make([]*taskSchedulerObserver, 0, s.schedulerObserverList.Size())
+	s.schedulerObserverList.Range(func(key, val any) bool {
+		observerList = append(observerList, val.(*taskSchedulerObserver))
+		return true
+	})
+	for _, eachObserver := range observerList {
+		eachObserver.setPaused(false)
+		s.resumeObserver(eachObserver, policy)
+	}
+}
+
+// 根据key获取调度项
+func (s *taskScheduler) GetTask(key string) (ITaskSchedulerObserver, bool) {
+	observerValue, ok := s.schedulerObserverList.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return observerValue.(*taskSchedulerObserver), true
+}
+
+// 获取所有调度项的快照,按下一次触发时间排序
+func (s *taskScheduler) Tasks() []TaskInfo {
+	result := make([]TaskInfo, 0, s.schedulerObserverList.Size())
+	s.schedulerObserverList.Range(func(key, val any) bool {
+		result = append(result, val.(*taskSchedulerObserver).Info())
+		return true
+	})
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].NextFireTime.Equal(result[j].NextFireTime) {
+			return result[i].Key < result[j].Key
+		}
+		return result[i].NextFireTime.Before(result[j].NextFireTime)
+	})
+	return result
+}
+
 // #endregion
+
+func (s *taskScheduler) getObserver(key string) (*taskSchedulerObserver, bool) {
+	observerValue, ok := s.schedulerObserverList.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return observerValue.(*taskSchedulerObserver), true
+}
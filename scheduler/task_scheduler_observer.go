@@ -1,6 +1,8 @@
 package scheduler
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/abmpio/threadingx/timingwheel"
@@ -8,20 +10,48 @@ import (
 
 type timeIntervalScheduler struct {
 	interval time.Duration
+	// 不为空时由schedule计算下一次触发时间,否则按照interval计算
+	schedule Schedule
 
-	stopped bool
+	stopped atomic.Bool
+	// 用于记录下一次触发的时间
+	observer *taskSchedulerObserver
+	// schedule为RandomizedDelay时上一次没有延迟的触发时间,Next只由时间轮依次调用
+	delayBase time.Time
 }
 
 func (s *timeIntervalScheduler) Next(prev time.Time) time.Time {
-	if s.stopped {
+	if s.stopped.Load() {
 		//已经停止
 		return time.Time{}
 	}
-	return prev.Add(s.interval)
+	var next time.Time
+	if s.schedule != nil {
+		if delayed, ok := s.schedule.(*randomizedDelaySchedule); ok {
+			// 时间轮以带有延迟的上一次触发时间调用,从保存的没有延迟的时间继续计算
+			base := prev
+			if !s.delayBase.IsZero() {
+				base = s.delayBase
+			}
+			s.delayBase, next = delayed.nextFromBase(base)
+		} else {
+			next = s.schedule.Next(prev)
+		}
+		if next.IsZero() {
+			// 没有下一次触发时间,计时器不会再被调度
+			s.stop()
+		}
+	} else {
+		next = prev.Add(s.interval)
+	}
+	if s.observer != nil {
+		s.observer.setNextFireTime(next)
+	}
+	return next
 }
 
 func (s *timeIntervalScheduler) stop() {
-	s.stopped = true
+	s.stopped.Store(true)
 }
 
 type ITaskSchedulerObserver interface {
@@ -32,6 +62,10 @@ type ITaskSchedulerObserver interface {
 	Error() error
 	AddCompleteCallbacks(callbacks ...func(ITaskSchedulerObserver))
 	IsStopped() bool
+	// 是否被ITaskScheduler.Pause或者PauseAll暂停
+	IsPaused() bool
+	// 获取调度项的快照
+	Info() TaskInfo
 
 	Stop() bool
 }
@@ -43,7 +77,31 @@ type taskSchedulerObserver struct {
 
 	completeCallbackList []func(ITaskSchedulerObserver)
 	taskItem             *TaskItem
-	err                  error
+	callback             func(*TaskItem) error
+	// 时间轮每次触发时的回调,用于重新调度
+	fire func()
+	err  error
+
+	// 等待中的重试计时器
+	retryTimer *timingwheel.Timer
+	// 调度项被停止后不再重试
+	retryCancelled atomic.Bool
+	// 通过ScheduleRecurring调度时控制回调的并行执行
+	overlap *overlapGuard
+	// 暂停期间到期的触发,恢复时按照ResumePolicy处理
+	paused     bool
+	pendingRun func()
+	// 上一次回调完成后才开始计时的调度项,用于恢复时从当前时间重新计时
+	rearm func()
+
+	kind     TaskKind
+	interval time.Duration
+	schedule Schedule
+	runCount int64
+	// 保护timer、scheduler、kind、interval、schedule、retryTimer、err、paused、pendingRun、nextFireTime与lastRunTime
+	rwLock       sync.RWMutex
+	nextFireTime time.Time
+	lastRunTime  time.Time
 }
 
 var _ ITaskSchedulerObserver = (*taskSchedulerObserver)(nil)
@@ -61,8 +119,8 @@ func (o *taskSchedulerObserver) GetKey() string {
 	if o == nil {
 		return ""
 	}
-	if o.timer != nil {
-		return o.timer.GetKey()
+	if timer := o.getTimer(); timer != nil {
+		return timer.GetKey()
 	}
 	if o.taskItem != nil {
 		return o.taskItem.GetKey()
@@ -80,34 +138,53 @@ func (o *taskSchedulerObserver) GetTaskItemValue() interface{} {
 
 // 如果回调返回了error,用来获取其error
 func (o *taskSchedulerObserver) Error() error {
+	o.rwLock.RLock()
+	defer o.rwLock.RUnlock()
 	return o.err
 }
 
 func (o *taskSchedulerObserver) IsStopped() bool {
-	return o.scheduler == nil || o.scheduler.stopped
+	scheduler := o.getScheduler()
+	return scheduler == nil || scheduler.stopped.Load()
+}
+
+func (o *taskSchedulerObserver) IsPaused() bool {
+	o.rwLock.RLock()
+	defer o.rwLock.RUnlock()
+	return o.isPausedLocked()
 }
 
 func (o *taskSchedulerObserver) Stop() bool {
-	if o.scheduler != nil {
-		o.scheduler.stop()
+	if scheduler := o.getScheduler(); scheduler != nil {
+		scheduler.stop()
+	}
+	hasPending := o.takePendingRun() != nil
+	o.retryCancelled.Store(true)
+	if o.overlap != nil {
+		o.overlap.stop()
+	}
+	if retryTimer := o.getRetryTimer(); retryTimer != nil {
+		retryTimer.Stop()
 	}
 	key := ""
 	if o.taskItem != nil {
 		key = o.taskItem.GetKey()
 	}
-	if len(key) <= 0 && o.timer != nil {
-		key = o.timer.GetKey()
+	timer := o.getTimer()
+	if len(key) <= 0 && timer != nil {
+		key = timer.GetKey()
 	}
 
-	if o.timer == nil {
+	if timer == nil {
 		if o.host != nil && len(key) > 0 {
-			o.host.schedulerObserverList.Del(key)
+			o.host.removeObserver(key, o)
 		}
 		return true
 	}
-	result := o.timer.Stop()
+	// 暂停期间已经到期的计时器不能再停止,但同样需要删除
+	result := timer.Stop() || hasPending
 	if result && o.host != nil && len(key) > 0 {
-		o.host.schedulerObserverList.Del(key)
+		o.host.removeObserver(key, o)
 	}
 	return result
 }
@@ -119,6 +196,160 @@ func (o *taskSchedulerObserver) AddCompleteCallbacks(callbacks ...func(ITaskSche
 	o.completeCallbackList = append(o.completeCallbackList, callbacks...)
 }
 
+// 获取调度项的快照
+func (o *taskSchedulerObserver) Info() TaskInfo {
+	info := TaskInfo{
+		Key:      o.GetKey(),
+		RunCount: atomic.LoadInt64(&o.runCount),
+	}
+	stopped := o.IsStopped()
+
+	o.rwLock.RLock()
+	defer o.rwLock.RUnlock()
+	info.Kind = o.kind
+	info.Schedule = o.describe()
+	info.Interval = o.interval
+	info.Stopped = o.kind != TaskKindAfter && stopped
+	info.Paused = o.isPausedLocked()
+	info.LastRunTime = o.lastRunTime
+	if !info.Stopped {
+		info.NextFireTime = o.nextFireTime
+	}
+	if o.err != nil {
+		info.Error = o.err.Error()
+	}
+	if o.overlap != nil {
+		info.Skipped = atomic.LoadInt64(&o.overlap.skippedCount)
+		info.Queued = atomic.LoadInt64(&o.overlap.queuedCount)
+		info.Replaced = atomic.LoadInt64(&o.overlap.replacedCount)
+	}
+	return info
+}
+
+// 调用时需要持有rwLock
+func (o *taskSchedulerObserver) describe() string {
+	if o.kind == TaskKindFixedDelay {
+		return describeSchedule(o.schedule) + " after completion"
+	}
+	if o.schedule != nil {
+		return describeSchedule(o.schedule)
+	}
+	return describeInterval(o.kind, o.interval)
+}
+
+func (o *taskSchedulerObserver) getTimer() *timingwheel.Timer {
+	o.rwLock.RLock()
+	defer o.rwLock.RUnlock()
+	return o.timer
+}
+
+// OneByOne的调度项每次执行完成后都会创建新的计时器,需要与Stop等方法同步
+func (o *taskSchedulerObserver) setTimer(timer *timingwheel.Timer) {
+	o.rwLock.Lock()
+	defer o.rwLock.Unlock()
+	o.timer = timer
+}
+
+func (o *taskSchedulerObserver) getScheduler() *timeIntervalScheduler {
+	o.rwLock.RLock()
+	defer o.rwLock.RUnlock()
+	return o.scheduler
+}
+
+func (o *taskSchedulerObserver) scheduleState() (TaskKind, time.Duration, Schedule) {
+	o.rwLock.RLock()
+	defer o.rwLock.RUnlock()
+	return o.kind, o.interval, o.schedule
+}
+
+// 替换调度规则,返回原来的scheduler
+func (o *taskSchedulerObserver) setSchedule(kind TaskKind,
+	interval time.Duration,
+	schedule Schedule,
+	scheduler *timeIntervalScheduler) *timeIntervalScheduler {
+	o.rwLock.Lock()
+	defer o.rwLock.Unlock()
+	old := o.scheduler
+	o.kind = kind
+	o.interval = interval
+	o.schedule = schedule
+	o.scheduler = scheduler
+	return old
+}
+
+func (o *taskSchedulerObserver) getRetryTimer() *timingwheel.Timer {
+	o.rwLock.RLock()
+	defer o.rwLock.RUnlock()
+	return o.retryTimer
+}
+
+func (o *taskSchedulerObserver) setRetryTimer(timer *timingwheel.Timer) {
+	o.rwLock.Lock()
+	defer o.rwLock.Unlock()
+	o.retryTimer = timer
+}
+
+func (o *taskSchedulerObserver) isPausedLocked() bool {
+	return o.paused || (o.host != nil && o.host.paused.Load())
+}
+
+// 暂停时保存run,只保留最后一次,返回是否已经暂停
+func (o *taskSchedulerObserver) deferIfPaused(run func()) bool {
+	o.rwLock.Lock()
+	defer o.rwLock.Unlock()
+	if !o.isPausedLocked() {
+		return false
+	}
+	o.pendingRun = run
+	return true
+}
+
+func (o *taskSchedulerObserver) setPaused(paused bool) {
+	o.rwLock.Lock()
+	defer o.rwLock.Unlock()
+	o.paused = paused
+}
+
+// 没有暂停时取出暂停期间到期的触发
+func (o *taskSchedulerObserver) takeResumeRun() func() {
+	o.rwLock.Lock()
+	defer o.rwLock.Unlock()
+	if o.isPausedLocked() {
+		return nil
+	}
+	run := o.pendingRun
+	o.pendingRun = nil
+	return run
+}
+
+func (o *taskSchedulerObserver) takePendingRun() func() {
+	o.rwLock.Lock()
+	defer o.rwLock.Unlock()
+	run := o.pendingRun
+	o.pendingRun = nil
+	return run
+}
+
+func (o *taskSchedulerObserver) setErr(err error) {
+	o.rwLock.Lock()
+	defer o.rwLock.Unlock()
+	o.err = err
+}
+
+func (o *taskSchedulerObserver) setNextFireTime(next time.Time) {
+	o.rwLock.Lock()
+	defer o.rwLock.Unlock()
+	o.nextFireTime = next
+}
+
+// 在执行回调前调用
+func (o *taskSchedulerObserver) markRun() {
+	atomic.AddInt64(&o.runCount, 1)
+	o.rwLock.Lock()
+	defer o.rwLock.Unlock()
+	o.lastRunTime = time.Now()
+}
+
 func (o *taskSchedulerObserver) notifyCompleted() {
 	for _, eachCallback := range o.completeCallbackList {
 		eachCallback(o)
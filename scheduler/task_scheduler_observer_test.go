@@ -40,3 +40,29 @@ func TestTaskSchedulerObserverNilSafeAccessors(t *testing.T) {
 		t.Fatalf("expected nil task item value for observer without task item, got %#v", observer.GetTaskItemValue())
 	}
 }
+
+func TestTaskSchedulerTasksReportsScheduleAndNextFireTime(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	recurItem := NewTaskItem()
+	recurItem.SetKey("recur")
+	s.SchedulerFunc(time.Hour, recurItem, nil)
+	afterItem := NewTaskItem()
+	afterItem.SetKey("after")
+	s.AfterFunc(time.Minute, afterItem, nil)
+
+	tasks := s.Tasks()
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %+v", tasks)
+	}
+	if tasks[0].Key != "after" || tasks[0].Kind != TaskKindAfter || tasks[0].Schedule != "after 1m0s" {
+		t.Fatalf("expected after task to fire first, got %+v", tasks[0])
+	}
+	if tasks[1].Key != "recur" || tasks[1].Schedule != "every 1h0m0s" || tasks[1].NextFireTime.IsZero() {
+		t.Fatalf("unexpected recurring task %+v", tasks[1])
+	}
+	if _, ok := s.GetTask("recur"); !ok {
+		t.Fatal("expected task to be found by key")
+	}
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRescheduleKeepsObserverAndCallbacks(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	for _, oneByOne := range []bool{false, true} {
+		var runs, completed int32
+		taskItem := NewTaskItem()
+		taskItem.SetKey("sync")
+		callback := func(ti *TaskItem) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}
+		complete := func(ITaskSchedulerObserver) {
+			atomic.AddInt32(&completed, 1)
+		}
+		var observer ITaskSchedulerObserver
+		if oneByOne {
+			observer = s.SchedulerFuncOneByOne(time.Hour, taskItem, callback, complete)
+		} else {
+			observer = s.SchedulerFunc(time.Hour, taskItem, callback, complete)
+		}
+
+		if !s.Reschedule("sync", 20*time.Millisecond) {
+			t.Fatalf("oneByOne=%v: expected Reschedule to succeed", oneByOne)
+		}
+		time.Sleep(110 * time.Millisecond)
+		if n := atomic.LoadInt32(&runs); n < 2 || atomic.LoadInt32(&completed) < 2 {
+			t.Fatalf("oneByOne=%v: expected runs at the new interval, got %d", oneByOne, n)
+		}
+		current, ok := s.GetTask("sync")
+		if !ok || current != observer || observer.Info().Interval != 20*time.Millisecond {
+			t.Fatalf("oneByOne=%v: expected the same observer with the new interval, got %+v", oneByOne, observer.Info())
+		}
+		s.StopScheduler("sync")
+	}
+}
+
+func TestRescheduleWithScheduleChangesKind(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	taskItem := NewTaskItem()
+	taskItem.SetKey("poll")
+	observer := s.SchedulerFuncOneByOne(time.Hour, taskItem, func(ti *TaskItem) error { return nil })
+	if !s.RescheduleWith("poll", Every(time.Minute)) {
+		t.Fatal("expected RescheduleWith to succeed")
+	}
+	info := observer.Info()
+	if info.Kind != TaskKindFixedDelay || info.Schedule != "every 1m0s after completion" {
+		t.Fatalf("unexpected info after RescheduleWith %+v", info)
+	}
+	if s.Reschedule("missing", time.Second) || s.ResetTimer("missing") {
+		t.Fatal("expected unknown keys to be rejected")
+	}
+}
+
+func TestResetTimerRestartsAfterFunc(t *testing.T) {
+	s := NewTaskScheduler()
+	defer s.Stop()
+
+	var fired int32
+	taskItem := NewTaskItem()
+	taskItem.SetKey("idle")
+	s.AfterFunc(60*time.Millisecond, taskItem, func(ti *TaskItem) error {
+		atomic.StoreInt32(&fired, 1)
+		return nil
+	})
+	time.Sleep(40 * time.Millisecond)
+	if !s.ResetTimer("idle") {
+		t.Fatal("expected ResetTimer to succeed before the timer fires")
+	}
+	time.Sleep(40 * time.Millisecond)
+	if atomic.LoadInt32(&fired) != 0 {
+		t.Fatal("expected the reset timer to start counting again")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if atomic.LoadInt32(&fired) != 1 {
+		t.Fatal("expected the reset timer to fire")
+	}
+	if s.ResetTimer("idle") {
+		t.Fatal("expected ResetTimer to fail after the timer fired")
+	}
+}
@@ -16,6 +16,8 @@ func init() {
 
 	_globalTimeline.(*timeline).setSceneTimer(_globalSceneTimer)
 	_globalSceneTimer.(*sceneTimer).setTimeline(_globalTimeline)
+	// 全局的时间轴不由_globalLogicThread驱动,不绑定逻辑线程,亲和性检查总是通过
+	registerTimeline("global", _globalTimeline)
 }
 
 func GlobalTimeline() ITimeline {
@@ -0,0 +1,272 @@
+package threading
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/abmpio/threadingx/rescue"
+)
+
+// 支持协作式取消的工作项
+// WorkItemThread设置了abortThreadTimeout时,ctx会在超时后被取消,工作项应该尽快返回
+type IContextWorkItem interface {
+	// 工作项的描述，可为空
+	Description() string
+
+	// 如何执行,ctx被取消后应该尽快返回
+	DoWork(ctx context.Context)
+}
+
+// 由WrapContextWorkItem包装后的工作项实现此接口
+type contextWork interface {
+	doWorkContext(ctx context.Context)
+}
+
+var _ IWorkItem = (*contextWorkItem)(nil)
+
+// IContextWorkItem的包装,使其可以放入工作项池中
+type contextWorkItem struct {
+	inner IContextWorkItem
+}
+
+// 将IContextWorkItem包装为IWorkItem,以便放入LogicThread等工作项池中
+func WrapContextWorkItem(workItem IContextWorkItem) IWorkItem {
+	if workItem == nil {
+		return nil
+	}
+	return &contextWorkItem{
+		inner: workItem,
+	}
+}
+
+// 创建一个支持协作式取消的工作项
+func NewContextWorkItem(fn func(ctx context.Context)) IWorkItem {
+	return WrapContextWorkItem(&ContextWorkItem{
+		action: fn,
+	})
+}
+
+// #region IWorkItem Members
+
+func (i *contextWorkItem) Description() string {
+	return i.inner.Description()
+}
+
+// 不经过WorkItemThread执行时使用不会被取消的ctx
+func (i *contextWorkItem) DoWork() {
+	i.inner.DoWork(context.Background())
+}
+
+// #endregion
+
+func (i *contextWorkItem) doWorkContext(ctx context.Context) {
+	i.inner.DoWork(ctx)
+}
+
+var _ IContextWorkItem = (*ContextWorkItem)(nil)
+
+// 以函数实现的IContextWorkItem
+type ContextWorkItem struct {
+	action      func(ctx context.Context)
+	description string
+}
+
+func (i *ContextWorkItem) SetDescription(description string) {
+	i.description = description
+}
+
+// #region IContextWorkItem Members
+
+func (i *ContextWorkItem) Description() string {
+	return i.description
+}
+
+func (i *ContextWorkItem) DoWork(ctx context.Context) {
+	if i.action == nil {
+		return
+	}
+	i.action(ctx)
+}
+
+// #endregion
+
+// 超时后被放弃但仍在运行的工作项
+type AbandonedWorkItem struct {
+	WorkItem    IWorkItem
+	Description string
+	// 开始执行的时间
+	StartedAt time.Time
+	// 被放弃的时间
+	AbandonedAt time.Time
+}
+
+// 带超时执行工作项
+// 超时后取消ctx并放弃等待,工作项所在的协程会被记录下来,在其返回之前这个工作项不会被再次执行
+// 连续超时达到quarantineAfterTimeouts次的工作项会被隔离,直到调用ReleaseQuarantine
+func (t *WorkItemThread) doWorkItemWithTimeout(workItem IWorkItem, startedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.abortThreadTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	panicChan := make(chan any, 1)
+	abandoned := make(chan struct{})
+	// 协程先交出自己的id,在调用者记录之后才执行工作项,保证工作项中的亲和性检查可以通过
+	goroutineIDChan := make(chan uint64)
+	ready := make(chan struct{})
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				select {
+				case <-abandoned:
+					// 已经被放弃,没有调用者可以接收这个panic
+					fmt.Printf("abandoned workItem panic,id:%s,workItem:%s,panic:%+v\n\n%s",
+						t.id,
+						workItem.Description(),
+						p,
+						strings.TrimSpace(string(debug.Stack())))
+				default:
+					panicChan <- fmt.Sprintf("%+v\n\n%s", p, strings.TrimSpace(string(debug.Stack())))
+				}
+			}
+			close(done)
+			select {
+			case <-abandoned:
+				// panic可能在ctx到期与close(abandoned)之间发生,此时已经放入了panicChan
+				t.logAbandonedPanic(workItem, panicChan)
+				t.onAbandonedWorkItemReturned(workItem)
+			default:
+			}
+		}()
+		goroutineIDChan <- currentGoroutineID()
+		<-ready
+		if cw, ok := workItem.(contextWork); ok {
+			cw.doWorkContext(ctx)
+		} else {
+			workItem.DoWork()
+		}
+	}()
+
+	t.delegateGoroutineID.Store(<-goroutineIDChan)
+	close(ready)
+
+	select {
+	case <-done:
+		t.delegateGoroutineID.Store(0)
+		select {
+		case p := <-panicChan:
+			panic(p)
+		default:
+		}
+		t.onWorkItemFinished(workItem)
+		return nil
+	case <-ctx.Done():
+		// 被放弃的协程不再被认为是逻辑线程
+		t.delegateGoroutineID.Store(0)
+		t.onWorkItemAbandoned(workItem, startedAt)
+		close(abandoned)
+		// 协程可能在ctx到期与close(abandoned)之间已经返回
+		select {
+		case <-done:
+			t.logAbandonedPanic(workItem, panicChan)
+			t.onAbandonedWorkItemReturned(workItem)
+		default:
+		}
+		return ctx.Err()
+	}
+}
+
+// 输出被放弃的工作项在被放弃之前放入panicChan的panic,没有时什么也不做
+func (t *WorkItemThread) logAbandonedPanic(workItem IWorkItem, panicChan chan any) {
+	select {
+	case p := <-panicChan:
+		fmt.Printf("abandoned workItem panic,id:%s,workItem:%s,panic:%s",
+			t.id,
+			workItem.Description(),
+			p)
+	default:
+	}
+}
+
+// 工作项是否可以执行,被隔离或者上一次被放弃的执行还没有返回时不执行
+func (t *WorkItemThread) canDoWorkItem(workItem IWorkItem) bool {
+	t.abandonLock.Lock()
+	defer t.abandonLock.Unlock()
+	if _, ok := t.quarantineList[workItem]; ok {
+		return false
+	}
+	if _, ok := t.abandonedList[workItem]; ok {
+		return false
+	}
+	return true
+}
+
+func (t *WorkItemThread) onWorkItemFinished(workItem IWorkItem) {
+	t.abandonLock.Lock()
+	defer t.abandonLock.Unlock()
+	delete(t.timeoutCountList, workItem)
+}
+
+func (t *WorkItemThread) onWorkItemAbandoned(workItem IWorkItem, startedAt time.Time) {
+	t.abandonLock.Lock()
+	defer t.abandonLock.Unlock()
+
+	t.abandonedList[workItem] = &AbandonedWorkItem{
+		WorkItem:    workItem,
+		Description: workItem.Description(),
+		StartedAt:   startedAt,
+		AbandonedAt: time.Now(),
+	}
+	t.timeoutCountList[workItem]++
+	if t.quarantineAfterTimeouts > 0 && t.timeoutCountList[workItem] >= t.quarantineAfterTimeouts {
+		t.quarantineList[workItem] = struct{}{}
+		delete(t.timeoutCountList, workItem)
+		fmt.Printf("workItem quarantined,id:%s,workItem:%s", t.id, workItem.Description())
+	}
+}
+
+func (t *WorkItemThread) onAbandonedWorkItemReturned(workItem IWorkItem) {
+	defer rescue.Recover()
+
+	t.abandonLock.Lock()
+	defer t.abandonLock.Unlock()
+	delete(t.abandonedList, workItem)
+}
+
+// 超时后被放弃但仍在运行的工作项
+func (t *WorkItemThread) AbandonedWorkItems() []AbandonedWorkItem {
+	t.abandonLock.Lock()
+	defer t.abandonLock.Unlock()
+
+	result := make([]AbandonedWorkItem, 0, len(t.abandonedList))
+	for _, eachItem := range t.abandonedList {
+		result = append(result, *eachItem)
+	}
+	return result
+}
+
+// 被隔离的工作项
+func (t *WorkItemThread) QuarantinedWorkItems() []IWorkItem {
+	t.abandonLock.Lock()
+	defer t.abandonLock.Unlock()
+
+	result := make([]IWorkItem, 0, len(t.quarantineList))
+	for eachItem := range t.quarantineList {
+		result = append(result, eachItem)
+	}
+	return result
+}
+
+// 解除工作项的隔离,之后的帧将继续执行这个工作项
+func (t *WorkItemThread) ReleaseQuarantine(workItem IWorkItem) bool {
+	t.abandonLock.Lock()
+	defer t.abandonLock.Unlock()
+
+	if _, ok := t.quarantineList[workItem]; !ok {
+		return false
+	}
+	delete(t.quarantineList, workItem)
+	return true
+}
@@ -0,0 +1,115 @@
+package threading
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitUntil(t *testing.T, timeout time.Duration, cond func() bool) {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %v", timeout)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestContextWorkItemIsCancelledAtAbortTimeout(t *testing.T) {
+	thread := NewWorkItemThread(nil, ThreadOptionWithAbortThreadTimeoutMS(20))
+
+	var ctxErr atomic.Value
+	workItem := NewContextWorkItem(func(ctx context.Context) {
+		<-ctx.Done()
+		ctxErr.Store(ctx.Err())
+	})
+	thread.doWorkItem(workItem)
+
+	waitUntil(t, time.Second, func() bool { return len(thread.AbandonedWorkItems()) == 0 })
+	if err, _ := ctxErr.Load().(error); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the work item to observe the deadline, got %v", err)
+	}
+}
+
+func TestAbandonedWorkItemIsNotRunConcurrently(t *testing.T) {
+	thread := NewWorkItemThread(nil, ThreadOptionWithAbortThreadTimeoutMS(10))
+
+	var calls, running int32
+	release := make(chan struct{})
+	workItem := NewWorkItem(func() {
+		atomic.AddInt32(&calls, 1)
+		if atomic.AddInt32(&running, 1) > 1 {
+			t.Errorf("work item is running concurrently")
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+	})
+
+	thread.doWorkItem(workItem)
+	abandoned := thread.AbandonedWorkItems()
+	if len(abandoned) != 1 || abandoned[0].WorkItem != workItem {
+		t.Fatalf("expected the work item to be abandoned, got %+v", abandoned)
+	}
+	// 上一次执行还没有返回,不再执行
+	thread.doWorkItem(workItem)
+	if v := atomic.LoadInt32(&calls); v != 1 {
+		t.Fatalf("expected abandoned work item to be skipped, got %d calls", v)
+	}
+
+	close(release)
+	waitUntil(t, time.Second, func() bool { return len(thread.AbandonedWorkItems()) == 0 })
+	thread.doWorkItem(workItem)
+	if v := atomic.LoadInt32(&calls); v != 2 {
+		t.Fatalf("expected work item to run again after returning, got %d calls", v)
+	}
+}
+
+func TestWorkItemIsQuarantinedAfterRepeatedTimeouts(t *testing.T) {
+	thread := NewWorkItemThread(nil, ThreadOptionWithAbortThreadTimeoutMS(5), ThreadOptionWithQuarantine(2))
+
+	var calls int32
+	workItem := NewWorkItem(func() {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+	})
+	for i := 0; i < 2; i++ {
+		thread.doWorkItem(workItem)
+		waitUntil(t, time.Second, func() bool { return len(thread.AbandonedWorkItems()) == 0 })
+	}
+	if quarantined := thread.QuarantinedWorkItems(); len(quarantined) != 1 || quarantined[0] != workItem {
+		t.Fatalf("expected the work item to be quarantined, got %v", quarantined)
+	}
+	thread.doWorkItem(workItem)
+	if v := atomic.LoadInt32(&calls); v != 2 {
+		t.Fatalf("expected quarantined work item to be skipped, got %d calls", v)
+	}
+	if !thread.ReleaseQuarantine(workItem) || len(thread.QuarantinedWorkItems()) != 0 {
+		t.Fatalf("expected the quarantine to be released")
+	}
+}
+
+func TestDelegateGoroutineIsCurrentOnlyUntilAbandoned(t *testing.T) {
+	thread := NewWorkItemThread(nil, ThreadOptionWithAbortThreadTimeoutMS(10))
+
+	var before, after atomic.Bool
+	release := make(chan struct{})
+	returned := make(chan struct{})
+	thread.doWorkItem(NewWorkItem(func() {
+		defer close(returned)
+		before.Store(thread.IsCurrent())
+		<-release
+		after.Store(thread.IsCurrent())
+	}))
+	close(release)
+	<-returned
+
+	if !before.Load() {
+		t.Fatal("expected the work item to run as the thread before it was abandoned")
+	}
+	if after.Load() {
+		t.Fatal("expected the abandoned goroutine not to pass affinity checks")
+	}
+}
@@ -0,0 +1,152 @@
+package threading
+
+import (
+	"runtime"
+	"time"
+)
+
+const (
+	defaultMaxCatchUpFrames = 5
+)
+
+// 线程落后于帧截止时间时的处理策略
+type FramePolicy int
+
+const (
+	// 丢弃已经错过的帧,立即执行最近的一帧后重新对齐到帧的时间点上
+	FramePolicySkip FramePolicy = iota
+	// 立即连续执行错过的帧,最多追赶maxCatchUpFrames帧,超过时按FramePolicySkip处理
+	// 每一帧的计划执行时间(WorkItemThread.CurrentFrameTime)仍然相差一个帧间隔,时间轴以此计算追赶的帧的间隔
+	FramePolicyCatchUp
+)
+
+func (p FramePolicy) String() string {
+	switch p {
+	case FramePolicySkip:
+		return "skip"
+	case FramePolicyCatchUp:
+		return "catch_up"
+	default:
+		return "unknown"
+	}
+}
+
+// 以绝对的截止时间驱动每一帧,帧的执行时间不会累加到帧间隔中
+type framePacer struct {
+	interval         time.Duration
+	policy           FramePolicy
+	maxCatchUpFrames int
+	// 距离截止时间小于这个值时不再睡眠,改为自旋等待,0表示不自旋
+	spinThreshold time.Duration
+	// 空闲时的帧间隔,0表示不启用空闲降频
+	idleInterval time.Duration
+
+	deadline time.Time
+	timer    *time.Timer
+	// 当前是否处于空闲降频状态
+	idle bool
+}
+
+func newFramePacer(options *workItemThreadOptions) *framePacer {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	return &framePacer{
+		interval:         options.threadWorkItemInterval,
+		policy:           options.framePolicy,
+		maxCatchUpFrames: options.maxCatchUpFrames,
+		spinThreshold:    options.spinThreshold,
+		idleInterval:     options.idleInterval,
+		timer:            timer,
+	}
+}
+
+// 以now作为起点,第一帧在一个帧间隔后执行
+func (p *framePacer) start(now time.Time) {
+	p.deadline = now.Add(p.interval)
+}
+
+// 从暂停中恢复,下一帧在now执行,之后重新对齐
+func (p *framePacer) restart(now time.Time) {
+	p.idle = false
+	p.deadline = now
+}
+
+// 等待到当前帧的截止时间,stopChan被关闭时返回false
+// 空闲状态下wakeChan收到信号时立即结束等待并恢复到正常的帧率
+func (p *framePacer) wait(stopChan chan struct{}, wakeChan chan struct{}) bool {
+	if !p.idle {
+		// 非空闲状态下不需要被唤醒
+		wakeChan = nil
+	}
+	sleep := time.Until(p.deadline) - p.spinThreshold
+	if sleep > 0 {
+		p.timer.Reset(sleep)
+		select {
+		case <-p.timer.C:
+		case <-wakeChan:
+			p.stopTimer()
+			p.idle = false
+			p.deadline = time.Now()
+			return true
+		case <-stopChan:
+			p.stopTimer()
+			return false
+		}
+	}
+	if p.spinThreshold <= 0 {
+		return true
+	}
+	// 自旋等待剩余的时间,用于获得亚毫秒级的精度
+	for time.Now().Before(p.deadline) {
+		select {
+		case <-stopChan:
+			return false
+		default:
+		}
+		runtime.Gosched()
+	}
+	return true
+}
+
+// 一帧执行完成后计算下一帧的截止时间,返回被丢弃的帧数
+// idle 表示这一帧之后工作项都处于空闲状态
+func (p *framePacer) advance(now time.Time, idle bool) int64 {
+	if p.idleInterval > 0 {
+		if idle {
+			p.idle = true
+			p.deadline = now.Add(p.idleInterval)
+			return 0
+		}
+		if p.idle {
+			// 从空闲状态恢复,以当前时间重新对齐
+			p.idle = false
+			p.deadline = now.Add(p.interval)
+			return 0
+		}
+	}
+	p.deadline = p.deadline.Add(p.interval)
+	if now.Before(p.deadline) {
+		return 0
+	}
+	// 已经落后,missed为截止时间已经过去但还没有执行的帧数(不含马上要执行的这一帧)
+	missed := int64(now.Sub(p.deadline) / p.interval)
+	if p.policy == FramePolicyCatchUp && missed < int64(p.maxCatchUpFrames) {
+		// 保持原来的截止时间,接下来的帧将连续执行
+		return 0
+	}
+	p.deadline = p.deadline.Add(time.Duration(missed) * p.interval)
+	return missed
+}
+
+func (p *framePacer) stopTimer() {
+	if !p.timer.Stop() {
+		select {
+		case <-p.timer.C:
+		default:
+		}
+	}
+}
+
+func (p *framePacer) stop() {
+	p.timer.Stop()
+}
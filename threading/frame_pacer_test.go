@@ -0,0 +1,101 @@
+package threading
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestFramePacer(opts ...ThreadOption) *framePacer {
+	options := newWorkItemThreadOptions()
+	options.threadWorkItemInterval = 10 * time.Millisecond
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+	return newFramePacer(options)
+}
+
+func TestFramePacerKeepsAbsoluteDeadlines(t *testing.T) {
+	pacer := newTestFramePacer()
+	start := time.Unix(0, 0)
+	pacer.start(start)
+
+	// 每一帧耗时4ms,截止时间仍然按10ms对齐
+	for i := 1; i <= 3; i++ {
+		if skipped := pacer.advance(start.Add(time.Duration(i)*10*time.Millisecond+4*time.Millisecond), false); skipped != 0 {
+			t.Fatalf("expected no skipped frames, got %d", skipped)
+		}
+		expected := start.Add(time.Duration(i+1) * 10 * time.Millisecond)
+		if !pacer.deadline.Equal(expected) {
+			t.Fatalf("frame %d: expected deadline %v, got %v", i, expected, pacer.deadline)
+		}
+	}
+}
+
+func TestFramePacerSkipPolicy(t *testing.T) {
+	pacer := newTestFramePacer()
+	start := time.Unix(0, 0)
+	pacer.start(start)
+
+	// 第一帧(10ms)执行了35ms,20ms与30ms的帧被丢弃,40ms的帧立即执行
+	skipped := pacer.advance(start.Add(45*time.Millisecond), false)
+	if skipped != 2 {
+		t.Fatalf("expected 2 skipped frames, got %d", skipped)
+	}
+	if expected := start.Add(40 * time.Millisecond); !pacer.deadline.Equal(expected) {
+		t.Fatalf("expected deadline %v, got %v", expected, pacer.deadline)
+	}
+}
+
+func TestFramePacerCatchUpPolicy(t *testing.T) {
+	pacer := newTestFramePacer(ThreadOptionWithFramePolicy(FramePolicyCatchUp, 3))
+	start := time.Unix(0, 0)
+	pacer.start(start)
+
+	// 落后2帧,在追赶范围内,保持原有的截止时间
+	if skipped := pacer.advance(start.Add(45*time.Millisecond), false); skipped != 0 {
+		t.Fatalf("expected to catch up, got %d skipped", skipped)
+	}
+	if expected := start.Add(20 * time.Millisecond); !pacer.deadline.Equal(expected) {
+		t.Fatalf("expected deadline %v, got %v", expected, pacer.deadline)
+	}
+
+	// 落后超过3帧,按skip处理
+	if skipped := pacer.advance(start.Add(95*time.Millisecond), false); skipped != 6 {
+		t.Fatalf("expected 6 skipped frames, got %d", skipped)
+	}
+	if expected := start.Add(90 * time.Millisecond); !pacer.deadline.Equal(expected) {
+		t.Fatalf("expected deadline %v, got %v", expected, pacer.deadline)
+	}
+}
+
+type testFramePool struct {
+	workItem IWorkItem
+}
+
+func (p *testFramePool) WorkItemIsList() bool         { return true }
+func (p *testFramePool) GetWorkItemQueueCount() int   { return 1 }
+func (p *testFramePool) GetNextWorkItem() []IWorkItem { return []IWorkItem{p.workItem} }
+
+func TestWorkItemThreadFrameRateDoesNotDrift(t *testing.T) {
+	var frames int32
+	pool := &testFramePool{
+		workItem: NewWorkItem(func() {
+			atomic.AddInt32(&frames, 1)
+			// 模拟每帧都有一定的工作量
+			time.Sleep(5 * time.Millisecond)
+		}),
+	}
+	thread := NewWorkItemThread(pool, ThreadOptionWithFPS(50), ThreadOptionWithSpinThreshold(time.Millisecond))
+	if thread.ThreadWorkItemIntervalMs() != 20 {
+		t.Fatalf("expected 20ms interval, got %d", thread.ThreadWorkItemIntervalMs())
+	}
+	thread.Start()
+	time.Sleep(500 * time.Millisecond)
+	thread.Stop()
+
+	// 按绝对截止时间应该执行约25帧,若把工作时间累加到间隔中只有约20帧
+	if v := atomic.LoadInt32(&frames); v < 22 || v > 26 {
+		t.Fatalf("expected about 25 frames in 500ms, got %d", v)
+	}
+}
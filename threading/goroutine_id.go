@@ -0,0 +1,45 @@
+package threading
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+var goroutinePrefix = []byte("goroutine ")
+
+// 当前协程的id,从runtime.Stack的第一行"goroutine 123 [running]:"中解析
+func currentGoroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, goroutinePrefix)
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// 从所有协程的堆栈中找出指定协程的堆栈,找不到时返回空字符串
+func goroutineStack(id uint64) string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+	header := []byte("goroutine " + strconv.FormatUint(id, 10) + " [")
+	start := bytes.Index(buf, header)
+	if start < 0 {
+		return ""
+	}
+	stack := buf[start:]
+	// 每个协程的堆栈以空行分隔
+	if end := bytes.Index(stack, []byte("\n\n")); end >= 0 {
+		stack = stack[:end]
+	}
+	return string(stack)
+}
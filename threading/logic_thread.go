@@ -1,6 +1,10 @@
 package threading
 
-import "sync"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
 type LogicThread struct {
 	_thread *WorkItemThread
@@ -13,17 +17,89 @@ type LogicThread struct {
 }
 
 var _ IWorkItemPool = (*LogicThread)(nil)
+var _ IIdleWorkItemPool = (*LogicThread)(nil)
 
-func NewLogicThread() *LogicThread {
+// opts 用于设置承载逻辑线程的WorkItemThread,如帧率等
+func NewLogicThread(opts ...ThreadOption) *LogicThread {
 	t := &LogicThread{
 		rwLock:            sync.RWMutex{},
 		addedWorkItemList: make([]IWorkItem, 0),
 		workingItemList:   make([]IWorkItem, 0),
 	}
-	t._thread = NewWorkItemThread(t)
+	t._thread = NewWorkItemThread(t, opts...)
 	return t
 }
 
+// 唤醒处于空闲降频状态的逻辑线程
+func (t *LogicThread) Wake() {
+	t._thread.Wake()
+}
+
+// 逻辑线程当前是否处于空闲降频状态
+func (t *LogicThread) Idling() bool {
+	return t._thread.Idling()
+}
+
+// 执行逻辑线程的协程id,没有运行时为0
+func (t *LogicThread) GoroutineID() uint64 {
+	return t._thread.GoroutineID()
+}
+
+// 正在代为执行工作项的协程id,没有时为0
+func (t *LogicThread) DelegateGoroutineID() uint64 {
+	return t._thread.DelegateGoroutineID()
+}
+
+// 当前正在执行的帧的开始时间,不在帧中时为零值
+func (t *LogicThread) CurrentFrameStartedAt() time.Time {
+	return t._thread.CurrentFrameStartedAt()
+}
+
+// 当前正在执行的帧计划执行的时间,不在帧中时为零值
+func (t *LogicThread) CurrentFrameTime() time.Time {
+	return t._thread.CurrentFrameTime()
+}
+
+// 最后一帧完成的时间
+func (t *LogicThread) LastFrameAt() time.Time {
+	return t._thread.LastFrameAt()
+}
+
+// 逻辑线程是否正在运行
+func (t *LogicThread) IsRunning() bool {
+	return t._thread.IsRunning()
+}
+
+// 当前代码是否运行在逻辑线程中
+func (t *LogicThread) IsCurrent() bool {
+	return t._thread.IsCurrent()
+}
+
+// 暂停逻辑线程,当前正在执行的帧会执行完成
+func (t *LogicThread) Pause() {
+	t._thread.Pause()
+}
+
+// 恢复逻辑线程,下一帧立即执行
+func (t *LogicThread) Resume() {
+	t._thread.Resume()
+}
+
+// 暂停状态下再执行n帧
+func (t *LogicThread) Step(n int) {
+	t._thread.Step(n)
+}
+
+// 是否处于暂停状态
+func (t *LogicThread) Paused() bool {
+	return t._thread.Paused()
+}
+
+// 逻辑线程的id
+func (t *LogicThread) ID() string {
+	return t._thread.ID()
+}
+
 func (t *LogicThread) Start() {
 	t._thread.Start()
 }
@@ -32,13 +108,24 @@ func (t *LogicThread) Stop() {
 	t._thread.Stop()
 }
 
+// 等待逻辑线程的协程退出
+func (t *LogicThread) Wait(ctx context.Context) error {
+	return t._thread.Wait(ctx)
+}
+
+// 停止逻辑线程并等待协程执行完当前帧后退出
+func (t *LogicThread) Shutdown(ctx context.Context) error {
+	return t._thread.Shutdown(ctx)
+}
+
 // 往逻辑线程中放置一个任务
 func (t *LogicThread) AttatchWorkItem(workItem IWorkItem) {
 	t.rwLock.Lock()
 	defer t.rwLock.Unlock()
 
 	t.addedWorkItemList = append(t.addedWorkItemList, workItem)
-	t.workingItemList = t.addedWorkItemList[:]
+	// 使用独立的快照,避免正在执行的工作项列表被原地修改
+	t.workingItemList = append([]IWorkItem(nil), t.addedWorkItemList...)
 }
 
 func (t *LogicThread) DetatchWorkItem(workItem IWorkItem) {
@@ -54,7 +141,7 @@ func (t *LogicThread) DetatchWorkItem(workItem IWorkItem) {
 		set = append(set, eachItem)
 	}
 	t.addedWorkItemList = set
-	t.workingItemList = t.addedWorkItemList[:]
+	t.workingItemList = append([]IWorkItem(nil), t.addedWorkItemList...)
 }
 
 // #region IWorkItemPool Members
@@ -65,12 +152,31 @@ func (t *LogicThread) WorkItemIsList() bool {
 
 // 获取线程池中的线程的工作项列表
 func (t *LogicThread) GetWorkItemQueueCount() int {
+	t.rwLock.RLock()
+	defer t.rwLock.RUnlock()
 	return len(t.workingItemList)
 }
 
 // 获取下一个工作项
 func (t *LogicThread) GetNextWorkItem() []IWorkItem {
+	t.rwLock.RLock()
+	defer t.rwLock.RUnlock()
 	return t.workingItemList
 }
 
 // #endregion
+
+// #region IIdleWorkItemPool Members
+
+// 所有的工作项都实现了IIdleWorkItem并且处于空闲状态
+func (t *LogicThread) IsIdle() bool {
+	for _, eachItem := range t.GetNextWorkItem() {
+		idleItem, ok := eachItem.(IIdleWorkItem)
+		if !ok || !idleItem.IsIdle() {
+			return false
+		}
+	}
+	return true
+}
+
+// #endregion
@@ -0,0 +1,102 @@
+package threading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// 多个WorkItemThread共同消费同一个WorkItemQueuePool
+// 工作项可能在任意一个线程中执行,不保证执行顺序
+type ThreadPool struct {
+	queue      *WorkItemQueuePool
+	threadList []*WorkItemThread
+}
+
+// 创建一个包含workers个线程的线程池,需要调用Start启动
+// queue 为nil时创建一个新的队列
+// opts 应用于池中的每一个线程,线程的id为ThreadOptionWithName设置的值(默认为随机值)加上序号
+func NewThreadPool(workers int, queue *WorkItemQueuePool, opts ...ThreadOption) *ThreadPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queue == nil {
+		queue = NewWorkItemQueuePool()
+	}
+	options := newWorkItemThreadOptions()
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+
+	p := &ThreadPool{
+		queue:      queue,
+		threadList: make([]*WorkItemThread, 0, workers),
+	}
+	for i := 0; i < workers; i++ {
+		threadOpts := append(append([]ThreadOption(nil), opts...), ThreadOptionWithName(fmt.Sprintf("%s-%d", options.id, i)))
+		p.threadList = append(p.threadList, NewWorkItemThread(queue, threadOpts...))
+	}
+	return p
+}
+
+// 线程数量
+func (p *ThreadPool) Size() int {
+	return len(p.threadList)
+}
+
+// 线程池消费的队列
+func (p *ThreadPool) Queue() *WorkItemQueuePool {
+	return p.queue
+}
+
+// 启动所有线程
+func (p *ThreadPool) Start() {
+	for _, eachThread := range p.threadList {
+		eachThread.Start()
+	}
+}
+
+// 是否至少有一个线程正在运行并且没有被停止,否则放入的工作项不会被执行
+func (p *ThreadPool) IsRunning() bool {
+	for _, eachThread := range p.threadList {
+		if eachThread.IsRunning() && !eachThread._shutdown.Get() {
+			return true
+		}
+	}
+	return false
+}
+
+// 放入一个工作项
+func (p *ThreadPool) Put(workItem IWorkItem) {
+	p.queue.Put(workItem)
+}
+
+// 放入一个函数
+func (p *ThreadPool) Post(fn func()) {
+	p.queue.Put(NewWorkItem(fn))
+}
+
+// 放入一个函数,priority越大越先执行
+func (p *ThreadPool) PostWithPriority(fn func(), priority int) {
+	p.queue.PutWithPriority(NewWorkItem(fn), priority)
+}
+
+// 停止所有线程,只是发出停止的信号,不等待协程退出
+// 队列中尚未执行的工作项会保留在队列中
+func (p *ThreadPool) Stop() {
+	for _, eachThread := range p.threadList {
+		eachThread.Stop()
+	}
+}
+
+// 停止所有线程并等待正在执行的工作项完成
+func (p *ThreadPool) Shutdown(ctx context.Context) error {
+	p.Stop()
+	errList := make([]error, 0)
+	for _, eachThread := range p.threadList {
+		if err := eachThread.Wait(ctx); err != nil {
+			errList = append(errList, fmt.Errorf("thread %s: %w", eachThread.ID(), err))
+		}
+	}
+	return errors.Join(errList...)
+}
@@ -0,0 +1,83 @@
+package threading
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkItemQueuePoolPriority(t *testing.T) {
+	var highWaterMarkCalls int32
+	pool := NewWorkItemQueuePool(WorkItemQueuePoolOptionWithHighWaterMark(2, func(length int) {
+		atomic.AddInt32(&highWaterMarkCalls, 1)
+	}))
+	order := make([]string, 0)
+	put := func(name string, priority int) {
+		pool.PutWithPriority(NewWorkItem(func() { order = append(order, name) }), priority)
+	}
+	put("low-1", 0)
+	put("high", 10)
+	put("low-2", 0)
+	put("mid", 5)
+
+	for {
+		items := pool.GetNextWorkItem()
+		if len(items) <= 0 {
+			break
+		}
+		items[0].DoWork()
+	}
+	expected := []string{"high", "mid", "low-1", "low-2"}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected order %v, got %v", expected, order)
+		}
+	}
+
+	metrics := pool.Metrics()
+	if metrics.Length != 0 || metrics.MaxLength != 4 || metrics.Enqueued != 4 || metrics.Dequeued != 4 {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+	if v := atomic.LoadInt32(&highWaterMarkCalls); v != 1 {
+		t.Fatalf("expected high water mark callback once, got %d", v)
+	}
+}
+
+func TestThreadPoolRunsAllWorkItems(t *testing.T) {
+	pool := NewThreadPool(4, nil)
+	pool.Start()
+
+	var count int32
+	for i := 0; i < 100; i++ {
+		pool.Post(func() {
+			atomic.AddInt32(&count, 1)
+		})
+	}
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&count) < 100 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if v := atomic.LoadInt32(&count); v != 100 {
+		t.Fatalf("expected 100 work items to run, got %d", v)
+	}
+
+	// 空闲的线程阻塞等待,新的工作项不需要等到下一帧
+	done := make(chan time.Time, 1)
+	start := time.Now()
+	pool.Post(func() { done <- time.Now() })
+	select {
+	case ranAt := <-done:
+		if ranAt.Sub(start) > 10*time.Millisecond {
+			t.Fatalf("expected work item to run immediately, took %v", ranAt.Sub(start))
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected work item to run")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pool.Shutdown(ctx); err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
+	}
+}
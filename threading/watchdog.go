@@ -0,0 +1,262 @@
+package threading
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/abmpio/threadingx/lang"
+	"github.com/abmpio/threadingx/rescue"
+)
+
+const (
+	defaultWatchdogThreshold = time.Second
+)
+
+// 可以被Watchdog监视的线程,WorkItemThread与LogicThread都实现了此接口
+type IWatchableThread interface {
+	ID() string
+	// 执行工作项的协程id,没有运行时为0
+	GoroutineID() uint64
+	// 当前正在执行的帧的开始时间,不在帧中时为零值
+	CurrentFrameStartedAt() time.Time
+	// 最后一帧完成的时间
+	LastFrameAt() time.Time
+	IsRunning() bool
+}
+
+var _ IWatchableThread = (*WorkItemThread)(nil)
+var _ IWatchableThread = (*LogicThread)(nil)
+
+// 可选接口,设置了abortThreadTimeout的线程在独立的协程中执行工作项,报告卡住时使用这个协程的堆栈
+type iDelegatingThread interface {
+	// 正在代为执行工作项的协程id,没有时为0
+	DelegateGoroutineID() uint64
+}
+
+var _ iDelegatingThread = (*WorkItemThread)(nil)
+var _ iDelegatingThread = (*LogicThread)(nil)
+
+// 一帧的执行时间超过阈值时的报告
+type StallReport struct {
+	ThreadID    string `json:"threadId"`
+	// 执行卡住的帧的协程id,工作项由独立的协程代为执行时为这个协程的id
+	GoroutineID uint64 `json:"goroutineId"`
+	// 卡住的帧的开始时间
+	FrameStartedAt time.Time `json:"frameStartedAt"`
+	// 检测到时这一帧已经执行的时间
+	Duration time.Duration `json:"duration"`
+	// 执行卡住的帧的协程的堆栈
+	Stack string `json:"stack"`
+}
+
+type watchdogOptions struct {
+	threshold     time.Duration
+	checkInterval time.Duration
+	onStall       func(StallReport)
+}
+
+type WatchdogOption func(o *watchdogOptions)
+
+// 一帧的执行时间超过threshold时认为线程卡住,默认为1秒
+func WatchdogOptionWithThreshold(threshold time.Duration) WatchdogOption {
+	return func(o *watchdogOptions) {
+		if threshold > 0 {
+			o.threshold = threshold
+		}
+	}
+}
+
+// 检查的间隔时间,默认为threshold的四分之一
+func WatchdogOptionWithCheckInterval(checkInterval time.Duration) WatchdogOption {
+	return func(o *watchdogOptions) {
+		if checkInterval > 0 {
+			o.checkInterval = checkInterval
+		}
+	}
+}
+
+// 检测到线程卡住时的回调,每个卡住的帧只回调一次,回调运行在Watchdog的协程中
+// 没有设置时打印报告
+func WatchdogOptionWithStallCallback(onStall func(StallReport)) WatchdogOption {
+	return func(o *watchdogOptions) {
+		o.onStall = onStall
+	}
+}
+
+// 监视线程的帧执行时间,检测卡住的帧并提供健康检查
+type Watchdog struct {
+	*watchdogOptions
+	thread IWatchableThread
+
+	rwLock sync.RWMutex
+	// 已经报告过的卡住的帧的开始时间
+	reportedFrame time.Time
+	lastReport    *StallReport
+	stalled       lang.SafeBool
+	stopChan      chan struct{}
+}
+
+func NewWatchdog(thread IWatchableThread, opts ...WatchdogOption) *Watchdog {
+	options := &watchdogOptions{
+		threshold: defaultWatchdogThreshold,
+	}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+	if options.checkInterval <= 0 {
+		options.checkInterval = options.threshold / 4
+	}
+	return &Watchdog{
+		watchdogOptions: options,
+		thread:          thread,
+	}
+}
+
+// 启动监视协程
+func (w *Watchdog) Start() {
+	w.rwLock.Lock()
+	defer w.rwLock.Unlock()
+	if w.stopChan != nil {
+		return
+	}
+	w.stopChan = make(chan struct{})
+	go w.run(w.stopChan)
+}
+
+// 停止监视协程
+func (w *Watchdog) Stop() {
+	w.rwLock.Lock()
+	defer w.rwLock.Unlock()
+	if w.stopChan == nil {
+		return
+	}
+	close(w.stopChan)
+	w.stopChan = nil
+}
+
+func (w *Watchdog) run(stopChan chan struct{}) {
+	ticker := time.NewTicker(w.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopChan:
+			return
+		case now := <-ticker.C:
+			w.Check(now)
+		}
+	}
+}
+
+// 检查一次线程的状态,一般由监视协程定期调用
+func (w *Watchdog) Check(now time.Time) {
+	frameStartedAt := w.thread.CurrentFrameStartedAt()
+	if frameStartedAt.IsZero() || now.Sub(frameStartedAt) < w.threshold {
+		w.stalled.Set(false)
+		return
+	}
+	w.stalled.Set(true)
+
+	w.rwLock.Lock()
+	if w.reportedFrame.Equal(frameStartedAt) {
+		// 这一帧已经报告过
+		w.rwLock.Unlock()
+		return
+	}
+	w.reportedFrame = frameStartedAt
+	w.rwLock.Unlock()
+
+	goroutineID := w.thread.GoroutineID()
+	if delegating, ok := w.thread.(iDelegatingThread); ok {
+		// 逻辑协程只是在等待代为执行的协程,卡住的是工作项所在的协程
+		if delegateID := delegating.DelegateGoroutineID(); delegateID != 0 {
+			goroutineID = delegateID
+		}
+	}
+	report := StallReport{
+		ThreadID:       w.thread.ID(),
+		GoroutineID:    goroutineID,
+		FrameStartedAt: frameStartedAt,
+		Duration:       now.Sub(frameStartedAt),
+		Stack:          goroutineStack(goroutineID),
+	}
+	w.rwLock.Lock()
+	w.lastReport = &report
+	w.rwLock.Unlock()
+
+	if w.onStall == nil {
+		fmt.Printf("线程卡住,id:%s 当前帧已执行:%s\n%s\n", report.ThreadID, report.Duration, report.Stack)
+		return
+	}
+	func() {
+		defer rescue.Recover()
+		w.onStall(report)
+	}()
+}
+
+// 线程正在运行并且当前帧没有超过阈值
+func (w *Watchdog) Healthy() bool {
+	return w.thread.IsRunning() && !w.stalled.Get()
+}
+
+// 最后一帧完成的时间
+func (w *Watchdog) LastFrameAt() time.Time {
+	return w.thread.LastFrameAt()
+}
+
+// 最后一次卡住的报告,没有时返回nil
+func (w *Watchdog) LastStall() *StallReport {
+	w.rwLock.RLock()
+	defer w.rwLock.RUnlock()
+	return w.lastReport
+}
+
+// 用于存活探针的http.Handler
+func (w *Watchdog) LivenessHandler() http.Handler {
+	return NewLivenessHandler(w)
+}
+
+// 线程的健康状态
+type LivenessStatus struct {
+	ThreadID    string    `json:"threadId"`
+	Healthy     bool      `json:"healthy"`
+	Running     bool      `json:"running"`
+	LastFrameAt time.Time `json:"lastFrameAt"`
+	// 当前帧已经执行的时间,不在帧中时为0
+	CurrentFrameDuration time.Duration `json:"currentFrameDuration"`
+}
+
+// 用于Kubernetes等存活探针的http.Handler
+// 所有线程都健康时返回200,否则返回503,响应体为每个线程的LivenessStatus
+func NewLivenessHandler(watchdogs ...*Watchdog) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		healthy := true
+		statusList := make([]LivenessStatus, 0, len(watchdogs))
+		for _, eachWatchdog := range watchdogs {
+			status := LivenessStatus{
+				ThreadID:    eachWatchdog.thread.ID(),
+				Healthy:     eachWatchdog.Healthy(),
+				Running:     eachWatchdog.thread.IsRunning(),
+				LastFrameAt: eachWatchdog.LastFrameAt(),
+			}
+			if frameStartedAt := eachWatchdog.thread.CurrentFrameStartedAt(); !frameStartedAt.IsZero() {
+				status.CurrentFrameDuration = now.Sub(frameStartedAt)
+			}
+			if !status.Healthy {
+				healthy = false
+			}
+			statusList = append(statusList, status)
+		}
+
+		rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if healthy {
+			rw.WriteHeader(http.StatusOK)
+		} else {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(rw).Encode(statusList)
+	})
+}
@@ -0,0 +1,109 @@
+package threading
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func stallingWorkItem(block <-chan struct{}) {
+	<-block
+}
+
+func TestWatchdogReportsStalledFrame(t *testing.T) {
+	thread := NewLogicThread()
+	var stallOnce atomic.Bool
+	release := make(chan struct{})
+	thread.AttatchWorkItem(NewWorkItem(func() {
+		if stallOnce.CompareAndSwap(false, true) {
+			stallingWorkItem(release)
+		}
+	}))
+
+	reportChan := make(chan StallReport, 4)
+	watchdog := NewWatchdog(thread,
+		WatchdogOptionWithThreshold(30*time.Millisecond),
+		WatchdogOptionWithStallCallback(func(report StallReport) {
+			reportChan <- report
+		}))
+	thread.Start()
+	watchdog.Start()
+	defer watchdog.Stop()
+	defer thread.Shutdown(context.Background())
+
+	var report StallReport
+	select {
+	case report = <-reportChan:
+	case <-time.After(time.Second):
+		t.Fatalf("expected a stall report")
+	}
+	if report.GoroutineID == 0 || report.GoroutineID != thread.GoroutineID() {
+		t.Fatalf("expected the logic goroutine id, got %d", report.GoroutineID)
+	}
+	if !strings.Contains(report.Stack, "stallingWorkItem") {
+		t.Fatalf("expected the stack of the logic goroutine, got %s", report.Stack)
+	}
+	if watchdog.Healthy() {
+		t.Fatalf("expected a stalled thread to be unhealthy")
+	}
+	recorder := httptest.NewRecorder()
+	watchdog.LivenessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while stalled, got %d", recorder.Code)
+	}
+
+	close(release)
+	waitUntil(t, time.Second, watchdog.Healthy)
+	if watchdog.LastFrameAt().IsZero() {
+		t.Fatalf("expected the last frame time to be recorded")
+	}
+	recorder = httptest.NewRecorder()
+	watchdog.LivenessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 after recovering, got %d", recorder.Code)
+	}
+	select {
+	case <-reportChan:
+		t.Fatalf("expected the stalled frame to be reported only once")
+	default:
+	}
+}
+
+func TestWatchdogReportsDelegateGoroutineStack(t *testing.T) {
+	thread := NewLogicThread(ThreadOptionWithAbortThreadTimeoutMS(500))
+	var stallOnce atomic.Bool
+	release := make(chan struct{})
+	thread.AttatchWorkItem(NewWorkItem(func() {
+		if stallOnce.CompareAndSwap(false, true) {
+			stallingWorkItem(release)
+		}
+	}))
+
+	reportChan := make(chan StallReport, 4)
+	watchdog := NewWatchdog(thread,
+		WatchdogOptionWithThreshold(30*time.Millisecond),
+		WatchdogOptionWithStallCallback(func(report StallReport) {
+			reportChan <- report
+		}))
+	thread.Start()
+	watchdog.Start()
+	defer watchdog.Stop()
+	defer thread.Shutdown(context.Background())
+	defer close(release)
+
+	select {
+	case report := <-reportChan:
+		if report.GoroutineID == 0 || report.GoroutineID == thread.GoroutineID() {
+			t.Fatalf("expected the delegate goroutine id, got %d", report.GoroutineID)
+		}
+		if !strings.Contains(report.Stack, "stallingWorkItem") {
+			t.Fatalf("expected the stack of the delegate goroutine, got %s", report.Stack)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected a stall report")
+	}
+}
@@ -1,5 +1,7 @@
 package threading
 
+import "time"
+
 type IWorkItem interface {
 	// 工作项的描述，可为空
 	Description() string
@@ -8,6 +10,21 @@ type IWorkItem interface {
 	DoWork()
 }
 
+// 可选接口,工作项实现此接口后LogicThread可以判断其是否处于空闲状态
+// 没有实现此接口的工作项总是被认为是忙碌的
+type IIdleWorkItem interface {
+	// 是否处于空闲状态,运行在执行工作项的协程中
+	IsIdle() bool
+}
+
+// 可选接口,工作项实现此接口后可以在LogicThread暂停与恢复时得到通知,回调运行在逻辑线程中
+type IPausableWorkItem interface {
+	// 进入暂停状态
+	OnPaused(at time.Time)
+	// 离开暂停状态,stepping为true时表示只是单步执行,执行完成后将再次进入暂停状态
+	OnResumed(at time.Time, stepping bool)
+}
+
 var _ IWorkItem = (*WorkItem)(nil)
 var _ IWorkItem = (*WorkItemT[any])(nil)
 
@@ -9,3 +9,9 @@ type IWorkItemPool interface {
 	// 获取下一个工作项
 	GetNextWorkItem() []IWorkItem
 }
+
+// 可选接口,工作项池实现此接口后可以配合ThreadOptionWithIdleInterval进行空闲降频
+type IIdleWorkItemPool interface {
+	// 池中所有的工作项是否都处于空闲状态
+	IsIdle() bool
+}
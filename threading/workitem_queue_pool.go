@@ -0,0 +1,248 @@
+package threading
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// 可选接口,工作项池实现此接口后WorkItemThread将阻塞等待新的工作项,而不是每一帧轮询一次
+type IBlockingWorkItemPool interface {
+	// 阻塞直到有可用的工作项或者stopChan被关闭,stopChan被关闭时返回nil
+	WaitNextWorkItem(stopChan <-chan struct{}) []IWorkItem
+}
+
+type workItemQueuePoolOptions struct {
+	// 队列长度超过这个值时调用highWaterMarkCallback,<=0表示不检查
+	highWaterMark         int
+	highWaterMarkCallback func(length int)
+}
+
+type WorkItemQueuePoolOption func(o *workItemQueuePoolOptions)
+
+// 队列长度从不超过highWaterMark变为超过highWaterMark时调用callback
+// 回调运行在调用Put的协程中,不要在回调中阻塞
+func WorkItemQueuePoolOptionWithHighWaterMark(highWaterMark int, callback func(length int)) WorkItemQueuePoolOption {
+	return func(o *workItemQueuePoolOptions) {
+		o.highWaterMark = highWaterMark
+		o.highWaterMarkCallback = callback
+	}
+}
+
+// 工作项队列的统计信息
+type WorkItemQueuePoolMetrics struct {
+	// 当前队列中的工作项数量
+	Length int `json:"length"`
+	// 队列曾经达到的最大长度
+	MaxLength int `json:"maxLength"`
+	// 累计放入的工作项数量
+	Enqueued int64 `json:"enqueued"`
+	// 累计取出的工作项数量
+	Dequeued int64 `json:"dequeued"`
+	// 工作项在队列中的平均等待时间
+	AvgWait time.Duration `json:"avgWait"`
+	// 工作项在队列中的最大等待时间
+	MaxWait time.Duration `json:"maxWait"`
+}
+
+// 线程安全的工作项队列,优先级高的工作项先出队,相同优先级按先进先出的顺序出队
+type WorkItemQueuePool struct {
+	*workItemQueuePoolOptions
+
+	lock  sync.Mutex
+	queue workItemHeap
+	seq   uint64
+	// 有新的工作项时发出信号,容量为1,等待者取出工作项后如果队列仍不为空会继续传递信号
+	signal chan struct{}
+
+	maxLength     int
+	enqueued      int64
+	dequeued      int64
+	totalWait     time.Duration
+	maxWait       time.Duration
+	overWaterMark bool
+}
+
+var _ IWorkItemPool = (*WorkItemQueuePool)(nil)
+var _ IBlockingWorkItemPool = (*WorkItemQueuePool)(nil)
+
+func NewWorkItemQueuePool(opts ...WorkItemQueuePoolOption) *WorkItemQueuePool {
+	options := &workItemQueuePoolOptions{}
+	for _, eachOpt := range opts {
+		eachOpt(options)
+	}
+	return &WorkItemQueuePool{
+		workItemQueuePoolOptions: options,
+		queue:                    make(workItemHeap, 0),
+		signal:                   make(chan struct{}, 1),
+	}
+}
+
+// 以默认优先级0放入一个工作项
+func (p *WorkItemQueuePool) Put(workItem IWorkItem) {
+	p.PutWithPriority(workItem, 0)
+}
+
+// 放入一个工作项,priority越大越先执行
+func (p *WorkItemQueuePool) PutWithPriority(workItem IWorkItem, priority int) {
+	if workItem == nil {
+		return
+	}
+	p.lock.Lock()
+	p.seq++
+	heap.Push(&p.queue, &queuedWorkItem{
+		workItem:   workItem,
+		priority:   priority,
+		seq:        p.seq,
+		enqueuedAt: time.Now(),
+	})
+	p.enqueued++
+	length := len(p.queue)
+	if length > p.maxLength {
+		p.maxLength = length
+	}
+	crossed := false
+	if p.highWaterMark > 0 {
+		if length > p.highWaterMark && !p.overWaterMark {
+			p.overWaterMark = true
+			crossed = true
+		}
+	}
+	p.lock.Unlock()
+
+	p.notify()
+	if crossed && p.highWaterMarkCallback != nil {
+		p.highWaterMarkCallback(length)
+	}
+}
+
+// 获取统计信息
+func (p *WorkItemQueuePool) Metrics() WorkItemQueuePoolMetrics {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	metrics := WorkItemQueuePoolMetrics{
+		Length:    len(p.queue),
+		MaxLength: p.maxLength,
+		Enqueued:  p.enqueued,
+		Dequeued:  p.dequeued,
+		MaxWait:   p.maxWait,
+	}
+	if p.dequeued > 0 {
+		metrics.AvgWait = p.totalWait / time.Duration(p.dequeued)
+	}
+	return metrics
+}
+
+func (p *WorkItemQueuePool) notify() {
+	select {
+	case p.signal <- struct{}{}:
+	default:
+	}
+}
+
+// 取出一个工作项,队列为空时返回nil
+func (p *WorkItemQueuePool) take() IWorkItem {
+	p.lock.Lock()
+	if len(p.queue) <= 0 {
+		p.lock.Unlock()
+		return nil
+	}
+	item := heap.Pop(&p.queue).(*queuedWorkItem)
+	wait := time.Since(item.enqueuedAt)
+	p.dequeued++
+	p.totalWait += wait
+	if wait > p.maxWait {
+		p.maxWait = wait
+	}
+	if p.overWaterMark && len(p.queue) <= p.highWaterMark {
+		p.overWaterMark = false
+	}
+	remaining := len(p.queue)
+	p.lock.Unlock()
+
+	if remaining > 0 {
+		// 唤醒其它的等待者继续处理
+		p.notify()
+	}
+	return item.workItem
+}
+
+// #region IWorkItemPool Members
+
+func (p *WorkItemQueuePool) WorkItemIsList() bool {
+	return false
+}
+
+// 获取队列中等待执行的工作项数量
+func (p *WorkItemQueuePool) GetWorkItemQueueCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.queue)
+}
+
+// 获取下一个工作项,队列为空时返回nil
+func (p *WorkItemQueuePool) GetNextWorkItem() []IWorkItem {
+	workItem := p.take()
+	if workItem == nil {
+		return nil
+	}
+	return []IWorkItem{workItem}
+}
+
+// #endregion
+
+// #region IBlockingWorkItemPool Members
+
+func (p *WorkItemQueuePool) WaitNextWorkItem(stopChan <-chan struct{}) []IWorkItem {
+	for {
+		if workItem := p.take(); workItem != nil {
+			return []IWorkItem{workItem}
+		}
+		select {
+		case <-p.signal:
+		case <-stopChan:
+			return nil
+		}
+	}
+}
+
+// #endregion
+
+type queuedWorkItem struct {
+	workItem   IWorkItem
+	priority   int
+	seq        uint64
+	enqueuedAt time.Time
+}
+
+// 按优先级从高到低、相同优先级按放入顺序排列的堆
+type workItemHeap []*queuedWorkItem
+
+func (h workItemHeap) Len() int {
+	return len(h)
+}
+
+func (h workItemHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h workItemHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *workItemHeap) Push(x any) {
+	*h = append(*h, x.(*queuedWorkItem))
+}
+
+func (h *workItemHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
@@ -1,9 +1,11 @@
 package threading
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/abmpio/threadingx/lang"
@@ -19,6 +21,16 @@ type workItemThreadOptions struct {
 	warningWhenWorkItemDurationMs int64
 	//线程每个方法执行的间隔时间
 	threadWorkItemInterval time.Duration
+	// 落后于帧截止时间时的处理策略
+	framePolicy FramePolicy
+	// FramePolicyCatchUp时最多连续追赶的帧数
+	maxCatchUpFrames int
+	// 距离帧截止时间小于这个值时改为自旋等待,0表示只睡眠
+	spinThreshold time.Duration
+	// 工作项池空闲时的帧间隔,0表示不启用空闲降频
+	idleInterval time.Duration
+	// 工作项连续超时达到这个次数后被隔离,0表示不隔离
+	quarantineAfterTimeouts int
 }
 
 func newWorkItemThreadOptions() *workItemThreadOptions {
@@ -27,6 +39,8 @@ func newWorkItemThreadOptions() *workItemThreadOptions {
 		abortThreadTimeout:            time.Duration(0),
 		warningWhenWorkItemDurationMs: 500,
 		threadWorkItemInterval:        16 * time.Millisecond,
+		framePolicy:                   FramePolicySkip,
+		maxCatchUpFrames:              defaultMaxCatchUpFrames,
 	}
 }
 
@@ -44,6 +58,75 @@ func ThreadOptionWithName(id string) ThreadOption {
 	}
 }
 
+// 设置每一帧的间隔时间,默认为16ms
+func ThreadOptionWithInterval(interval time.Duration) ThreadOption {
+	return func(o *workItemThreadOptions) {
+		if interval > 0 {
+			o.threadWorkItemInterval = interval
+		}
+	}
+}
+
+// 设置每秒的帧数,等同于ThreadOptionWithInterval(time.Second / fps)
+func ThreadOptionWithFPS(fps int) ThreadOption {
+	return func(o *workItemThreadOptions) {
+		if fps > 0 {
+			o.threadWorkItemInterval = time.Second / time.Duration(fps)
+		}
+	}
+}
+
+// 设置落后于帧截止时间时的处理策略,默认为FramePolicySkip
+// maxCatchUpFrames 只对FramePolicyCatchUp有效,<=0时使用默认值5
+func ThreadOptionWithFramePolicy(policy FramePolicy, maxCatchUpFrames int) ThreadOption {
+	return func(o *workItemThreadOptions) {
+		o.framePolicy = policy
+		if maxCatchUpFrames > 0 {
+			o.maxCatchUpFrames = maxCatchUpFrames
+		}
+	}
+}
+
+// 工作项连续超时maxTimeouts次后被隔离,不再执行,直到调用ReleaseQuarantine
+// 只在设置了abortThreadTimeout时有效
+func ThreadOptionWithQuarantine(maxTimeouts int) ThreadOption {
+	return func(o *workItemThreadOptions) {
+		if maxTimeouts >= 0 {
+			o.quarantineAfterTimeouts = maxTimeouts
+		}
+	}
+}
+
+// 启用空闲降频,当工作项池(实现了IIdleWorkItemPool)处于空闲状态时,每idleInterval执行一帧
+// 调用WorkItemThread.Wake后立即恢复到正常的帧率
+func ThreadOptionWithIdleInterval(idleInterval time.Duration) ThreadOption {
+	return func(o *workItemThreadOptions) {
+		if idleInterval >= 0 {
+			o.idleInterval = idleInterval
+		}
+	}
+}
+
+// 启用空闲降频,等同于ThreadOptionWithIdleInterval(time.Second / fps)
+func ThreadOptionWithIdleFPS(fps int) ThreadOption {
+	return func(o *workItemThreadOptions) {
+		if fps > 0 {
+			o.idleInterval = time.Second / time.Duration(fps)
+		}
+	}
+}
+
+// 启用睡眠与自旋混合的等待方式,距离帧截止时间小于spinThreshold时改为自旋
+// 系统计时器的精度通常在毫秒级别,自旋可以获得亚毫秒级的精度,代价是额外的cpu占用
+// 一般设置为1~2ms即可
+func ThreadOptionWithSpinThreshold(spinThreshold time.Duration) ThreadOption {
+	return func(o *workItemThreadOptions) {
+		if spinThreshold >= 0 {
+			o.spinThreshold = spinThreshold
+		}
+	}
+}
+
 type WorkItemThread struct {
 	*workItemThreadOptions
 	pool IWorkItemPool
@@ -52,6 +135,44 @@ type WorkItemThread struct {
 	_running   bool
 	rw         sync.RWMutex
 	_lastStart *time.Time
+	// Stop时关闭,用于打断帧之间的等待
+	stopChan chan struct{}
+	// 协程退出时关闭
+	doneChan chan struct{}
+	// 因为落后而被丢弃的帧数
+	skippedFrames int64
+	// 用于在空闲降频时唤醒线程
+	wakeChan chan struct{}
+	// 当前是否处于空闲降频状态
+	idling lang.SafeBool
+
+	// 执行工作项的协程id
+	goroutineID atomic.Uint64
+	// 设置了abortThreadTimeout时工作项在独立的协程中执行,这里记录正在代为执行的协程id
+	delegateGoroutineID atomic.Uint64
+	// 当前帧开始的时间(UnixNano),不在帧中时为0
+	frameStartedAt atomic.Int64
+	// 最后一帧完成的时间(UnixNano)
+	lastFrameAt atomic.Int64
+	// 当前帧计划执行的时间(UnixNano),即帧的截止时间,不在帧中或者阻塞模式下为0
+	frameTime atomic.Int64
+
+	// 保护暂停相关的状态
+	pauseLock sync.Mutex
+	paused    bool
+	// 暂停时还可以执行的帧数
+	stepBudget int
+	// Resume与Step时发出信号
+	controlChan chan struct{}
+
+	// 保护以下超时相关的状态
+	abandonLock sync.Mutex
+	// 超时后被放弃但仍在运行的工作项
+	abandonedList map[IWorkItem]*AbandonedWorkItem
+	// 工作项连续超时的次数
+	timeoutCountList map[IWorkItem]int
+	// 被隔离的工作项
+	quarantineList map[IWorkItem]struct{}
 }
 
 // new NewWorkItemThread instance
@@ -68,6 +189,13 @@ func NewWorkItemThread(pool IWorkItemPool, opts ...ThreadOption) *WorkItemThread
 		_shutdown: lang.SafeBool{},
 		_running:  false,
 		rw:        sync.RWMutex{},
+		wakeChan:  make(chan struct{}, 1),
+
+		controlChan: make(chan struct{}, 1),
+
+		abandonedList:    make(map[IWorkItem]*AbandonedWorkItem),
+		timeoutCountList: make(map[IWorkItem]int),
+		quarantineList:   make(map[IWorkItem]struct{}),
 	}
 	return t
 }
@@ -80,19 +208,239 @@ func (t *WorkItemThread) SetOptions(opts ...ThreadOption) {
 	}
 }
 
+// 线程的id,可以通过ThreadOptionWithName设置
+func (t *WorkItemThread) ID() string {
+	return t.id
+}
+
 // 获取最后一个工作项启动时间
 func (t *WorkItemThread) LastWorkItemStartTime() *time.Time {
 	return t._lastStart
 }
 
-// 时间间隔(毫秒)，默认为16ms，即每秒60秒
+// 时间间隔(毫秒)，默认为16ms，即每秒60帧
 func (t *WorkItemThread) ThreadWorkItemIntervalMs() int64 {
-	return t.threadWorkItemInterval.Microseconds()
+	return t.threadWorkItemInterval.Milliseconds()
+}
+
+// 因为落后于帧截止时间而被丢弃的帧数
+func (t *WorkItemThread) SkippedFrames() int64 {
+	return atomic.LoadInt64(&t.skippedFrames)
+}
+
+// 唤醒处于空闲降频状态的线程,立即执行下一帧并恢复到正常的帧率
+// 可以在任意协程中调用,线程不处于空闲状态时没有任何影响
+func (t *WorkItemThread) Wake() {
+	select {
+	case t.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// 暂停执行帧,当前正在执行的帧会执行完成
+// 只对按帧执行的工作项池有效,阻塞等待的工作项池(如WorkItemQueuePool)不受影响
+func (t *WorkItemThread) Pause() {
+	t.pauseLock.Lock()
+	defer t.pauseLock.Unlock()
+	t.paused = true
+	t.stepBudget = 0
+}
+
+// 恢复执行帧,下一帧立即执行
+func (t *WorkItemThread) Resume() {
+	t.pauseLock.Lock()
+	t.paused = false
+	t.stepBudget = 0
+	t.pauseLock.Unlock()
+	t.signalControl()
+}
+
+// 暂停状态下再执行n帧,之后保持暂停;没有暂停时不做任何事情
+func (t *WorkItemThread) Step(n int) {
+	if n <= 0 {
+		return
+	}
+	t.pauseLock.Lock()
+	if !t.paused {
+		t.pauseLock.Unlock()
+		return
+	}
+	t.stepBudget += n
+	t.pauseLock.Unlock()
+	t.signalControl()
+}
+
+// 是否处于暂停状态
+func (t *WorkItemThread) Paused() bool {
+	t.pauseLock.Lock()
+	defer t.pauseLock.Unlock()
+	return t.paused
+}
+
+func (t *WorkItemThread) signalControl() {
+	select {
+	case t.controlChan <- struct{}{}:
+	default:
+	}
+}
+
+// 是否可以执行下一帧,暂停时消耗一个单步的帧数
+func (t *WorkItemThread) takeFrame() bool {
+	t.pauseLock.Lock()
+	defer t.pauseLock.Unlock()
+	if !t.paused {
+		return true
+	}
+	if t.stepBudget > 0 {
+		t.stepBudget--
+		return true
+	}
+	return false
+}
+
+// 暂停时阻塞直到恢复、单步或者停止,stopChan被关闭时返回false
+// 进入与离开暂停时通知实现了IPausableWorkItem的工作项
+func (t *WorkItemThread) waitWhilePaused(stopChan chan struct{}, pacer *framePacer) bool {
+	if t.takeFrame() {
+		return true
+	}
+	t.notifyPaused(time.Now())
+	for {
+		select {
+		case <-stopChan:
+			return false
+		case <-t.controlChan:
+		}
+		if t.takeFrame() {
+			now := time.Now()
+			t.notifyResumed(now, t.Paused())
+			// 恢复后立即执行这一帧,之后的帧以此重新对齐
+			pacer.restart(now)
+			return true
+		}
+	}
+}
+
+func (t *WorkItemThread) notifyPaused(at time.Time) {
+	if !t.pool.WorkItemIsList() {
+		return
+	}
+	for _, eachItem := range t.pool.GetNextWorkItem() {
+		if pausable, ok := eachItem.(IPausableWorkItem); ok {
+			func() {
+				defer rescue.Recover()
+				pausable.OnPaused(at)
+			}()
+		}
+	}
 }
 
-// 停此线程
+func (t *WorkItemThread) notifyResumed(at time.Time, stepping bool) {
+	if !t.pool.WorkItemIsList() {
+		return
+	}
+	for _, eachItem := range t.pool.GetNextWorkItem() {
+		if pausable, ok := eachItem.(IPausableWorkItem); ok {
+			func() {
+				defer rescue.Recover()
+				pausable.OnResumed(at, stepping)
+			}()
+		}
+	}
+}
+
+// 当前是否处于空闲降频状态
+func (t *WorkItemThread) Idling() bool {
+	return t.idling.Get()
+}
+
+// 执行工作项的协程id,线程没有运行时为0
+func (t *WorkItemThread) GoroutineID() uint64 {
+	return t.goroutineID.Load()
+}
+
+// 设置了abortThreadTimeout时正在代为执行工作项的协程id,没有时为0
+func (t *WorkItemThread) DelegateGoroutineID() uint64 {
+	return t.delegateGoroutineID.Load()
+}
+
+// 当前代码是否运行在这个线程的协程中(包括设置了abortThreadTimeout时代为执行工作项的协程)
+func (t *WorkItemThread) IsCurrent() bool {
+	id := currentGoroutineID()
+	if id == 0 {
+		return false
+	}
+	return id == t.goroutineID.Load() || id == t.delegateGoroutineID.Load()
+}
+
+// 当前正在执行的帧的开始时间,不在帧中时为零值
+func (t *WorkItemThread) CurrentFrameStartedAt() time.Time {
+	v := t.frameStartedAt.Load()
+	if v == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, v)
+}
+
+// 当前正在执行的帧计划执行的时间,即这一帧的截止时间,不在帧中或者阻塞模式下为零值
+// 落后于截止时间时这个值早于实际的执行时间,FramePolicyCatchUp追赶的帧之间相差一个帧间隔
+func (t *WorkItemThread) CurrentFrameTime() time.Time {
+	v := t.frameTime.Load()
+	if v == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, v)
+}
+
+// 最后一帧完成的时间,还没有完成过任何一帧时为零值
+func (t *WorkItemThread) LastFrameAt() time.Time {
+	v := t.lastFrameAt.Load()
+	if v == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, v)
+}
+
+// 线程是否正在运行
+func (t *WorkItemThread) IsRunning() bool {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+	return t._running
+}
+
+// 停此线程,只是发出停止的信号,不等待协程退出
 func (t *WorkItemThread) Stop() {
+	t.rw.Lock()
+	defer t.rw.Unlock()
+
 	t._shutdown.Set(true)
+	if t.stopChan != nil {
+		close(t.stopChan)
+		t.stopChan = nil
+	}
+}
+
+// 等待协程执行完当前帧并退出,ctx到期时返回ctx的错误
+// 线程没有启动过时立即返回
+func (t *WorkItemThread) Wait(ctx context.Context) error {
+	t.rw.RLock()
+	doneChan := t.doneChan
+	t.rw.RUnlock()
+	if doneChan == nil {
+		return nil
+	}
+	select {
+	case <-doneChan:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 停止线程并等待协程退出
+func (t *WorkItemThread) Shutdown(ctx context.Context) error {
+	t.Stop()
+	return t.Wait(ctx)
 }
 
 // 启动线程
@@ -106,58 +454,138 @@ func (t *WorkItemThread) Start() {
 	}
 	t._running = true
 	t._shutdown.Set(false)
+	t.stopChan = make(chan struct{})
+	t.doneChan = make(chan struct{})
 	//创建协程并等待协程被启动
-	t.createGoRoutine()
+	t.createGoRoutine(t.stopChan, t.doneChan)
 }
 
 // 启动协程
-func (t *WorkItemThread) createGoRoutine() {
+func (t *WorkItemThread) createGoRoutine(stopChan chan struct{}, doneChan chan struct{}) {
 	go func() {
-		t.workToDo()
+		defer close(doneChan)
+		t.workToDo(stopChan)
 	}()
 }
 
-func (t *WorkItemThread) workToDo() {
+func (t *WorkItemThread) workToDo(stopChan chan struct{}) {
+	t.goroutineID.Store(currentGoroutineID())
+	defer t.goroutineID.Store(0)
+
+	if blockingPool, ok := t.pool.(IBlockingWorkItemPool); ok {
+		t.workToDoBlocking(blockingPool, stopChan)
+	} else {
+		t.workToDoPaced(stopChan)
+	}
+
+	t.rw.Lock()
+	t._running = false
+	t.rw.Unlock()
+}
+
+// 阻塞等待工作项池中的工作项,有工作项时立即执行
+func (t *WorkItemThread) workToDoBlocking(pool IBlockingWorkItemPool, stopChan chan struct{}) {
+	for !t._shutdown.Get() {
+		nextWorkItems := pool.WaitNextWorkItem(stopChan)
+		if len(nextWorkItems) <= 0 {
+			// stopChan已经关闭
+			break
+		}
+		// 阻塞模式下每一批工作项视为一帧
+		t.beginFrame()
+		for _, eachWorkItem := range nextWorkItems {
+			t.doWorkItem(eachWorkItem)
+		}
+		t.endFrame()
+	}
+}
+
+// 按帧执行工作项池中的工作项
+func (t *WorkItemThread) workToDoPaced(stopChan chan struct{}) {
+	pacer := newFramePacer(t.workItemThreadOptions)
+	defer pacer.stop()
+	pacer.start(time.Now())
 
 	for !t._shutdown.Get() {
-		// 等待时间片断，默认为16毫秒，即每秒60帧
-		time.Sleep(t.threadWorkItemInterval)
-		nextWorkItems := t.pool.GetNextWorkItem()
-		for {
-			if len(nextWorkItems) <= 0 {
+		// 等待到这一帧的截止时间，默认每16毫秒一帧，即每秒60帧
+		if !pacer.wait(stopChan, t.wakeChan) || t._shutdown.Get() {
+			break
+		}
+		if !t.waitWhilePaused(stopChan, pacer) || t._shutdown.Get() {
+			break
+		}
+		t.frameTime.Store(pacer.deadline.UnixNano())
+		t.beginFrame()
+		t.doFrame()
+		t.endFrame()
+		idle := t.isPoolIdle()
+		if skipped := pacer.advance(time.Now(), idle); skipped > 0 {
+			atomic.AddInt64(&t.skippedFrames, skipped)
+		}
+		t.idling.Set(pacer.idle)
+	}
+	t.idling.Set(false)
+}
+
+func (t *WorkItemThread) beginFrame() {
+	t.frameStartedAt.Store(time.Now().UnixNano())
+}
+
+func (t *WorkItemThread) endFrame() {
+	t.lastFrameAt.Store(time.Now().UnixNano())
+	t.frameStartedAt.Store(0)
+	t.frameTime.Store(0)
+}
+
+// 工作项池是否处于空闲状态,没有启用空闲降频时总是返回false
+func (t *WorkItemThread) isPoolIdle() bool {
+	if t.idleInterval <= 0 {
+		return false
+	}
+	idlePool, ok := t.pool.(IIdleWorkItemPool)
+	if !ok {
+		return false
+	}
+	// 先清除之前的唤醒信号再检查,检查之后到达的唤醒信号会保留到下一次等待
+	select {
+	case <-t.wakeChan:
+	default:
+	}
+	return idlePool.IsIdle()
+}
+
+// 执行一帧
+func (t *WorkItemThread) doFrame() {
+	nextWorkItems := t.pool.GetNextWorkItem()
+	for {
+		if len(nextWorkItems) <= 0 {
+			break
+		}
+		if !t.pool.WorkItemIsList() {
+			if !t._shutdown.Get() {
+				t.doWorkItem(nextWorkItems[0])
+				nextWorkItems = t.pool.GetNextWorkItem()
+			} else {
+				//已经shutdown，则直接退出
 				break
 			}
-			if !t.pool.WorkItemIsList() {
-				count := t.pool.GetWorkItemQueueCount()
-				if count > 10 {
-					fmt.Printf("线程池中堆积未处理的线程已经超过10个,当前数量:%d", count)
-				}
+		} else {
+			//一次拿多个工作项的，则循环拿到的工作项列表，如LogicThread就是这种方式
+			for i := 0; i < len(nextWorkItems); i++ {
 				if !t._shutdown.Get() {
-					t.doWorkItem(nextWorkItems[0])
-					nextWorkItems = t.pool.GetNextWorkItem()
-				} else {
-					//已经shutdown，则直接退出
-					break
+					t.doWorkItem(nextWorkItems[i])
 				}
-			} else {
-				//一次拿多个工作项的，则循环拿到的工作项列表，如LogicThread就是这种方式
-				for i := 0; i < len(nextWorkItems); i++ {
-					if !t._shutdown.Get() {
-						t.doWorkItem(nextWorkItems[i])
-					}
-				}
-				//跳出循环，等待下一次进来
-				break
 			}
+			//跳出循环，等待下一次进来
+			break
 		}
 	}
-
-	t.rw.Lock()
-	t._running = false
-	t.rw.Unlock()
 }
 
 func (t *WorkItemThread) doWorkItem(workItem IWorkItem) {
+	if !t.canDoWorkItem(workItem) {
+		return
+	}
 	t.rw.Lock()
 	now := time.Now()
 	t._lastStart = &now
@@ -168,17 +596,14 @@ func (t *WorkItemThread) doWorkItem(workItem IWorkItem) {
 	if t.abortThreadTimeout <= 0 || t.abortThreadTimeout == math.MaxInt64 {
 		workItem.DoWork()
 	} else {
-		err := DoWithTimeout(func() error {
-			workItem.DoWork()
-			return nil
-		}, t.abortThreadTimeout)
+		err := t.doWorkItemWithTimeout(workItem, now)
 		if err != nil {
 			fmt.Printf("doWorkItem timeout,id:%s,err:%s",
 				t.id,
 				err.Error())
 		}
 	}
-	workItemDurationMs := time.Since(*t._lastStart).Milliseconds()
+	workItemDurationMs := time.Since(now).Milliseconds()
 	if workItemDurationMs >= t.warningWhenWorkItemDurationMs {
 		fmt.Printf("线程性能警报,id:%s 任务耗时过长,任务: %s 耗时ms:%d",
 			t.id,
@@ -2,6 +2,7 @@ package timelinex
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/abmpio/threadingx/collection"
@@ -38,13 +39,47 @@ type timeline struct {
 	registedOneTimeObserverQueue *collection.Queue
 	//一直订阅的observer列表
 	registedObserverList []ITimelineObserver
+	// 与registedObserverList一一对应的统计信息
+	registedObserverStatList []*timelineObserverStat
 	// 这个列表来源于registedObserverList，不直接使用registedObserverList是防止多线程下的安全性
-	workingObserverList []ITimelineObserver
-	rwLock              sync.RWMutex
+	workingObserverList     []ITimelineObserver
+	workingObserverStatList []*timelineObserverStat
+	rwLock                  sync.RWMutex
 
-	isChanged          lang.SafeBool
+	isChanged lang.SafeBool
+	// 关闭后不再接受新的订阅
+	closed             lang.SafeBool
 	previousUpdateTime *time.Time
 	scenseTimer        ISceneTimer
+
+	// 一次性observer队列中等待执行的数量
+	oneTimeQueueLength int64
+	// 有新的订阅或者一次性observer时调用,用于唤醒处于空闲降频状态的逻辑线程
+	waker     func()
+	frameStat timelineFrameStat
+	// 是否有调试信息的请求正在等待时间轴线程获取observer的描述,同一时间最多只有一个
+	describing atomic.Bool
+	// 执行这个时间轴的逻辑线程,用于线程亲和性检查
+	logicThread *threading.LogicThread
+
+	// 保护暂停相关的状态
+	pauseLock sync.Mutex
+	// 逻辑线程暂停的时间,恢复时从帧间隔中扣除暂停的时长
+	pausedAt time.Time
+	// 暂停期间计时器的派发暂存在heldTimerList中,恢复后放入一次性observer队列
+	holdingTimers bool
+	heldTimerList []heldTimerDispatch
+}
+
+var _ threading.IIdleWorkItem = (*timeline)(nil)
+var _ IThreadAffinity = (*timeline)(nil)
+var _ threading.IPausableWorkItem = (*timeline)(nil)
+
+// 暂停期间到期的计时器派发
+type heldTimerDispatch struct {
+	key       string
+	recurring bool
+	observer  ITimelineObserver
 }
 
 func newTimeline() *timeline {
@@ -53,7 +88,9 @@ func newTimeline() *timeline {
 
 		registedOneTimeObserverQueue: collection.NewQueue(10),
 		registedObserverList:         make([]ITimelineObserver, 0),
+		registedObserverStatList:     make([]*timelineObserverStat, 0),
 		workingObserverList:          make([]ITimelineObserver, 0),
+		workingObserverStatList:      make([]*timelineObserverStat, 0),
 		rwLock:                       sync.RWMutex{},
 
 		isChanged: lang.SafeBool{},
@@ -61,6 +98,28 @@ func newTimeline() *timeline {
 	return timelineService
 }
 
+func (t *timeline) setLogicThread(logicThread *threading.LogicThread) {
+	t.logicThread = logicThread
+}
+
+// 当前代码是否运行在执行这个时间轴的逻辑线程中,没有关联逻辑线程时总是返回true
+func (t *timeline) IsOnLogicThread() bool {
+	if t.logicThread == nil {
+		return true
+	}
+	return t.logicThread.IsCurrent()
+}
+
+func (t *timeline) setWaker(waker func()) {
+	t.waker = waker
+}
+
+func (t *timeline) wake() {
+	if t.waker != nil {
+		t.waker()
+	}
+}
+
 func (t *timeline) setSceneTimer(timer ISceneTimer) {
 	t.scenseTimer = timer
 }
@@ -69,30 +128,32 @@ func (t *timeline) setSceneTimer(timer ISceneTimer) {
 
 // 订阅时间轴轮轮循通知，以用来接收时间轴通知，这里订阅的将会一直工作在这个时间轴中，直到调用Unsubscribe(ITimelineObserver)方法取消订阅为止
 func (t *timeline) Subscribe(timelineObserver ITimelineObserver) ITimelineObserver {
-	if timelineObserver == nil {
+	if timelineObserver == nil || t.closed.Get() {
 		return nil
 	}
 	t.rwLock.Lock()
-	defer t.rwLock.Unlock()
-
 	t.registedObserverList = append(t.registedObserverList, timelineObserver)
+	t.registedObserverStatList = append(t.registedObserverStatList, newTimelineObserverStat())
 	t.isChanged.Set(true)
+	t.rwLock.Unlock()
+
+	t.wake()
 	return timelineObserver
 }
 
 // 订阅时间轴轮轮循通知，只通知一次，通知到达一次后在下次的时间轴中将不会再次通知，此方法会自动执行取消订阅
 func (t *timeline) SubscribeAsOneTime(timelineObserver ITimelineObserver, delayTime *time.Duration) {
-	if timelineObserver == nil {
+	if timelineObserver == nil || t.closed.Get() {
 		return
 	}
 
 	if delayTime == nil || delayTime.Milliseconds() <= 0 {
 		//立即执行，不延时
-		t.registedOneTimeObserverQueue.Put(timelineObserver)
+		t.enqueueOneTimeObserver(timelineObserver)
 		return
 	}
 	t.scenseTimer.StartNewOneTimer(*delayTime, func() {
-		t.registedOneTimeObserverQueue.Put(timelineObserver)
+		t.enqueueOneTimeObserver(timelineObserver)
 	})
 }
 
@@ -116,6 +177,7 @@ func (t *timeline) Unsubscribe(timelineObserver ITimelineObserver) {
 	}
 	if removeIndex > -1 {
 		t.registedObserverList = removeSliceByIndex(t.registedObserverList, removeIndex)
+		t.registedObserverStatList = removeSliceByIndex(t.registedObserverStatList, removeIndex)
 		// 标记工作快照过期，让下一个 tick 重新构建 workingObserverList。
 		// 否则已退订的 observer 仍可能继续留在轮循快照中。
 		t.isChanged.Set(true)
@@ -124,6 +186,11 @@ func (t *timeline) Unsubscribe(timelineObserver ITimelineObserver) {
 
 // #endregion
 
+// 不再接受新的订阅,已有的订阅与一次性observer队列继续工作
+func (t *timeline) close() {
+	t.closed.Set(true)
+}
+
 // #region threading.IWorkItem Members
 
 func (t *timeline) Description() string {
@@ -133,14 +200,85 @@ func (t *timeline) Description() string {
 // 通知observer
 func (t *timeline) DoWork() {
 	now := time.Now()
+	// 以帧计划执行的时间计算间隔,FramePolicyCatchUp连续追赶的帧得到的是帧间隔而不是接近0的值
+	frameTime := now
+	if t.logicThread != nil {
+		if v := t.logicThread.CurrentFrameTime(); !v.IsZero() {
+			frameTime = v
+		}
+	}
 	if t.previousUpdateTime == nil {
-		t.previousUpdateTime = &now
+		t.previousUpdateTime = &frameTime
 	}
 	lastTime := t.previousUpdateTime
-	t.previousUpdateTime = &now
-	duration := time.Since(*lastTime)
+	t.previousUpdateTime = &frameTime
+	duration := frameTime.Sub(*lastTime)
+	if duration < 0 {
+		duration = 0
+	}
 	// 通知各个observer
 	t._notifyRegistedObserver(float64(duration.Milliseconds()))
+	t.frameStat.record(now, float64(duration.Milliseconds()), time.Since(now))
+}
+
+// #endregion
+
+// #region threading.IPausableWorkItem Members
+
+// 逻辑线程进入暂停状态,运行在逻辑线程中
+func (t *timeline) OnPaused(at time.Time) {
+	AssertOnLogicThread(t)
+	t.pauseLock.Lock()
+	defer t.pauseLock.Unlock()
+	t.holdingTimers = true
+	t.pausedAt = at
+}
+
+// 逻辑线程离开暂停状态,运行在逻辑线程中
+// 暂停的时长不计入下一帧的间隔,单步执行时计时器的派发仍然暂存
+func (t *timeline) OnResumed(at time.Time, stepping bool) {
+	AssertOnLogicThread(t)
+	t.pauseLock.Lock()
+	if t.previousUpdateTime != nil && !t.pausedAt.IsZero() {
+		previousUpdateTime := t.previousUpdateTime.Add(at.Sub(t.pausedAt))
+		t.previousUpdateTime = &previousUpdateTime
+	}
+	t.pausedAt = time.Time{}
+	if stepping {
+		t.pauseLock.Unlock()
+		return
+	}
+	t.holdingTimers = false
+	heldTimerList := t.heldTimerList
+	t.heldTimerList = nil
+	t.pauseLock.Unlock()
+
+	for _, eachHeld := range heldTimerList {
+		t.enqueueOneTimeObserver(eachHeld.observer)
+	}
+}
+
+// #endregion
+
+// #region threading.IIdleWorkItem Members
+
+// 一次性observer队列为空,并且所有订阅的observer都实现了IIdleTimelineObserver且处于空闲状态
+// 没有任何订阅时也认为是空闲的
+func (t *timeline) IsIdle() bool {
+	if atomic.LoadInt64(&t.oneTimeQueueLength) > 0 {
+		return false
+	}
+	t.rwLock.RLock()
+	// Unsubscribe会原地修改registedObserverList,这里使用独立的快照
+	observerList := append([]ITimelineObserver(nil), t.registedObserverList...)
+	t.rwLock.RUnlock()
+	for _, eachObserver := range observerList {
+		idleObserver, ok := eachObserver.(IIdleTimelineObserver)
+		if !ok || !idleObserver.IsIdle() {
+			return false
+		}
+	}
+	return true
 }
 
 // #endregion
@@ -149,12 +287,14 @@ func (t *timeline) DoWork() {
 // / 通知所有的订阅者
 // / </summary>
 func (t *timeline) _notifyRegistedObserver(deltaMS float64) {
+	AssertOnLogicThread(t)
 	if t.isChanged.Get() {
 		// 已经改变
 		t.rwLock.Lock()
 		// 使用独立快照，避免 workingObserverList 与 registedObserverList 共享底层数组。
 		// 否则在 unsubscribe 时，当前工作快照会被原地污染，出现幽灵 observer / 重复 observer。
 		t.workingObserverList = append([]ITimelineObserver(nil), t.registedObserverList...)
+		t.workingObserverStatList = append([]*timelineObserverStat(nil), t.registedObserverStatList...)
 		t.isChanged.Set(false)
 		t.rwLock.Unlock()
 	}
@@ -170,18 +310,74 @@ func (t *timeline) _notifyRegistedObserver(deltaMS float64) {
 
 	// 通知所有一直在订阅的observer
 	workingObserverList := t.workingObserverList[:]
-	for _, eachObserver := range workingObserverList {
+	workingObserverStatList := t.workingObserverStatList[:]
+	for i, eachObserver := range workingObserverList {
+		workingObserverStatList[i].called()
 		threadingx.RunSafe(func() {
 			eachObserver.OnNext(deltaMS)
 		})
 	}
 }
 
+// 场景计时器到期后的派发,逻辑线程暂停时暂存到恢复为止
+func (t *timeline) dispatchTimer(key string, recurring bool, observer ITimelineObserver) {
+	t.pauseLock.Lock()
+	if !t.holdingTimers {
+		t.pauseLock.Unlock()
+		t.enqueueOneTimeObserver(observer)
+		return
+	}
+	defer t.pauseLock.Unlock()
+	if recurring && len(key) > 0 {
+		for _, eachHeld := range t.heldTimerList {
+			if eachHeld.key == key {
+				// 循环计时器只保留一次派发,避免长时间暂停后集中执行
+				return
+			}
+		}
+	}
+	t.heldTimerList = append(t.heldTimerList, heldTimerDispatch{
+		key:       key,
+		recurring: recurring,
+		observer:  observer,
+	})
+}
+
+// 移除暂存的计时器派发
+func (t *timeline) dropHeldTimer(key string) {
+	t.pauseLock.Lock()
+	defer t.pauseLock.Unlock()
+	if len(t.heldTimerList) <= 0 {
+		return
+	}
+	heldTimerList := make([]heldTimerDispatch, 0, len(t.heldTimerList))
+	for _, eachHeld := range t.heldTimerList {
+		if eachHeld.key != key {
+			heldTimerList = append(heldTimerList, eachHeld)
+		}
+	}
+	t.heldTimerList = heldTimerList
+}
+
+// 暂存的计时器派发数量
+func (t *timeline) heldTimerCount() int {
+	t.pauseLock.Lock()
+	defer t.pauseLock.Unlock()
+	return len(t.heldTimerList)
+}
+
+func (t *timeline) enqueueOneTimeObserver(observer ITimelineObserver) {
+	atomic.AddInt64(&t.oneTimeQueueLength, 1)
+	t.registedOneTimeObserverQueue.Put(observer)
+	t.wake()
+}
+
 func (t *timeline) dequeueOneTimelineObserver() ITimelineObserver {
 	v, ok := t.registedOneTimeObserverQueue.Take()
 	if !ok {
 		return nil
 	}
+	atomic.AddInt64(&t.oneTimeQueueLength, -1)
 	observer := v.(ITimelineObserver)
 	return observer
 }
@@ -0,0 +1,221 @@
+package timelinex
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abmpio/timelinex/scheduler"
+)
+
+// 可选接口,observer实现此接口后可以在调试信息中显示描述
+type IDescription interface {
+	Description() string
+}
+
+// 时间轴的调试快照
+type TimelineDebugInfo struct {
+	Name string `json:"name"`
+	// 已经执行的帧数
+	FrameCount uint64 `json:"frameCount"`
+	// 最后一帧开始的时间
+	LastFrameTime time.Time `json:"lastFrameTime"`
+	// 最后一帧与上一帧相差的毫秒值
+	LastDeltaMS float64 `json:"lastDeltaMS"`
+	// 最后一帧通知所有observer所耗费的时间
+	LastTickDuration time.Duration `json:"lastTickDuration"`
+	// 一次性observer队列中等待执行的数量
+	OneTimeQueueLength int64                       `json:"oneTimeQueueLength"`
+	Observers          []TimelineObserverDebugInfo `json:"observers"`
+	// 场景计时器中有效的计时器
+	Timers []scheduler.TaskInfo `json:"timers"`
+}
+
+// 等待时间轴线程获取observer描述的最长时间
+const debugDescriptionTimeout = 200 * time.Millisecond
+
+// 订阅在时间轴上的observer的调试快照
+type TimelineObserverDebugInfo struct {
+	Type string `json:"type"`
+	// IDescription.Description的结果,在时间轴线程中获取,时间轴线程没有在超时时间内执行(如暂停或者没有运行)时为空
+	Description  string    `json:"description,omitempty"`
+	SubscribedAt time.Time `json:"subscribedAt"`
+	CallCount    int64     `json:"callCount"`
+}
+
+// 订阅的observer的统计信息
+type timelineObserverStat struct {
+	subscribedAt time.Time
+	callCount    int64
+}
+
+func newTimelineObserverStat() *timelineObserverStat {
+	return &timelineObserverStat{
+		subscribedAt: time.Now(),
+	}
+}
+
+func (s *timelineObserverStat) called() {
+	atomic.AddInt64(&s.callCount, 1)
+}
+
+// 帧的统计信息,在时间轴线程中写入,在其它协程中读取
+type timelineFrameStat struct {
+	rwLock           sync.RWMutex
+	frameCount       uint64
+	lastFrameTime    time.Time
+	lastDeltaMS      float64
+	lastTickDuration time.Duration
+}
+
+func (s *timelineFrameStat) record(frameTime time.Time, deltaMS float64, tickDuration time.Duration) {
+	s.rwLock.Lock()
+	defer s.rwLock.Unlock()
+	s.frameCount++
+	s.lastFrameTime = frameTime
+	s.lastDeltaMS = deltaMS
+	s.lastTickDuration = tickDuration
+}
+
+// 获取时间轴的调试快照
+func (t *timeline) debugInfo(name string) TimelineDebugInfo {
+	info := TimelineDebugInfo{
+		Name:               name,
+		OneTimeQueueLength: atomic.LoadInt64(&t.oneTimeQueueLength),
+		Observers:          make([]TimelineObserverDebugInfo, 0),
+		Timers:             make([]scheduler.TaskInfo, 0),
+	}
+	t.frameStat.rwLock.RLock()
+	info.FrameCount = t.frameStat.frameCount
+	info.LastFrameTime = t.frameStat.lastFrameTime
+	info.LastDeltaMS = t.frameStat.lastDeltaMS
+	info.LastTickDuration = t.frameStat.lastTickDuration
+	t.frameStat.rwLock.RUnlock()
+
+	t.rwLock.RLock()
+	describedList := make(map[int]IDescription)
+	for i, eachObserver := range t.registedObserverList {
+		stat := t.registedObserverStatList[i]
+		info.Observers = append(info.Observers, TimelineObserverDebugInfo{
+			Type:         fmt.Sprintf("%T", eachObserver),
+			SubscribedAt: stat.subscribedAt,
+			CallCount:    atomic.LoadInt64(&stat.callCount),
+		})
+		if d, ok := eachObserver.(IDescription); ok {
+			describedList[i] = d
+		}
+	}
+	t.rwLock.RUnlock()
+
+	for i, description := range t.describe(describedList) {
+		info.Observers[i].Description = description
+	}
+
+	if timer, ok := t.scenseTimer.(*sceneTimer); ok && timer != nil {
+		info.Timers = append(info.Timers, timer.taskScheduler.Tasks()...)
+	}
+	return info
+}
+
+// 在时间轴线程中调用observer的Description,observer只允许在时间轴线程中访问
+// 时间轴已经关闭、暂停、没有由逻辑线程驱动,或者已经有请求在等待时不获取,超时时返回nil
+func (t *timeline) describe(describedList map[int]IDescription) map[int]string {
+	if len(describedList) <= 0 {
+		return nil
+	}
+	collect := func() map[int]string {
+		result := make(map[int]string, len(describedList))
+		for i, d := range describedList {
+			result[i] = d.Description()
+		}
+		return result
+	}
+	if t.logicThread != nil && t.logicThread.IsCurrent() {
+		return collect()
+	}
+	if t.closed.Get() || t.logicThread == nil || !t.logicThread.IsRunning() || t.logicThread.Paused() {
+		// 放入的一次性observer不会被执行,只会留在队列中
+		return nil
+	}
+	if !t.describing.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	resultChan := make(chan map[int]string, 1)
+	var abandoned atomic.Bool
+	t.SubscribeAsOneTime(Observer(func() {
+		defer t.describing.Store(false)
+		if abandoned.Load() {
+			// 请求已经超时
+			return
+		}
+		resultChan <- collect()
+	}), nil)
+	select {
+	case result := <-resultChan:
+		return result
+	case <-time.After(debugDescriptionTimeout):
+		abandoned.Store(true)
+		return nil
+	}
+}
+
+// 所有需要在调试信息中显示的时间轴
+type timelineRegistry struct {
+	rwLock       sync.RWMutex
+	timelineList map[*timeline]string
+}
+
+var _timelineRegistry = &timelineRegistry{
+	timelineList: make(map[*timeline]string),
+}
+
+func registerTimeline(name string, t ITimeline) {
+	tl, ok := t.(*timeline)
+	if !ok {
+		return
+	}
+	_timelineRegistry.rwLock.Lock()
+	defer _timelineRegistry.rwLock.Unlock()
+	_timelineRegistry.timelineList[tl] = name
+}
+
+func unregisterTimeline(t ITimeline) {
+	tl, ok := t.(*timeline)
+	if !ok {
+		return
+	}
+	_timelineRegistry.rwLock.Lock()
+	defer _timelineRegistry.rwLock.Unlock()
+	delete(_timelineRegistry.timelineList, tl)
+}
+
+// 获取所有时间轴的调试快照,按名称排序
+func DebugSnapshot() []TimelineDebugInfo {
+	_timelineRegistry.rwLock.RLock()
+	nameList := make(map[*timeline]string, len(_timelineRegistry.timelineList))
+	for tl, name := range _timelineRegistry.timelineList {
+		nameList[tl] = name
+	}
+	_timelineRegistry.rwLock.RUnlock()
+
+	// 每个时间轴都可能需要等待其时间轴线程,并行获取
+	result := make([]TimelineDebugInfo, len(nameList))
+	var wg sync.WaitGroup
+	i := 0
+	for tl, name := range nameList {
+		wg.Add(1)
+		go func(index int, tl *timeline, name string) {
+			defer wg.Done()
+			result[index] = tl.debugInfo(name)
+		}(i, tl, name)
+		i++
+	}
+	wg.Wait()
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
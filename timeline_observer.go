@@ -6,6 +6,13 @@ type ITimelineObserver interface {
 	OnNext(deltaMS float64)
 }
 
+// 可选接口,observer实现此接口后可以告诉时间轴其是否处于空闲状态
+// 所有的observer都处于空闲状态时,启用了空闲降频的逻辑线程将降低帧率
+type IIdleTimelineObserver interface {
+	// 是否处于空闲状态,运行在时间轴线程中
+	IsIdle() bool
+}
+
 var _ ITimelineObserver = (*DefaultTimelineObserver)(nil)
 var _ ITimelineObserver = (*ActionTimelineObserver)(nil)
 var _ ITimelineObserver = (*ActionWithTimelineObserver[any])(nil)
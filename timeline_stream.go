@@ -0,0 +1,350 @@
+package timelinex
+
+import "time"
+
+// 基于时间轴轮循通知的数据流,通过组合操作符构建,最终通过Subscribe组合成一个observer订阅到时间轴中
+// Stream只是对数据流的描述,每次Subscribe都会创建独立的运行状态,所有回调都运行在时间轴线程中
+type Stream[T any] struct {
+	// emit 向下游发出一个值, complete 通知下游此数据流已经完成
+	build func(c *streamContext, emit func(T), complete func())
+}
+
+// 一次订阅的运行上下文
+type streamContext struct {
+	// 从订阅开始累计的毫秒值,时间相关的操作符以此为时钟
+	elapsedMS       float64
+	tickHandlerList []func(deltaMS float64)
+	// 最终的数据流是否已经完成
+	completed bool
+}
+
+// 注册每一帧的回调,按注册的先后顺序执行
+func (c *streamContext) onTick(handler func(deltaMS float64)) {
+	c.tickHandlerList = append(c.tickHandlerList, handler)
+}
+
+// 包装下游的回调,完成之后不再发出任何值,并保证complete只通知一次
+func guardStream[T any](emit func(T), complete func()) (func(T), func(), *bool) {
+	done := false
+	return func(v T) {
+			if !done {
+				emit(v)
+			}
+		}, func() {
+			if done {
+				return
+			}
+			done = true
+			complete()
+		}, &done
+}
+
+// 时间轴的每一帧产生一个值,值为与上一帧相差的毫秒值,此数据流不会完成
+func Ticks() Stream[float64] {
+	return Stream[float64]{
+		build: func(c *streamContext, emit func(float64), complete func()) {
+			c.onTick(func(deltaMS float64) {
+				emit(deltaMS)
+			})
+		},
+	}
+}
+
+// 只保留满足条件的值
+func (s Stream[T]) Filter(predicate func(T) bool) Stream[T] {
+	return Stream[T]{
+		build: func(c *streamContext, emit func(T), complete func()) {
+			s.build(c, func(v T) {
+				if predicate(v) {
+					emit(v)
+				}
+			}, complete)
+		},
+	}
+}
+
+// 转换每一个值
+func (s Stream[T]) Map(mapper func(T) T) Stream[T] {
+	return Stream[T]{
+		build: func(c *streamContext, emit func(T), complete func()) {
+			s.build(c, func(v T) {
+				emit(mapper(v))
+			}, complete)
+		},
+	}
+}
+
+// 只取前n个值,之后数据流完成
+func (s Stream[T]) Take(n int) Stream[T] {
+	return Stream[T]{
+		build: func(c *streamContext, emit func(T), complete func()) {
+			emit, complete, _ = guardStream(emit, complete)
+			if n <= 0 {
+				complete()
+				return
+			}
+			taken := 0
+			s.build(c, func(v T) {
+				taken++
+				emit(v)
+				if taken >= n {
+					complete()
+				}
+			}, complete)
+		},
+	}
+}
+
+// 跳过前n个值
+func (s Stream[T]) Skip(n int) Stream[T] {
+	return Stream[T]{
+		build: func(c *streamContext, emit func(T), complete func()) {
+			skipped := 0
+			s.build(c, func(v T) {
+				if skipped < n {
+					skipped++
+					return
+				}
+				emit(v)
+			}, complete)
+		},
+	}
+}
+
+// 在条件满足时一直取值,第一次不满足时数据流完成
+func (s Stream[T]) TakeWhile(predicate func(T) bool) Stream[T] {
+	return Stream[T]{
+		build: func(c *streamContext, emit func(T), complete func()) {
+			emit, complete, done := guardStream(emit, complete)
+			s.build(c, func(v T) {
+				if *done {
+					return
+				}
+				if !predicate(v) {
+					complete()
+					return
+				}
+				emit(v)
+			}, complete)
+		},
+	}
+}
+
+// 将每一个值延时d之后再发出,时间以时间轴的累计时间计算
+// 上游完成后,已经收到的值仍然会在到期后发出,之后数据流完成
+func (s Stream[T]) Delay(d time.Duration) Stream[T] {
+	delayMS := float64(d.Milliseconds())
+	return Stream[T]{
+		build: func(c *streamContext, emit func(T), complete func()) {
+			type delayedValue struct {
+				dueMS float64
+				value T
+			}
+			queue := make([]delayedValue, 0)
+			upstreamCompleted := false
+			s.build(c, func(v T) {
+				queue = append(queue, delayedValue{dueMS: c.elapsedMS + delayMS, value: v})
+			}, func() {
+				upstreamCompleted = true
+			})
+			c.onTick(func(deltaMS float64) {
+				for len(queue) > 0 && queue[0].dueMS <= c.elapsedMS {
+					v := queue[0].value
+					queue = queue[1:]
+					emit(v)
+				}
+				if upstreamCompleted && len(queue) <= 0 {
+					complete()
+				}
+			})
+		},
+	}
+}
+
+// 每隔d发出这段时间内最新的值,这段时间内没有值时不发出
+func (s Stream[T]) Sample(d time.Duration) Stream[T] {
+	periodMS := float64(d.Milliseconds())
+	return Stream[T]{
+		build: func(c *streamContext, emit func(T), complete func()) {
+			var latest T
+			hasValue := false
+			nextSampleMS := c.elapsedMS + periodMS
+			s.build(c, func(v T) {
+				latest = v
+				hasValue = true
+			}, complete)
+			c.onTick(func(deltaMS float64) {
+				if c.elapsedMS < nextSampleMS {
+					return
+				}
+				if periodMS <= 0 {
+					nextSampleMS = c.elapsedMS
+				}
+				for nextSampleMS <= c.elapsedMS && periodMS > 0 {
+					nextSampleMS += periodMS
+				}
+				if hasValue {
+					hasValue = false
+					emit(latest)
+				}
+			})
+		},
+	}
+}
+
+// 每收集n个值后作为一个切片发出,上游完成时发出剩余的值
+// n小于等于0时按1处理
+func Buffer[T any](s Stream[T], n int) Stream[[]T] {
+	if n <= 0 {
+		n = 1
+	}
+	return Stream[[]T]{
+		build: func(c *streamContext, emit func([]T), complete func()) {
+			buffer := make([]T, 0, n)
+			s.build(c, func(v T) {
+				buffer = append(buffer, v)
+				if len(buffer) >= n {
+					full := buffer
+					buffer = make([]T, 0, n)
+					emit(full)
+				}
+			}, func() {
+				if len(buffer) > 0 {
+					emit(buffer)
+				}
+				complete()
+			})
+		},
+	}
+}
+
+// 将每d时间内收到的值作为一个切片发出,没有值的窗口发出空切片
+func Window[T any](s Stream[T], d time.Duration) Stream[[]T] {
+	periodMS := float64(d.Milliseconds())
+	return Stream[[]T]{
+		build: func(c *streamContext, emit func([]T), complete func()) {
+			window := make([]T, 0)
+			windowEndMS := c.elapsedMS + periodMS
+			s.build(c, func(v T) {
+				window = append(window, v)
+			}, func() {
+				emit(window)
+				complete()
+			})
+			c.onTick(func(deltaMS float64) {
+				if c.elapsedMS < windowEndMS {
+					return
+				}
+				windowEndMS = c.elapsedMS + periodMS
+				closed := window
+				window = make([]T, 0)
+				emit(closed)
+			})
+		},
+	}
+}
+
+// 对每一个值进行累加,发出每一次累加的结果
+func Scan[T any, R any](s Stream[T], seed R, accumulator func(acc R, v T) R) Stream[R] {
+	return Stream[R]{
+		build: func(c *streamContext, emit func(R), complete func()) {
+			acc := seed
+			s.build(c, func(v T) {
+				acc = accumulator(acc, v)
+				emit(acc)
+			}, complete)
+		},
+	}
+}
+
+// 合并多个数据流,按照每一帧中各数据流的顺序发出,所有数据流都完成后才完成
+func Merge[T any](streams ...Stream[T]) Stream[T] {
+	return Stream[T]{
+		build: func(c *streamContext, emit func(T), complete func()) {
+			emit, complete, _ = guardStream(emit, complete)
+			if len(streams) <= 0 {
+				complete()
+				return
+			}
+			remaining := len(streams)
+			for _, eachStream := range streams {
+				_, branchComplete, _ := guardStream(emit, func() {
+					remaining--
+					if remaining <= 0 {
+						complete()
+					}
+				})
+				eachStream.build(c, emit, branchComplete)
+			}
+		},
+	}
+}
+
+// 将数据流组合成一个observer并订阅到时间轴中,数据流完成后自动取消订阅并调用onCompleted
+// 返回订阅的observer,可以通过ITimeline.Unsubscribe提前取消订阅
+func (s Stream[T]) Subscribe(timeline ITimeline, onNext func(T), onCompleted ...func()) ITimelineObserver {
+	o := &streamObserver{
+		timeline:        timeline,
+		c:               &streamContext{},
+		onCompletedList: onCompleted,
+	}
+	s.build(o.c, func(v T) {
+		if o.c.completed {
+			return
+		}
+		if onNext != nil {
+			onNext(v)
+		}
+	}, func() {
+		o.c.completed = true
+	})
+	if o.c.completed {
+		// 在构建时就已经完成,如Take(0)
+		o.finish()
+		return o
+	}
+	timeline.Subscribe(o)
+	return o
+}
+
+var _ ITimelineObserver = (*streamObserver)(nil)
+
+type streamObserver struct {
+	timeline        ITimeline
+	c               *streamContext
+	done            bool
+	onCompletedList []func()
+}
+
+// #region ITimelineObserver Members
+
+func (o *streamObserver) OnNext(deltaMS float64) {
+	if o.done {
+		return
+	}
+	o.c.elapsedMS += deltaMS
+	for _, eachHandler := range o.c.tickHandlerList {
+		eachHandler(deltaMS)
+		if o.c.completed {
+			break
+		}
+	}
+	if o.c.completed {
+		o.timeline.Unsubscribe(o)
+		o.finish()
+	}
+}
+
+// #endregion
+
+func (o *streamObserver) finish() {
+	if o.done {
+		return
+	}
+	o.done = true
+	for _, eachCallback := range o.onCompletedList {
+		if eachCallback != nil {
+			eachCallback()
+		}
+	}
+}
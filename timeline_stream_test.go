@@ -0,0 +1,102 @@
+package timelinex
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStreamTakeCompletesAndUnsubscribes(t *testing.T) {
+	tl := newTimeline()
+
+	values := make([]float64, 0)
+	completed := 0
+	Scan(Ticks().Skip(1), 0.0, func(acc float64, v float64) float64 {
+		return acc + v
+	}).Take(3).Subscribe(tl, func(v float64) {
+		values = append(values, v)
+	}, func() {
+		completed++
+	})
+
+	for i := 0; i < 6; i++ {
+		tl._notifyRegistedObserver(10)
+	}
+	if !reflect.DeepEqual(values, []float64{10, 20, 30}) {
+		t.Fatalf("unexpected values %v", values)
+	}
+	if completed != 1 {
+		t.Fatalf("expected completion callback once, got %d", completed)
+	}
+	if len(tl.registedObserverList) != 0 {
+		t.Fatalf("expected completed stream to unsubscribe, got %d observers", len(tl.registedObserverList))
+	}
+}
+
+func TestStreamTimeBasedOperators(t *testing.T) {
+	tl := newTimeline()
+
+	frame := 0
+	counter := Scan(Ticks(), 0, func(acc int, v float64) int {
+		return acc + 1
+	})
+
+	delayed := make([]int, 0)
+	counter.Delay(30*time.Millisecond).Subscribe(tl, func(v int) {
+		delayed = append(delayed, frame)
+	})
+	sampled := make([]int, 0)
+	counter.Sample(30*time.Millisecond).Subscribe(tl, func(v int) {
+		sampled = append(sampled, v)
+	})
+	windows := make([][]int, 0)
+	Window(counter, 20*time.Millisecond).Subscribe(tl, func(v []int) {
+		windows = append(windows, v)
+	})
+	buffers := make([][]int, 0)
+	Buffer(Merge(counter.Filter(func(v int) bool { return v%2 == 0 }), counter.TakeWhile(func(v int) bool { return v < 3 })), 2).
+		Subscribe(tl, func(v []int) {
+			buffers = append(buffers, v)
+		})
+
+	for frame = 1; frame <= 6; frame++ {
+		tl._notifyRegistedObserver(10)
+	}
+
+	// 第一帧的值在第四帧(累计40ms)时到期
+	if !reflect.DeepEqual(delayed, []int{4, 5, 6}) {
+		t.Fatalf("unexpected delayed frames %v", delayed)
+	}
+	if !reflect.DeepEqual(sampled, []int{3, 6}) {
+		t.Fatalf("unexpected samples %v", sampled)
+	}
+	if !reflect.DeepEqual(windows, [][]int{{1, 2}, {3, 4}, {5, 6}}) {
+		t.Fatalf("unexpected windows %v", windows)
+	}
+	if !reflect.DeepEqual(buffers, [][]int{{1, 2}, {2, 4}}) {
+		t.Fatalf("unexpected buffers %v", buffers)
+	}
+}
+
+func TestStreamBufferClampsNonPositiveSize(t *testing.T) {
+	tl := newTimeline()
+
+	counter := Scan(Ticks(), 0, func(acc int, v float64) int {
+		return acc + 1
+	}).Take(2)
+	buffers := make([][]int, 0)
+	Buffer(counter, -1).Subscribe(tl, func(v []int) {
+		buffers = append(buffers, v)
+	})
+	zeroBuffers := make([][]int, 0)
+	Buffer(counter, 0).Subscribe(tl, func(v []int) {
+		zeroBuffers = append(zeroBuffers, v)
+	})
+
+	for i := 0; i < 3; i++ {
+		tl._notifyRegistedObserver(10)
+	}
+	if !reflect.DeepEqual(buffers, [][]int{{1}, {2}}) || !reflect.DeepEqual(zeroBuffers, [][]int{{1}, {2}}) {
+		t.Fatalf("expected buffers of one value, got %v and %v", buffers, zeroBuffers)
+	}
+}